@@ -1,5 +1,7 @@
 package ast
 
+import "github.com/Zac-Garby/radon/token"
+
 // A Node is the interface from which all AST nodes implement.
 type Node interface{}
 
@@ -8,6 +10,8 @@ type Node interface{}
 type Statement interface {
 	Node
 	Stmt()
+	Pos() token.Position
+	SetPos(token.Position)
 }
 
 // An Expression is a node which evaluates to a value, for example a
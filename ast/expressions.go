@@ -1,5 +1,7 @@
 package ast
 
+import "math/big"
+
 type expr struct{}
 
 func (e expr) Expr() {}
@@ -13,12 +15,25 @@ type (
 		Value string
 	}
 
-	// A Number represents a number literal.
+	// A Number represents a floating point number literal.
 	Number struct {
 		expr
 		Value float64
 	}
 
+	// An Integer represents an integer literal, which can be arbitrarily large.
+	Integer struct {
+		expr
+		Value *big.Int
+	}
+
+	// A Decimal represents a decimal literal, such as 12.34d. Value is the
+	// literal without the suffix or any underscores.
+	Decimal struct {
+		expr
+		Value string
+	}
+
 	// A Boolean represents a boolean literal.
 	Boolean struct {
 		expr
@@ -31,6 +46,14 @@ type (
 		Value string
 	}
 
+	// An Interpolation is a string literal with embedded expressions, such as
+	// "hello {name}". Parts alternates between String segments and the
+	// expressions between them, starting and ending with a String.
+	Interpolation struct {
+		expr
+		Parts []Expression
+	}
+
 	// Nil is the nil literal; the absence of a value.
 	Nil struct {
 		expr
@@ -42,10 +65,18 @@ type (
 		Value []Expression
 	}
 
-	// A Map is a hashmap.
+	// A Slice selects part of a sequence, as in xs[1:3]. It can only be written
+	// inside square brackets, and any of its parts can be left out, in which case
+	// they're nil.
+	Slice struct {
+		expr
+		Start, Stop, Step Expression
+	}
+
+	// A Map is a hashmap. Its pairs are in the order they're written in.
 	Map struct {
 		expr
-		Value map[Expression]Expression
+		Value []Pair
 	}
 
 	// A Block combines multiple statements into an expression.
@@ -69,10 +100,22 @@ type (
 	}
 
 	// A Call calls a function with the argument. If the argument is a tuple,
-	// each element of the tuple is passed as a separate argument.
+	// each element of the tuple is passed as a separate argument. Returns is
+	// only set when the call is the left-hand side of a function definition
+	// with an annotated return value, as in `double x -> number = x * 2`.
 	Call struct {
 		expr
-		Function, Argument Expression
+		Function, Argument, Returns Expression
+	}
+
+	// A Keyword is a named argument in a function call, e.g. `greeting: "hi"`
+	// in `greet "bob", greeting: "hi"`. Keywords are always the last elements
+	// of a Call's argument. In a function definition, or on the left of an
+	// assignment, a Keyword is instead an annotated name, e.g. `x: number`.
+	Keyword struct {
+		expr
+		Name  string
+		Value Expression
 	}
 
 	// An If expression executes Consequence or Alternative based on Condition.
@@ -82,9 +125,11 @@ type (
 	}
 
 	// A MatchBranch is a condition -> body branch for use in a Match expression.
-	// Notice a MatchBranch isn't an Expression itself.
+	// The condition is a pattern, and Guard, if not nil, is an extra condition
+	// which must also be true for the branch to match. Notice a MatchBranch
+	// isn't an Expression itself.
 	MatchBranch struct {
-		Condition, Body Expression
+		Condition, Guard, Body Expression
 	}
 
 	// A Match executes a different piece of code based on the input value.
@@ -100,4 +145,18 @@ type (
 		expr
 		Parameters, Parent Expression
 	}
+
+	// A Protocol expression defines a protocol. Methods lists the methods it
+	// requires, separated by commas or as the lines of a block. Each method is
+	// either just a name, to allow any amount of arguments, or a name called
+	// with its parameters, as in `protocol do area (); scale factor end`.
+	Protocol struct {
+		expr
+		Methods Expression
+	}
 )
+
+// A Pair is a key and its value in a Map.
+type Pair struct {
+	Key, Value Expression
+}
@@ -1,9 +1,24 @@
 package ast
 
-type stmt struct{}
+import "github.com/Zac-Garby/radon/token"
+
+// stmt is embedded in every statement, and records where the statement starts.
+type stmt struct {
+	start token.Position
+}
 
 func (s stmt) Stmt() {}
 
+// Pos returns the position of the first token of the statement.
+func (s *stmt) Pos() token.Position {
+	return s.start
+}
+
+// SetPos sets the position of the first token of the statement.
+func (s *stmt) SetPos(pos token.Position) {
+	s.start = pos
+}
+
 type (
 	// An ExpressionStatement is an expression which can take the place of a
 	// statement.
@@ -52,4 +67,12 @@ type (
 		stmt
 		Names Expression
 	}
+
+	// A Const statement declares Target as a constant, which can't be reassigned,
+	// with the value Value. Target can be anything which can be declared to,
+	// including an annotated name or a function definition.
+	Const struct {
+		stmt
+		Target, Value Expression
+	}
 )
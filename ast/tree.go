@@ -106,11 +106,13 @@ func Tree(node Node, indent int, name string) string {
 			}
 
 			for _, branch := range n {
-				str += fmt.Sprintf("%s\n%s\n%s\n",
-					in(indent),
-					Tree(branch.Condition, indent+1, "cond"),
-					Tree(branch.Body, indent+1, "body"),
-				)
+				str += fmt.Sprintf("%s\n%s\n", in(indent), Tree(branch.Condition, indent+1, "cond"))
+
+				if branch.Guard != nil {
+					str += Tree(branch.Guard, indent+1, "guard") + "\n"
+				}
+
+				str += Tree(branch.Body, indent+1, "body") + "\n"
 			}
 
 			return str + "\n" + in(indent) + "]"
@@ -16,8 +16,10 @@ var Instructions = map[byte]Data{
 	LoadName:       {Name: "LOAD_NAME", HasArg: true},
 	StoreName:      {Name: "STORE_NAME", HasArg: true},
 	DeclareName:    {Name: "DECLARE_NAME", HasArg: true},
+	DeclareConst:   {Name: "DECLARE_CONST", HasArg: true},
 	LoadSubscript:  {Name: "LOAD_SUBSCRIPT"},
 	StoreSubscript: {Name: "STORE_SUBSCRIPT"},
+	Pop:            {Name: "POP"},
 
 	UnaryInvert:    {Name: "UNARY_INVERT"},
 	UnaryNegate:    {Name: "UNARY_NEGATE"},
@@ -40,28 +42,43 @@ var Instructions = map[byte]Data{
 	BinaryLessEq:   {Name: "BINARY_LESS_EQ"},
 	BinaryMoreEq:   {Name: "BINARY_MORE_EQ"},
 	BinaryTuple:    {Name: "BINARY_TUPLE"},
+	BinaryIn:       {Name: "BINARY_IN"},
+	BinaryNotIn:    {Name: "BINARY_NOT_IN"},
 
-	CallFunction: {Name: "CALL_FUNCTION", HasArg: true},
-	Return:       {Name: "RETURN"},
-	PushScope:    {Name: "PUSH_SCOPE"},
-	PopScope:     {Name: "POP_SCOPE"},
-	Export:       {Name: "EXPORT", HasArg: true},
+	CallFunction:    {Name: "CALL_FUNCTION", HasArg: true},
+	CallKeywords:    {Name: "CALL_KEYWORDS", HasArg: true},
+	BindDefaults:    {Name: "BIND_DEFAULTS", HasArg: true},
+	BindAnnotations: {Name: "BIND_ANNOTATIONS"},
+	CheckAnnotation: {Name: "CHECK_ANNOTATION", HasArg: true},
+	MakeModel:       {Name: "MAKE_MODEL"},
+	MakeInstance:    {Name: "MAKE_INSTANCE", HasArg: true},
+	Return:          {Name: "RETURN"},
+	PushScope:       {Name: "PUSH_SCOPE"},
+	PopScope:        {Name: "POP_SCOPE"},
+	Export:          {Name: "EXPORT", HasArg: true},
 
-	Jump:        {Name: "JUMP", HasArg: true},
-	JumpIf:      {Name: "JUMP_IF", HasArg: true},
-	JumpUnless:  {Name: "JUMP_UNLESS", HasArg: true},
-	StartMatch:  {Name: "START_MATCH"},
-	EndMatch:    {Name: "END_MATCH"},
-	StartBranch: {Name: "START_BRANCH"},
-	EndBranch:   {Name: "END_BRANCH"},
-	Break:       {Name: "BREAK"},
-	Next:        {Name: "NEXT"},
-	StartLoop:   {Name: "START_LOOP"},
-	EndLoop:     {Name: "END_LOOP"},
-	PushIter:    {Name: "PUSH_ITER"},
-	PopIter:     {Name: "POP_ITER"},
-	AdvIterFor:  {Name: "ADV_ITER_FOR", HasArg: true},
+	Jump:           {Name: "JUMP", HasArg: true},
+	JumpIf:         {Name: "JUMP_IF", HasArg: true},
+	JumpUnless:     {Name: "JUMP_UNLESS", HasArg: true},
+	StartMatch:     {Name: "START_MATCH", HasArg: true},
+	EndMatch:       {Name: "END_MATCH"},
+	LoadMatchInput: {Name: "LOAD_MATCH_INPUT"},
+	MatchValue:     {Name: "MATCH_VALUE", HasArg: true},
+	MatchType:      {Name: "MATCH_TYPE", HasArg: true},
+	MatchSequence:  {Name: "MATCH_SEQUENCE", HasArg: true},
+	MatchKey:       {Name: "MATCH_KEY", HasArg: true},
+	Break:          {Name: "BREAK"},
+	Next:           {Name: "NEXT"},
+	StartLoop:      {Name: "START_LOOP", HasArg: true},
+	EndLoop:        {Name: "END_LOOP"},
+	PushIter:       {Name: "PUSH_ITER"},
+	PopIter:        {Name: "POP_ITER"},
+	AdvIterFor:     {Name: "ADV_ITER_FOR"},
 
-	MakeList: {Name: "MAKE_LIST", HasArg: true},
-	MakeMap:  {Name: "MAKE_MAP", HasArg: true},
+	MakeList:  {Name: "MAKE_LIST", HasArg: true},
+	MakeMap:   {Name: "MAKE_MAP", HasArg: true},
+	MakeSlice: {Name: "MAKE_SLICE"},
+
+	UnpackSequence: {Name: "UNPACK_SEQUENCE", HasArg: true},
+	UnpackKey:      {Name: "UNPACK_KEY"},
 }
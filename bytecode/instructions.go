@@ -22,12 +22,19 @@ const (
 	// the single enclosing scope, not the parent ones
 	DeclareName
 
+	// DeclareConst is the same as DeclareName, but makes the variable a
+	// constant, which can't be reassigned
+	DeclareConst
+
 	// LoadSubscript pushes $1[$0]
 	LoadSubscript
 
 	// StoreSubscript sets $1[$0] to $2
 	StoreSubscript
 
+	// Pop pops $0, discarding it
+	Pop
+
 	/* Operators */
 	UnaryInvert
 	UnaryNegate
@@ -51,10 +58,42 @@ const (
 	BinaryMoreEq
 	BinaryTuple
 
+	// BinaryIn pushes whether $0 contains $1
+	BinaryIn
+
+	// BinaryNotIn pushes whether $0 doesn't contain $1
+	BinaryNotIn
+
 	/* Functions & scopes */
 	// CallFunctions calls $0 and pops an item for each argument
 	CallFunction
 
+	// CallKeywords calls $0 with keyword arguments. $1 is a tuple of the keywords'
+	// names, below which is each keyword's value, followed by the [arg] positional
+	// arguments
+	CallKeywords
+
+	// MakeModel pops the function $1, and $0, which is either nil or a model, and
+	// pushes a new model with $1 as its Init and $0 as its parent
+	MakeModel
+
+	// MakeInstance pops the model $0 and [arg] key/value pairs, then sets __model
+	// and each pair in the map below them
+	MakeInstance
+
+	// BindDefaults pops the function $0 and [arg] default values for its last
+	// parameters, pushing a copy of the function with those defaults
+	BindDefaults
+
+	// BindAnnotations pops a tuple of parameter names $0, an annotation for each
+	// of them, and the function below them, pushing a copy of the function with
+	// those annotations. The return value's annotation is named return
+	BindAnnotations
+
+	// CheckAnnotation pops the annotation $0 and checks that $1, which is about to
+	// be stored in the variable names[arg], matches it
+	CheckAnnotation
+
 	Return
 	PushScope
 	PopScope
@@ -74,21 +113,40 @@ const (
 	JumpUnless
 
 	/* Matches */
-	// StartMatch begins a match block, pushing $0 to the match-value register
+	// The match instructions below jump to target [arg] if the pattern fails to
+	// match, first restoring the data stack to how it was after StartMatch
+
+	// StartMatch begins a match block, pushing $0 to the match-value register.
+	// [arg] is the jump target of the corresponding EndMatch
 	StartMatch
 
 	// EndMatch ends a match block, popping the match-value register
 	EndMatch
 
-	// StartBranch specifies the start of a match branch
-	StartBranch
+	// LoadMatchInput pushes the value in the match-value register
+	LoadMatchInput
+
+	// MatchValue pops $0 and $1, and fails unless they're equal
+	MatchValue
+
+	// MatchType pops $0, and fails unless $1 is of the type named by $0
+	MatchType
+
+	// MatchSequence pops $0 (whether the pattern has a rest element), $1 (the amount
+	// of other elements) and $2, and fails unless $2 has that many items, or at least
+	// that many if there's a rest element. The rest element, if any, then the items
+	// in reverse order, are pushed, so the first item is on top
+	MatchSequence
 
-	// EndBranch specifies the end of a match branch
-	EndBranch
+	// MatchKey pops $0, and fails unless $1 is a map containing the key $0, pushing
+	// the corresponding value otherwise
+	MatchKey
 
 	/* Loop stuff */
 	Break
 	Next
+
+	// StartLoop begins a loop. [arg] is the jump target of the corresponding EndLoop
 	StartLoop
 	EndLoop
 
@@ -100,7 +158,7 @@ const (
 	PopIter
 
 	// AdvIterFor advances the top iterable on the iterable stack. If nothing left, breaks
-	// out of the loop. Designed for use in for-loops. Will also assign the top iter to [arg]
+	// out of the loop. Designed for use in for-loops. Otherwise, pushes the next item
 	AdvIterFor
 
 	/* Data */
@@ -109,4 +167,16 @@ const (
 
 	// MakeMap pushes a map from the top [arg]*2 items, in key, val order
 	MakeMap
+
+	// MakeSlice pushes the slice $2:$1:$0. Each of $2, $1 and $0 must be an
+	// integer, or nil if it was left out
+	MakeSlice
+
+	// UnpackSequence pops $0 and pushes its items in reverse order, so the first
+	// is on top. $0 must have exactly [arg] items
+	UnpackSequence
+
+	// UnpackKey pops $0 and pushes $1[$0], leaving $1, which must be a map
+	// containing the key $0
+	UnpackKey
 )
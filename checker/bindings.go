@@ -0,0 +1,205 @@
+package checker
+
+import (
+	"github.com/Zac-Garby/radon/ast"
+)
+
+// A bindingScope counts how many times each name is bound in one of the scopes
+// which the checker makes: the program itself, and each function, for loop, match
+// branch and model inside it.
+type bindingScope struct {
+	counts    map[string]int
+	enclosing *bindingScope
+}
+
+// An assignment is a name bound in a scope with =, which assigns to a variable of
+// the same name in an enclosing scope instead, if there is one.
+type assignment struct {
+	scope *bindingScope
+	name  string
+}
+
+// A bindingCounter counts the bindings in each scope of a program.
+type bindingCounter struct {
+	scopes      map[ast.Node]map[string]int
+	total       map[string]int
+	assignments []assignment
+}
+
+// countBindings counts how many times each name is bound in each scope of a
+// program, by being assigned, declared, or used as a parameter, a loop variable or
+// in a pattern. The scopes are keyed by the node which makes them: the program, the
+// left-hand side of a function definition, a for loop, a match branch or a model.
+// The number of times each name is bound in the whole program is also returned.
+//
+// Assigning to a name with = also counts as binding it in each enclosing scope
+// which binds it, since the assignment could change that scope's variable.
+func countBindings(prog *ast.Program) (map[ast.Node]map[string]int, map[string]int) {
+	b := &bindingCounter{
+		scopes: make(map[ast.Node]map[string]int),
+		total:  make(map[string]int),
+	}
+
+	top := b.push(prog, nil)
+
+	for _, stmt := range prog.Statements {
+		b.scan(stmt, top)
+	}
+
+	// Every enclosing binding is found before any are added, so that assignments
+	// only count in scopes which bind the name themselves.
+	var enclosing []assignment
+
+	for _, a := range b.assignments {
+		for s := a.scope.enclosing; s != nil; s = s.enclosing {
+			if s.counts[a.name] > 0 {
+				enclosing = append(enclosing, assignment{scope: s, name: a.name})
+			}
+		}
+	}
+
+	for _, a := range enclosing {
+		a.scope.counts[a.name]++
+	}
+
+	return b.scopes, b.total
+}
+
+// push makes the scope for node, inside enclosing.
+func (b *bindingCounter) push(node ast.Node, enclosing *bindingScope) *bindingScope {
+	s := &bindingScope{
+		counts:    make(map[string]int),
+		enclosing: enclosing,
+	}
+
+	b.scopes[node] = s.counts
+
+	return s
+}
+
+// bind counts a binding of name in s. If assigned is true, it was bound with =.
+func (b *bindingCounter) bind(s *bindingScope, name string, assigned bool) {
+	s.counts[name]++
+	b.total[name]++
+
+	if assigned {
+		b.assignments = append(b.assignments, assignment{scope: s, name: name})
+	}
+}
+
+// bindAll counts a binding in s of every name in target.
+func (b *bindingCounter) bindAll(s *bindingScope, target ast.Node, assigned bool) {
+	walkTargets(target, func(id *ast.Identifier) {
+		b.bind(s, id.Value, assigned)
+	})
+}
+
+// scan counts the bindings in node, which is in the scope s.
+func (b *bindingCounter) scan(node ast.Node, s *bindingScope) {
+	switch n := node.(type) {
+	case nil:
+		return
+
+	case *ast.Infix:
+		if n.Operator == "=" || n.Operator == ":=" {
+			b.scanAssign(n.Left, n.Right, s, n.Operator == "=")
+			return
+		}
+
+	case *ast.Const:
+		b.scanAssign(n.Target, n.Value, s, false)
+		return
+
+	case *ast.Export:
+		if s.enclosing != nil {
+			b.bindAll(s.enclosing, n.Names, true)
+		}
+
+		return
+
+	case *ast.For:
+		b.scan(n.Collection, s)
+
+		inner := b.push(n, s)
+		b.bindAll(inner, n.Var, false)
+		b.scan(n.Body, inner)
+
+		return
+
+	case *ast.Match:
+		b.scan(n.Input, s)
+
+		for i := range n.Branches {
+			var (
+				branch = &n.Branches[i]
+				inner  = b.push(branch, s)
+			)
+
+			b.bindAll(inner, branch.Condition, false)
+			b.scan(branch.Guard, inner)
+			b.scan(branch.Body, inner)
+		}
+
+		return
+
+	case *ast.Model:
+		inner := b.push(n, s)
+		b.bindAll(inner, n.Parameters, false)
+		b.scan(n.Parent, inner)
+
+		return
+	}
+
+	for _, child := range children(node) {
+		b.scan(child, s)
+	}
+}
+
+// scanAssign counts the bindings made by assigning right to left in the scope s.
+// If assigned is true, the assignment uses =.
+func (b *bindingCounter) scanAssign(left, right ast.Expression, s *bindingScope, assigned bool) {
+	switch target := left.(type) {
+	case *ast.Keyword:
+		b.bind(s, target.Name, assigned)
+		b.scan(target.Value, s)
+		b.scan(right, s)
+
+		return
+
+	case *ast.Call:
+		if _, isIndex := target.Argument.(*ast.List); isIndex {
+			break
+		}
+
+		b.bindAll(s, target.Function, assigned)
+
+		inner := b.push(target, s)
+
+		// Annotations aren't bound, so only the names of the parameters are
+		// counted
+		for _, param := range parameters(target.Argument) {
+			if inf, ok := param.(*ast.Infix); ok && inf.Operator == "=" {
+				param = inf.Left
+				b.scan(inf.Right, inner)
+			}
+
+			switch p := param.(type) {
+			case *ast.Keyword:
+				b.bind(inner, p.Name, false)
+
+			case *ast.Prefix:
+				b.bindAll(inner, p.Right, false)
+
+			default:
+				b.bindAll(inner, p, false)
+			}
+		}
+
+		b.scan(right, inner)
+
+		return
+	}
+
+	b.bindAll(s, left, assigned)
+	b.scan(right, s)
+}
@@ -0,0 +1,232 @@
+// Package checker statically checks Radon programs against their type annotations.
+//
+// The checker infers the types of expressions where it can, such as literals,
+// annotated variables and calls to functions with annotated return values, and
+// reports a problem wherever an inferred type definitely doesn't match what's
+// expected. Anything it can't infer is assumed to be fine, so unannotated code
+// never causes a problem, except for operators which can never be applied to the
+// types of their operands.
+//
+// The type of an unannotated variable is only inferred if its name is bound exactly
+// once in its scope, counting assignments in nested scopes which could change it.
+// Names are assumed to refer to the scopes they're written in, so assignments made
+// by a function to a variable of the function which calls it aren't accounted for.
+package checker
+
+import (
+	"fmt"
+
+	"github.com/Zac-Garby/radon/ast"
+	"github.com/Zac-Garby/radon/object"
+	"github.com/Zac-Garby/radon/token"
+)
+
+// unknown is the type of an expression whose type can't be inferred.
+const unknown object.Type = ""
+
+// A Problem is a place in a program where a type doesn't match an annotation,
+// or where an operator is applied to types which it can never be applied to.
+type Problem struct {
+	Message string
+	Pos     token.Position
+}
+
+// Error returns a string representation of a Problem, to comply with the error
+// interface.
+func (p *Problem) Error() string {
+	return fmt.Sprintf("** Check error ~ [%s] %s", p.Pos.String(), p.Message)
+}
+
+// A signature describes the parameters and return value of a function.
+type signature struct {
+	name     string
+	params   []string
+	types    map[string]object.Type
+	required int
+	rest     bool
+	returns  object.Type
+}
+
+// A scope contains the types of the variables defined in it. A variable whose type
+// can't be inferred is still defined, as unknown, so it shadows any variables with
+// the same name in enclosing scopes.
+//
+// bindings counts how many times each name is bound in the scope. Since variables
+// can be assigned more than once, the inferred type of an unannotated variable is
+// only trusted if it's bound exactly once.
+type scope struct {
+	vars       map[string]object.Type
+	annotated  map[string]bool
+	signatures map[string]*signature
+	bindings   map[string]int
+	enclosing  *scope
+}
+
+type checker struct {
+	scope    *scope
+	pos      token.Position
+	returns  []object.Type
+	problems []*Problem
+
+	// scopeBindings holds the binding counts of each scope, keyed by the node which
+	// makes it, and bindings counts how many times each name is bound anywhere in
+	// the program.
+	scopeBindings map[ast.Node]map[string]int
+	bindings      map[string]int
+
+	// models holds the types which are the names of models used as annotations.
+	models map[object.Type]bool
+}
+
+// Check checks a program, returning every problem it finds.
+func Check(prog *ast.Program) []*Problem {
+	c := &checker{models: make(map[object.Type]bool)}
+	c.scopeBindings, c.bindings = countBindings(prog)
+
+	c.pushScope(prog)
+
+	for _, stmt := range prog.Statements {
+		c.statement(stmt)
+	}
+
+	return c.problems
+}
+
+func (c *checker) report(format string, args ...interface{}) {
+	c.problems = append(c.problems, &Problem{
+		Message: fmt.Sprintf(format, args...),
+		Pos:     c.pos,
+	})
+}
+
+// pushScope enters the scope made by node.
+func (c *checker) pushScope(node ast.Node) {
+	c.scope = &scope{
+		vars:       make(map[string]object.Type),
+		annotated:  make(map[string]bool),
+		signatures: make(map[string]*signature),
+		bindings:   c.scopeBindings[node],
+		enclosing:  c.scope,
+	}
+}
+
+func (c *checker) popScope() {
+	c.scope = c.scope.enclosing
+}
+
+// lookup finds the scope in which name is defined, or nil if it isn't.
+func (c *checker) lookup(name string) *scope {
+	for s := c.scope; s != nil; s = s.enclosing {
+		if _, ok := s.vars[name]; ok {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// typeOf returns the type of the variable name.
+func (c *checker) typeOf(name string) object.Type {
+	if s := c.lookup(name); s != nil {
+		return s.vars[name]
+	}
+
+	if _, ok := object.Builtins[name]; ok && c.bindings[name] == 0 {
+		return object.BuiltinType
+	}
+
+	if _, ok := object.Modules[name]; ok && c.bindings[name] == 0 {
+		return object.MapType
+	}
+
+	return unknown
+}
+
+// define defines name in the current scope, as a variable of type t.
+func (c *checker) define(name string, t object.Type) {
+	c.scope.vars[name] = t
+	delete(c.scope.annotated, name)
+	delete(c.scope.signatures, name)
+}
+
+// annotate defines name in the current scope, as a variable annotated with t.
+func (c *checker) annotate(name string, t object.Type) {
+	c.define(name, t)
+	c.scope.annotated[name] = true
+}
+
+// assign records that a value of type t has been assigned to name. If name is an
+// annotated variable, t is checked against its annotation.
+func (c *checker) assign(name string, t object.Type) {
+	if s := c.lookup(name); s != nil && s.annotated[name] {
+		if !c.conforms(t, s.vars[name]) {
+			c.report("the variable %s is annotated as %s, but is assigned a value of type %s", name, s.vars[name], t)
+		}
+
+		return
+	}
+
+	if c.scope.bindings[name] == 1 {
+		c.define(name, t)
+	} else {
+		c.define(name, unknown)
+	}
+}
+
+// statement checks a statement, returning its type if it's an expression statement,
+// or unknown otherwise.
+func (c *checker) statement(stmt ast.Statement) object.Type {
+	defer func(pos token.Position) {
+		c.pos = pos
+	}(c.pos)
+
+	c.pos = stmt.Pos()
+
+	switch node := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return c.infer(node.Expr)
+
+	case *ast.Return:
+		t := c.infer(node.Value)
+
+		if len(c.returns) > 0 {
+			c.checkReturn(t)
+		}
+
+	case *ast.While:
+		c.infer(node.Condition)
+		c.infer(node.Body)
+
+	case *ast.Const:
+		return c.inferAssign(node.Target, node.Value)
+
+	case *ast.For:
+		c.infer(node.Collection)
+
+		c.pushScope(node)
+		c.defineTargets(node.Var)
+		c.infer(node.Body)
+		c.popScope()
+	}
+
+	return unknown
+}
+
+// checkReturn checks a value of type t returned from the function being checked.
+func (c *checker) checkReturn(t object.Type) {
+	expected := c.returns[len(c.returns)-1]
+
+	if !c.conforms(t, expected) {
+		c.report("a function annotated to return %s returns a value of type %s", expected, t)
+	}
+}
+
+// defineTargets defines each name in a destructuring target, or a pattern, as an
+// unknown variable in the current scope.
+func (c *checker) defineTargets(target ast.Expression) {
+	walkTargets(target, func(id *ast.Identifier) {
+		if id.Value != "_" {
+			c.define(id.Value, unknown)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package checker_test
+
+import (
+	"testing"
+
+	"github.com/Zac-Garby/radon/checker"
+	"github.com/Zac-Garby/radon/lexer"
+	"github.com/Zac-Garby/radon/parser"
+)
+
+func TestNoProblems(t *testing.T) {
+	tests := []string{
+		"add x: number, y: number -> number = x + y; add 1, 2.5",
+		"add x: number, y: number -> number = x + y; add 1, y: 2",
+		"add x: number, y: number -> number = x + y; n: integer = 1; add n, n",
+		"add x: number, y: number -> number = x + y; (add 1) 2",
+		`greet name: string, greeting: string = "hi" -> string = greeting + " " + name`,
+		`n: integer = 5; n = 6; s: string = "a" + str n`,
+		"x = 1; x = \"a\"; x - 1",
+		"f x = x - 1; f \"a\"",
+		"f x -> number = if x then 1 else 2.5",
+		"f x -> integer = do return 1 end",
+		"square = model side; area s: square -> number = s.side ^ 2",
+		"square = model side; area s: square = s.side; f sq: square = area sq",
+		"square = model side; area s: square = s.side; area {\"side\": 2}",
+		"shape = protocol area; f s: shape = s.area ()",
+		`match 5 where | integer n -> n + 1, | string s -> s + "!", | _ -> 0`,
+		"for x in [1, 2] do x - 1 end",
+		"(len [1]) + 1",
+		"[1] + [2]",
+		"v = {\"a\": 1}; v + 1",
+		"g x = x; g = 5; g - 1",
+
+		// Assignments in nested scopes could change the variable
+		`s = "a"; f x = do s = 5 end; s - 1`,
+		`s = "a"; for x in [1] do s = 5 end; s - 1`,
+		`s = "a"; f x = do export s end; s - 1`,
+	}
+
+	for _, test := range tests {
+		for _, problem := range check(t, test) {
+			t.Errorf("%s: unexpected problem: %s", test, problem)
+		}
+	}
+}
+
+func TestProblems(t *testing.T) {
+	tests := map[string]string{
+		`add x: number, y: number -> number = x + y; add "a", 2`:   "the argument for the parameter x of add has type string, but is annotated as number",
+		`add x: number, y: number -> number = x + y; add 1, y: ""`: "the argument for the parameter y of add has type string, but is annotated as number",
+		"add x: number, y: number = x + y; add 1, 2, 3":            "too many arguments passed to add. expected at most 2, got 3",
+		`n: integer = "five"`:             "the variable n is annotated as integer, but is assigned a value of type string",
+		`n: integer = 5; n = "five"`:      "the variable n is annotated as integer, but is assigned a value of type string",
+		`f x -> string = 5`:               "a function annotated to return string returns a value of type integer",
+		`f x -> string = do return 5 end`: "a function annotated to return string returns a value of type integer",
+		`f x: integer = "a" = x`:          "the default value of the parameter x has type string, but is annotated as integer",
+		`"a" - 1`:                         "cannot apply the operator - to string and integer",
+		`x = "a"; x - 1`:                  "cannot apply the operator - to string and integer",
+		`(len []) - "a"`:                  "cannot apply the operator - to integer and string",
+		`-"a"`:                            "cannot apply the operator - to string",
+		`f x: string = x * x`:             "cannot apply the operator * to string and string",
+		`match 5 where | integer n -> n - "a", | _ -> 0`:       "cannot apply the operator - to integer and string",
+		"square = model side; area s: square = s.side; area 5": "the argument for the parameter s of area has type integer, but is annotated as square",
+
+		// Binding the same name in another scope doesn't stop its type being inferred
+		`s = "a"; f s = s; s - 1`:                            "cannot apply the operator - to string and integer",
+		`g x = do s = "a"; s - 1 end; h x = do s = 5; s end`: "cannot apply the operator - to string and integer",
+		`s = "a"; f x = do s := 5 end; s - 1`:                "cannot apply the operator - to string and integer",
+
+		// A pinned name in a pattern isn't bound by it
+		`s = "a"; match 5 where | ^s -> s - 1, | _ -> 0`: "cannot apply the operator - to string and integer",
+	}
+
+	for test, expected := range tests {
+		problems := check(t, test)
+
+		if len(problems) != 1 {
+			t.Errorf("%s: expected exactly one problem, got %v", test, problems)
+			continue
+		}
+
+		if problems[0].Message != expected {
+			t.Errorf("%s: expected `%s`, got `%s`", test, expected, problems[0].Message)
+		}
+	}
+}
+
+func TestProblemPositions(t *testing.T) {
+	problems := check(t, "a = 1\nb = do\n  c = \"x\" - 1\nend")
+
+	if len(problems) != 1 {
+		t.Fatalf("expected exactly one problem, got %v", problems)
+	}
+
+	if pos := problems[0].Pos; pos.Line != 3 || pos.Column != 3 {
+		t.Errorf("expected the problem to be at 3:3, got %s", pos.String())
+	}
+}
+
+func check(t *testing.T, code string) []*checker.Problem {
+	prog, err := parser.New(lexer.Lexer(code, "test")).Parse()
+	if err != nil {
+		t.Fatalf("%s: %s", code, err)
+	}
+
+	return checker.Check(prog)
+}
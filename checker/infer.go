@@ -0,0 +1,410 @@
+package checker
+
+import (
+	"github.com/Zac-Garby/radon/ast"
+	"github.com/Zac-Garby/radon/compiler"
+	"github.com/Zac-Garby/radon/object"
+)
+
+// infer checks an expression, returning its type, or unknown if it can't be
+// inferred.
+func (c *checker) infer(e ast.Expression) object.Type {
+	switch node := e.(type) {
+	case nil:
+		return object.NilType
+	case *ast.Number:
+		return object.NumberType
+	case *ast.Integer:
+		return object.IntegerType
+	case *ast.Decimal:
+		return object.DecimalType
+	case *ast.Boolean:
+		return object.BooleanType
+	case *ast.String:
+		return object.StringType
+	case *ast.Nil:
+		return object.NilType
+	case *ast.Identifier:
+		return c.typeOf(node.Value)
+	case *ast.Interpolation:
+		for _, part := range node.Parts {
+			c.infer(part)
+		}
+
+		return object.StringType
+	case *ast.List:
+		for _, elem := range node.Value {
+			c.infer(elem)
+		}
+
+		return object.ListType
+	case *ast.Slice:
+		for _, part := range []ast.Expression{node.Start, node.Stop, node.Step} {
+			if part != nil {
+				c.infer(part)
+			}
+		}
+
+		return object.SliceType
+	case *ast.Map:
+		for _, pair := range node.Value {
+			c.infer(pair.Key)
+			c.infer(pair.Value)
+		}
+
+		return object.MapType
+	case *ast.Block:
+		return c.inferBlock(node)
+	case *ast.Prefix:
+		return c.inferPrefix(node)
+	case *ast.Infix:
+		return c.inferInfix(node)
+	case *ast.Call:
+		return c.inferCall(node)
+	case *ast.If:
+		return c.inferIf(node)
+	case *ast.Match:
+		return c.inferMatch(node)
+	case *ast.Model:
+		return c.inferModel(node)
+	case *ast.Protocol:
+		return object.ProtocolType
+	}
+
+	return unknown
+}
+
+// inferBlock checks a block, whose type is the type of its last statement.
+func (c *checker) inferBlock(node *ast.Block) object.Type {
+	result := unknown
+
+	for _, stmt := range node.Value {
+		result = c.statement(stmt)
+	}
+
+	return result
+}
+
+func (c *checker) inferPrefix(node *ast.Prefix) object.Type {
+	right := c.infer(node.Right)
+
+	if node.Operator == "," {
+		return object.TupleType
+	}
+
+	result, ok := prefixResult(node.Operator, right)
+	if !ok {
+		c.report("cannot apply the operator %s to %s", node.Operator, right)
+	}
+
+	return result
+}
+
+func (c *checker) inferInfix(node *ast.Infix) object.Type {
+	switch node.Operator {
+	case "=", ":=":
+		return c.inferAssign(node.Left, node.Right)
+	case "|>":
+		return c.inferCall(&ast.Call{Function: node.Right, Argument: node.Left})
+	case "$":
+		return c.inferCall(&ast.Call{Function: node.Left, Argument: node.Right})
+	case ".":
+		c.infer(node.Left)
+		return unknown
+	}
+
+	left, right := c.infer(node.Left), c.infer(node.Right)
+
+	switch node.Operator {
+	case ",":
+		return object.TupleType
+	case "==", "!=", "implements", "in", "not in":
+		return object.BooleanType
+	}
+
+	result, ok := infixResult(node.Operator, left, right)
+	if !ok {
+		c.report("cannot apply the operator %s to %s and %s", node.Operator, left, right)
+	}
+
+	return result
+}
+
+func (c *checker) inferAssign(left, right ast.Expression) object.Type {
+	switch target := left.(type) {
+	case *ast.Call:
+		if _, isIndex := target.Argument.(*ast.List); !isIndex {
+			c.checkFunction(target, right)
+			return object.FunctionType
+		}
+
+	case *ast.Keyword:
+		var (
+			expected = c.annotationType(target.Value)
+			t        = c.infer(right)
+		)
+
+		if !c.conforms(t, expected) {
+			c.report("the variable %s is annotated as %s, but is assigned a value of type %s", target.Name, expected, t)
+		}
+
+		c.annotate(target.Name, expected)
+
+		return t
+
+	case *ast.Identifier:
+		t := c.infer(right)
+		c.assign(target.Value, t)
+
+		return t
+	}
+
+	t := c.infer(right)
+
+	// Destructuring targets, subscripts, and dot expressions
+	walkTargets(left, func(id *ast.Identifier) {
+		c.assign(id.Value, unknown)
+	})
+
+	return t
+}
+
+// checkFunction checks the definition of a function, whose parameters are the
+// argument of call, and records its signature if it's given a name.
+func (c *checker) checkFunction(call *ast.Call, body ast.Expression) {
+	sig := &signature{
+		types:   make(map[string]object.Type),
+		returns: unknown,
+	}
+
+	if call.Returns != nil {
+		sig.returns = c.annotationType(call.Returns)
+	}
+
+	if id, ok := call.Function.(*ast.Identifier); ok {
+		sig.name = id.Value
+		c.assign(id.Value, object.FunctionType)
+
+		if s := c.lookup(id.Value); s == c.scope && s.bindings[id.Value] == 1 {
+			s.signatures[id.Value] = sig
+		}
+	} else {
+		c.infer(call.Function)
+	}
+
+	c.pushScope(call)
+	defer c.popScope()
+
+	if _, ok := call.Function.(*ast.Infix); ok {
+		c.define("self", unknown)
+	}
+
+	hasDefaults := false
+
+	for _, param := range parameters(call.Argument) {
+		var (
+			name       string
+			annotation ast.Expression
+			def        ast.Expression
+		)
+
+		if inf, ok := param.(*ast.Infix); ok && inf.Operator == "=" {
+			param, def = inf.Left, inf.Right
+		}
+
+		switch p := param.(type) {
+		case *ast.Identifier:
+			name = p.Value
+
+		case *ast.Keyword:
+			name, annotation = p.Name, p.Value
+
+		case *ast.Prefix:
+			if id, ok := p.Right.(*ast.Identifier); ok && p.Operator == "..." {
+				sig.rest = true
+				c.define(id.Value, object.TupleType)
+			}
+
+			continue
+
+		default:
+			continue
+		}
+
+		t := unknown
+		if annotation != nil {
+			t = c.annotationType(annotation)
+		}
+
+		if def != nil {
+			hasDefaults = true
+
+			if d := c.infer(def); !c.conforms(d, t) {
+				c.report("the default value of the parameter %s has type %s, but is annotated as %s", name, d, t)
+			}
+		} else if !hasDefaults {
+			sig.required++
+		}
+
+		sig.params = append(sig.params, name)
+		sig.types[name] = t
+
+		if annotation != nil {
+			c.annotate(name, t)
+		} else {
+			c.define(name, unknown)
+		}
+	}
+
+	c.returns = append(c.returns, sig.returns)
+	defer func() {
+		c.returns = c.returns[:len(c.returns)-1]
+	}()
+
+	c.checkReturn(c.infer(body))
+}
+
+// parameters returns the parameters of a function definition, or the arguments of
+// a call, from its argument.
+func parameters(arg ast.Expression) []ast.Expression {
+	tup, ok := arg.(*ast.Infix)
+	if !ok || tup.Operator != "," {
+		return []ast.Expression{arg}
+	}
+
+	if tup.Left == nil && tup.Right == nil {
+		return nil
+	}
+
+	return append(parameters(tup.Left), tup.Right)
+}
+
+func (c *checker) inferCall(node *ast.Call) object.Type {
+	var (
+		args     = parameters(node.Argument)
+		types    = make([]object.Type, len(args))
+		keywords = make(map[string]object.Type)
+	)
+
+	for i, arg := range args {
+		if kw, ok := arg.(*ast.Keyword); ok {
+			keywords[kw.Name] = c.infer(kw.Value)
+		} else {
+			types[i] = c.infer(arg)
+		}
+	}
+
+	positional := len(args) - len(keywords)
+
+	id, ok := node.Function.(*ast.Identifier)
+	if !ok {
+		c.infer(node.Function)
+		return unknown
+	}
+
+	if c.typeOf(id.Value) == object.BuiltinType {
+		return builtinResults[id.Value]
+	}
+
+	s := c.lookup(id.Value)
+	if s == nil || s.signatures[id.Value] == nil {
+		return unknown
+	}
+
+	sig := s.signatures[id.Value]
+
+	if positional > len(sig.params) && !sig.rest {
+		c.report("too many arguments passed to %s. expected at most %d, got %d", sig.name, len(sig.params), positional)
+	}
+
+	for i, param := range sig.params {
+		t, ok := keywords[param]
+		if !ok && i < positional {
+			t, ok = types[i], true
+		}
+
+		if ok && !c.conforms(t, sig.types[param]) {
+			c.report("the argument for the parameter %s of %s has type %s, but is annotated as %s", param, sig.name, t, sig.types[param])
+		}
+	}
+
+	// Too few arguments make a partially-applied function
+	if len(keywords) == 0 && positional > 0 && positional < sig.required {
+		return object.FunctionType
+	}
+
+	return sig.returns
+}
+
+func (c *checker) inferIf(node *ast.If) object.Type {
+	c.infer(node.Condition)
+
+	var (
+		consequence = c.infer(node.Consequence)
+		alternative = c.infer(node.Alternative)
+	)
+
+	return merge(consequence, alternative)
+}
+
+func (c *checker) inferMatch(node *ast.Match) object.Type {
+	c.infer(node.Input)
+
+	var result object.Type
+
+	for i := range node.Branches {
+		branch := &node.Branches[i]
+
+		c.pushScope(branch)
+		c.bindPattern(branch.Condition)
+
+		if branch.Guard != nil {
+			c.infer(branch.Guard)
+		}
+
+		t := c.infer(branch.Body)
+		c.popScope()
+
+		if i == 0 {
+			result = t
+		} else {
+			result = merge(result, t)
+		}
+	}
+
+	return result
+}
+
+// bindPattern defines the names bound by a pattern. A name in a type pattern,
+// such as `number n`, has that type, and any other name is unknown.
+func (c *checker) bindPattern(pattern ast.Expression) {
+	c.defineTargets(pattern)
+
+	if call, ok := pattern.(*ast.Call); ok {
+		fn, ok := call.Function.(*ast.Identifier)
+		if !ok || !compiler.TypeNames[fn.Value] {
+			return
+		}
+
+		if id, ok := call.Argument.(*ast.Identifier); ok && id.Value != "_" {
+			c.define(id.Value, object.Type(fn.Value))
+		}
+	}
+}
+
+func (c *checker) inferModel(node *ast.Model) object.Type {
+	c.pushScope(node)
+	defer c.popScope()
+
+	c.defineTargets(node.Parameters)
+
+	if call, ok := node.Parent.(*ast.Call); ok {
+		c.infer(call.Function)
+
+		for _, arg := range parameters(call.Argument) {
+			c.infer(arg)
+		}
+	}
+
+	return object.ModelType
+}
@@ -0,0 +1,197 @@
+package checker
+
+import (
+	"math/big"
+
+	"github.com/Zac-Garby/radon/ast"
+	"github.com/Zac-Garby/radon/compiler"
+	"github.com/Zac-Garby/radon/object"
+)
+
+// samples contains, for each type whose operators can be checked, example values
+// which operators are applied to, to find out whether they can be and what type
+// the result has. A number can be any kind of number, since that's what the number
+// annotation means. Maps aren't included, since instances can overload operators.
+var samples = map[object.Type][]object.Object{
+	object.NumberType: {
+		&object.Number{Value: 1},
+		&object.Integer{Value: 1},
+		&object.Decimal{Unscaled: big.NewInt(1)},
+	},
+	object.IntegerType: {&object.Integer{Value: 1}},
+	object.DecimalType: {&object.Decimal{Unscaled: big.NewInt(1)}},
+	object.BooleanType: {&object.Boolean{Value: true}},
+	object.StringType:  {&object.String{Value: "a"}},
+	object.ListType:    {&object.List{}},
+	object.TupleType:   {&object.Tuple{}},
+	object.SetType:     {object.NewSet(0)},
+	object.SliceType:   {&object.Slice{Start: &object.Nil{}, Stop: &object.Nil{}, Step: &object.Nil{}}},
+	object.NilType:     {&object.Nil{}},
+}
+
+// checkedOperators are the infix operators which are applied to samples.
+var checkedOperators = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "^": true, "//": true, "%": true,
+	"||": true, "&&": true, "|": true, "&": true,
+	"<": true, ">": true, "<=": true, ">=": true,
+}
+
+// builtinResults maps the names of builtins to the types of their results.
+var builtinResults = map[string]object.Type{
+	"str":   object.StringType,
+	"len":   object.IntegerType,
+	"type":  object.StringType,
+	"tup":   object.TupleType,
+	"set":   object.SetType,
+	"slice": object.SliceType,
+
+	"split":      object.ListType,
+	"join":       object.StringType,
+	"replace":    object.StringType,
+	"trim":       object.StringType,
+	"trim-left":  object.StringType,
+	"trim-right": object.StringType,
+	"upper":      object.StringType,
+	"lower":      object.StringType,
+	"starts?":    object.BooleanType,
+	"ends?":      object.BooleanType,
+	"find":       object.IntegerType,
+	"repeat":     object.StringType,
+	"pad":        object.StringType,
+	"chars":      object.ListType,
+	"bytes":      object.ListType,
+	"ord":        object.IntegerType,
+	"chr":        object.StringType,
+	"format":     object.StringType,
+	"frozen":     object.BooleanType,
+}
+
+// isNumeric checks whether t is any kind of number.
+func isNumeric(t object.Type) bool {
+	return t == object.NumberType || t == object.IntegerType || t == object.DecimalType
+}
+
+// merge returns the type of a value which could be of type a or of type b.
+func merge(a, b object.Type) object.Type {
+	if a == b {
+		return a
+	}
+
+	if isNumeric(a) && isNumeric(b) {
+		return object.NumberType
+	}
+
+	return unknown
+}
+
+// conforms checks whether a value of type actual could match the annotation
+// expected. An unknown type conforms to everything.
+func (c *checker) conforms(actual, expected object.Type) bool {
+	// a model's instances are maps, and could be instances of a child model, so
+	// models are only checked as far as being maps
+	if c.models[actual] {
+		actual = object.MapType
+	}
+
+	if c.models[expected] {
+		expected = object.MapType
+	}
+
+	if actual == unknown || expected == unknown || actual == expected {
+		return true
+	}
+
+	// number means any kind of number, so an integer matches a number annotation,
+	// and a value inferred to be a number could be an integer
+	if isNumeric(actual) && isNumeric(expected) {
+		return actual == object.NumberType || expected == object.NumberType
+	}
+
+	return false
+}
+
+// annotationType returns the type which an annotation requires, if it's the name
+// of a type. A model's type is named after the model, and is recorded in c.models
+// so that it's checked as a map. Anything else is unknown.
+func (c *checker) annotationType(node ast.Expression) object.Type {
+	if id, ok := node.(*ast.Identifier); ok && compiler.TypeNames[id.Value] {
+		return object.Type(id.Value)
+	}
+
+	if c.infer(node) == object.ModelType {
+		if id, ok := node.(*ast.Identifier); ok {
+			c.models[object.Type(id.Value)] = true
+			return object.Type(id.Value)
+		}
+
+		return object.MapType
+	}
+
+	return unknown
+}
+
+// infixResult returns the type of the result of applying op to values of the types
+// left and right, and whether it can be applied at all. If it isn't known, the
+// result is unknown but op is assumed to be applicable.
+func infixResult(op string, left, right object.Type) (object.Type, bool) {
+	lefts, rights := samples[left], samples[right]
+
+	if !checkedOperators[op] || len(lefts) == 0 || len(rights) == 0 {
+		return unknown, true
+	}
+
+	var (
+		result  object.Type
+		applied bool
+	)
+
+	for _, l := range lefts {
+		for _, r := range rights {
+			res, ok := l.Infix(op, r)
+			if !ok {
+				continue
+			}
+
+			if !applied {
+				result = res.Type()
+			} else {
+				result = merge(result, res.Type())
+			}
+
+			applied = true
+		}
+	}
+
+	return result, applied
+}
+
+// prefixResult is the same as infixResult, but for prefix operators.
+func prefixResult(op string, right object.Type) (object.Type, bool) {
+	rights := samples[right]
+
+	if (op != "-" && op != "+" && op != "!") || len(rights) == 0 {
+		return unknown, true
+	}
+
+	var (
+		result  object.Type
+		applied bool
+	)
+
+	for _, r := range rights {
+		res, ok := r.Prefix(op)
+		if !ok {
+			continue
+		}
+
+		if !applied {
+			result = res.Type()
+		} else {
+			result = merge(result, res.Type())
+		}
+
+		applied = true
+	}
+
+	return result, applied
+}
@@ -0,0 +1,130 @@
+package checker
+
+import (
+	"github.com/Zac-Garby/radon/ast"
+)
+
+// walk calls visit with node and then, recursively, with every node inside it.
+func walk(node ast.Node, visit func(ast.Node)) {
+	if node == nil {
+		return
+	}
+
+	visit(node)
+
+	for _, child := range children(node) {
+		walk(child, visit)
+	}
+}
+
+// walkTargets calls visit with each identifier in target, an assignment target or
+// a pattern, except those in pinned values such as `^limit`, which are evaluated
+// instead of being bound.
+func walkTargets(target ast.Node, visit func(*ast.Identifier)) {
+	switch n := target.(type) {
+	case nil:
+		return
+
+	case *ast.Identifier:
+		visit(n)
+		return
+
+	case *ast.Prefix:
+		if n.Operator == "^" {
+			return
+		}
+	}
+
+	for _, child := range children(target) {
+		walkTargets(child, visit)
+	}
+}
+
+// children returns the nodes directly inside node. Some of them may be nil.
+func children(node ast.Node) []ast.Node {
+	switch n := node.(type) {
+	case *ast.ExpressionStatement:
+		return []ast.Node{n.Expr}
+
+	case *ast.Return:
+		return []ast.Node{n.Value}
+
+	case *ast.While:
+		return []ast.Node{n.Condition, n.Body}
+
+	case *ast.For:
+		return []ast.Node{n.Var, n.Collection, n.Body}
+
+	case *ast.Export:
+		return []ast.Node{n.Names}
+
+	case *ast.Const:
+		return []ast.Node{n.Target, n.Value}
+
+	case *ast.Interpolation:
+		nodes := make([]ast.Node, len(n.Parts))
+		for i, part := range n.Parts {
+			nodes[i] = part
+		}
+
+		return nodes
+
+	case *ast.List:
+		nodes := make([]ast.Node, len(n.Value))
+		for i, elem := range n.Value {
+			nodes[i] = elem
+		}
+
+		return nodes
+
+	case *ast.Slice:
+		return []ast.Node{n.Start, n.Stop, n.Step}
+
+	case *ast.Map:
+		var nodes []ast.Node
+		for _, pair := range n.Value {
+			nodes = append(nodes, pair.Key, pair.Value)
+		}
+
+		return nodes
+
+	case *ast.Block:
+		nodes := make([]ast.Node, len(n.Value))
+		for i, stmt := range n.Value {
+			nodes[i] = stmt
+		}
+
+		return nodes
+
+	case *ast.Prefix:
+		return []ast.Node{n.Right}
+
+	case *ast.Infix:
+		return []ast.Node{n.Left, n.Right}
+
+	case *ast.Call:
+		return []ast.Node{n.Function, n.Argument, n.Returns}
+
+	case *ast.Keyword:
+		return []ast.Node{n.Value}
+
+	case *ast.If:
+		return []ast.Node{n.Condition, n.Consequence, n.Alternative}
+
+	case *ast.Match:
+		nodes := []ast.Node{n.Input}
+		for _, branch := range n.Branches {
+			nodes = append(nodes, branch.Condition, branch.Guard, branch.Body)
+		}
+
+		return nodes
+
+	case *ast.Model:
+		return []ast.Node{n.Parameters, n.Parent}
+
+	case *ast.Protocol:
+		return []ast.Node{n.Methods}
+	}
+
+	return nil
+}
@@ -17,8 +17,14 @@ func (c *Compiler) CompileExpression(e ast.Expression) error {
 	switch node := e.(type) {
 	case *ast.Number:
 		return c.compileNumber(node)
+	case *ast.Integer:
+		return c.compileInteger(node)
+	case *ast.Decimal:
+		return c.compileDecimal(node)
 	case *ast.String:
 		return c.compileString(node)
+	case *ast.Interpolation:
+		return c.compileInterpolation(node)
 	case *ast.Boolean:
 		return c.compileBoolean(node)
 	case *ast.Nil:
@@ -35,12 +41,20 @@ func (c *Compiler) CompileExpression(e ast.Expression) error {
 		return c.compileList(node)
 	case *ast.Map:
 		return c.compileMap(node)
+	case *ast.Slice:
+		return c.compileSlice(node)
 	case *ast.Call:
 		return c.compileCall(node)
 	case *ast.Block:
 		return c.compileBlock(node)
 	case *ast.Match:
 		return c.compileMatch(node)
+	case *ast.Model:
+		return c.compileModel(node)
+	case *ast.Protocol:
+		return c.compileProtocol(node)
+	case *ast.Keyword:
+		return errors.New("compiler: a keyword argument can only be passed to a function call")
 	default:
 		return fmt.Errorf("compiler: compilation not yet implemented for %s", reflect.TypeOf(e))
 	}
@@ -51,11 +65,63 @@ func (c *Compiler) compileNumber(node *ast.Number) error {
 	return err
 }
 
+func (c *Compiler) compileInteger(node *ast.Integer) error {
+	var obj *object.Integer
+
+	if node.Value.IsInt64() {
+		obj = &object.Integer{Value: node.Value.Int64()}
+	} else {
+		obj = &object.Integer{Big: node.Value}
+	}
+
+	_, err := c.addAndLoad(obj)
+	return err
+}
+
+func (c *Compiler) compileDecimal(node *ast.Decimal) error {
+	obj, ok := object.ParseDecimal(node.Value)
+	if !ok {
+		return fmt.Errorf("compiler: invalid decimal literal %sd", node.Value)
+	}
+
+	_, err := c.addAndLoad(obj)
+	return err
+}
+
 func (c *Compiler) compileString(node *ast.String) error {
 	_, err := c.addAndLoad(&object.String{Value: node.Value})
 	return err
 }
 
+func (c *Compiler) compileInterpolation(node *ast.Interpolation) error {
+	// Each part is converted to a string by the str builtin, loaded as a
+	// constant so it can't be shadowed, and then concatenated to the rest.
+	for i, part := range node.Parts {
+		if str, ok := part.(*ast.String); ok {
+			if err := c.compileString(str); err != nil {
+				return err
+			}
+		} else {
+			if err := c.CompileExpression(part); err != nil {
+				return err
+			}
+
+			if _, err := c.addAndLoad(object.Builtins["str"]); err != nil {
+				return err
+			}
+
+			low, high := runeToBytes(1)
+			c.push(bytecode.CallFunction, high, low)
+		}
+
+		if i > 0 {
+			c.push(bytecode.BinaryAdd)
+		}
+	}
+
+	return nil
+}
+
 func (c *Compiler) compileBoolean(node *ast.Boolean) error {
 	_, err := c.addAndLoad(&object.Boolean{Value: node.Value})
 	return err
@@ -83,6 +149,12 @@ func (c *Compiler) compileInfix(node *ast.Infix) error {
 		return c.compileDot(left, right)
 	case ",":
 		return c.compileCommaInfix(left, right)
+	case "|>":
+		return c.compileCall(&ast.Call{Function: right, Argument: left})
+	case "$":
+		return c.compileCall(&ast.Call{Function: left, Argument: right})
+	case "implements":
+		return c.compileImplements(left, right)
 	}
 
 	if err := c.CompileExpression(left); err != nil {
@@ -94,23 +166,25 @@ func (c *Compiler) compileInfix(node *ast.Infix) error {
 	}
 
 	op, ok := map[string]byte{
-		"+":  bytecode.BinaryAdd,
-		"-":  bytecode.BinarySub,
-		"*":  bytecode.BinaryMul,
-		"/":  bytecode.BinaryDiv,
-		"^":  bytecode.BinaryExp,
-		"//": bytecode.BinaryFloorDiv,
-		"%":  bytecode.BinaryMod,
-		"||": bytecode.BinaryLogicOr,
-		"&&": bytecode.BinaryLogicAnd,
-		"|":  bytecode.BinaryBitOr,
-		"&":  bytecode.BinaryBitAnd,
-		"==": bytecode.BinaryEqual,
-		"!=": bytecode.BinaryNotEqual,
-		"<":  bytecode.BinaryLess,
-		">":  bytecode.BinaryMod,
-		"<=": bytecode.BinaryLessEq,
-		">=": bytecode.BinaryMoreEq,
+		"+":      bytecode.BinaryAdd,
+		"-":      bytecode.BinarySub,
+		"*":      bytecode.BinaryMul,
+		"/":      bytecode.BinaryDiv,
+		"^":      bytecode.BinaryExp,
+		"//":     bytecode.BinaryFloorDiv,
+		"%":      bytecode.BinaryMod,
+		"||":     bytecode.BinaryLogicOr,
+		"&&":     bytecode.BinaryLogicAnd,
+		"|":      bytecode.BinaryBitOr,
+		"&":      bytecode.BinaryBitAnd,
+		"==":     bytecode.BinaryEqual,
+		"!=":     bytecode.BinaryNotEqual,
+		"<":      bytecode.BinaryLess,
+		">":      bytecode.BinaryMore,
+		"<=":     bytecode.BinaryLessEq,
+		">=":     bytecode.BinaryMoreEq,
+		"in":     bytecode.BinaryIn,
+		"not in": bytecode.BinaryNotIn,
 	}[node.Operator]
 
 	if !ok {
@@ -122,6 +196,248 @@ func (c *Compiler) compileInfix(node *ast.Infix) error {
 	return nil
 }
 
+// compileFunction loads a function with the given parameters, whose body is compiled
+// by compileBody in a new Compiler instance. Any default values are evaluated in the
+// current scope, when the function is defined, as are any annotations.
+func (c *Compiler) compileFunction(params *parameterList, compileBody func(sub *Compiler) error) error {
+	fn := &object.Function{
+		Parameters: params.names,
+		Rest:       params.rest,
+	}
+
+	subCompiler := New()
+	if err := compileBody(subCompiler); err != nil {
+		return err
+	}
+
+	code, err := bytecode.Read(bytes.NewReader(subCompiler.Bytes))
+	if err != nil {
+		return err
+	}
+
+	fn.Code = code
+	fn.Constants = subCompiler.Constants
+	fn.Names = subCompiler.Names
+	fn.Jumps = subCompiler.Jumps
+
+	for _, def := range params.defaults {
+		if err := c.CompileExpression(def); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.addAndLoad(fn); err != nil {
+		return err
+	}
+
+	if len(params.defaults) > 0 {
+		low, high := runeToBytes(rune(len(params.defaults)))
+		c.push(bytecode.BindDefaults, high, low)
+	}
+
+	annotated, annotations := params.annotated, params.annotations
+
+	// return is a keyword, so it can't be the name of a parameter
+	if params.returns != nil {
+		annotated = append(annotated, "return")
+		annotations = append(annotations, params.returns)
+	}
+
+	if len(annotated) > 0 {
+		names := &object.Tuple{}
+
+		for i, name := range annotated {
+			if err := c.compileAnnotation(annotations[i]); err != nil {
+				return err
+			}
+
+			names.Value = append(names.Value, &object.String{Value: name})
+		}
+
+		if _, err := c.addAndLoad(names); err != nil {
+			return err
+		}
+
+		c.push(bytecode.BindAnnotations)
+	}
+
+	return nil
+}
+
+// compileModel compiles a model. Its Init function makes the instance, starting with
+// an instance of the parent model, if there is one, or otherwise an empty map, and
+// then setting each parameter in it. When a model is called, __model is declared in
+// Init's scope as the model itself.
+func (c *Compiler) compileModel(node *ast.Model) error {
+	params, err := c.getParameterList(node.Parameters)
+	if err != nil {
+		return err
+	}
+
+	var parent *ast.Call
+
+	if node.Parent != nil {
+		call, ok := node.Parent.(*ast.Call)
+		if !ok {
+			return errors.New("compiler: a model's parent must be called with arguments, e.g. model name : animal name, \"dog\"")
+		}
+
+		parent = call
+	}
+
+	fields := params.names
+	if params.rest != "" {
+		fields = append(fields, params.rest)
+	}
+
+	err = c.compileFunction(params, func(sub *Compiler) error {
+		if parent != nil {
+			parentModel := &ast.Infix{
+				Operator: ".",
+				Left:     &ast.Identifier{Value: "__model"},
+				Right:    &ast.Identifier{Value: "__parent"},
+			}
+
+			if err := sub.compileCall(&ast.Call{Function: parentModel, Argument: parent.Argument}); err != nil {
+				return err
+			}
+		} else {
+			sub.push(bytecode.MakeMap, 0, 0)
+		}
+
+		for _, field := range fields {
+			if _, err := sub.addAndLoad(&object.String{Value: field}); err != nil {
+				return err
+			}
+
+			if err := sub.compileName(field); err != nil {
+				return err
+			}
+		}
+
+		if err := sub.compileName("__model"); err != nil {
+			return err
+		}
+
+		low, high := runeToBytes(rune(len(fields)))
+		sub.push(bytecode.MakeInstance, high, low)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if parent != nil {
+		if err := c.CompileExpression(parent.Function); err != nil {
+			return err
+		}
+	} else if _, err := c.addAndLoad(&object.Nil{}); err != nil {
+		return err
+	}
+
+	c.push(bytecode.MakeModel)
+
+	return nil
+}
+
+// compileAnnotation compiles the annotation of a parameter, return value or variable.
+// The name of a type, such as `number`, is compiled to a string, as it is in a type
+// pattern. Anything else, such as a model or a protocol, is evaluated.
+func (c *Compiler) compileAnnotation(node ast.Expression) error {
+	if id, ok := node.(*ast.Identifier); ok && TypeNames[id.Value] {
+		_, err := c.addAndLoad(&object.String{Value: id.Value})
+		return err
+	}
+
+	return c.CompileExpression(node)
+}
+
+// compileProtocol compiles a protocol. Since its methods are known at compile time,
+// it's just a constant.
+func (c *Compiler) compileProtocol(node *ast.Protocol) error {
+	var (
+		protocol = &object.Protocol{}
+		elems    []ast.Expression
+	)
+
+	if block, ok := node.Methods.(*ast.Block); ok {
+		for _, stmt := range block.Value {
+			expr, ok := stmt.(*ast.ExpressionStatement)
+			if !ok {
+				return errors.New("compiler: a protocol's methods must be names, optionally followed by their parameters")
+			}
+
+			elems = append(elems, c.flattenParameters(expr.Expr)...)
+		}
+	} else {
+		elems = c.flattenParameters(node.Methods)
+	}
+
+	for _, elem := range elems {
+		method := object.ProtocolMethod{Arity: -1}
+
+		switch e := elem.(type) {
+		case *ast.Identifier:
+			method.Name = e.Value
+
+		case *ast.Call:
+			id, ok := e.Function.(*ast.Identifier)
+			if !ok {
+				return errors.New("compiler: the name of a protocol's method must be an identifier")
+			}
+
+			params, err := c.getParameterList(e.Argument)
+			if err != nil {
+				return err
+			}
+
+			if len(params.defaults) > 0 || params.rest != "" {
+				return fmt.Errorf("compiler: the method %s in a protocol can't have default or rest parameters", id.Value)
+			}
+
+			method.Name = id.Value
+			method.Arity = len(params.names)
+
+		default:
+			return errors.New("compiler: a protocol's methods must be names, optionally followed by their parameters")
+		}
+
+		for _, m := range protocol.Methods {
+			if m.Name == method.Name {
+				return fmt.Errorf("compiler: the method %s is in the protocol more than once", method.Name)
+			}
+		}
+
+		protocol.Methods = append(protocol.Methods, method)
+	}
+
+	_, err := c.addAndLoad(protocol)
+	return err
+}
+
+// compileImplements compiles `value implements protocol` as a call to the
+// implements builtin.
+func (c *Compiler) compileImplements(left, right ast.Expression) error {
+	if err := c.CompileExpression(right); err != nil {
+		return err
+	}
+
+	if err := c.CompileExpression(left); err != nil {
+		return err
+	}
+
+	if _, err := c.addAndLoad(object.Builtins["implements"]); err != nil {
+		return err
+	}
+
+	low, high := runeToBytes(2)
+	c.push(bytecode.CallFunction, high, low)
+
+	return nil
+}
+
 func (c *Compiler) compileCommaInfix(left, right ast.Expression) error {
 	if left == nil || right == nil {
 		c.addAndLoad(&object.Tuple{})
@@ -163,93 +479,194 @@ func (c *Compiler) compileDot(left, right ast.Expression) error {
 }
 
 func (c *Compiler) compileAssignOrDeclare(l, right ast.Expression, t string) error {
-	switch left := l.(type) {
+	if call, ok := l.(*ast.Call); ok {
+		// A subscript is a call with a list, so `xs[0], xs[1] = ...` defines a
+		// function xs whose first parameter is [0]
+		if tup, ok := call.Argument.(*ast.Infix); ok && tup.Operator == "," && tup.Left != nil {
+			if _, isIndex := c.expandTuple(tup)[0].(*ast.List); isIndex {
+				return errors.New("compiler: subscripts in a tuple of targets must be parenthesised: (a[0]), (a[1]) = b")
+			}
+		}
+
+		if _, isIndex := call.Argument.(*ast.List); !isIndex {
+			return c.compileAssignToFunction(call, right, t)
+		}
+	}
+
+	// The right-hand side is evaluated entirely before anything is stored,
+	// so `a, b = b, a` swaps a and b.
+	if err := c.CompileExpression(right); err != nil {
+		return err
+	}
+
+	return c.compileStoreTarget(l, t)
+}
+
+// compileStoreTarget stores the value on top of the stack in target, which can be an
+// identifier, a subscript, or a tuple, list or map of targets to destructure the value
+// into, such as `x, y = point` or `{name: n} = person`. t is either "assign", "declare"
+// or "const".
+func (c *Compiler) compileStoreTarget(target ast.Expression, t string) error {
+	switch node := target.(type) {
 	case *ast.Identifier:
-		return c.compileAssignToIdent(left, right, t)
+		if node.Value == "_" {
+			c.push(bytecode.Pop)
+			return nil
+		}
+
+		index, err := c.addName(node.Value)
+		if err != nil {
+			return err
+		}
+
+		low, high := runeToBytes(rune(index))
+		c.push(storeInstruction(t), high, low)
+
+		return nil
+
+	case *ast.Keyword:
+		if err := c.compileAnnotation(node.Value); err != nil {
+			return err
+		}
+
+		index, err := c.addName(node.Name)
+		if err != nil {
+			return err
+		}
+
+		low, high := runeToBytes(rune(index))
+		c.push(bytecode.CheckAnnotation, high, low)
+
+		return c.compileStoreTarget(&ast.Identifier{Value: node.Name}, t)
 
 	case *ast.Call:
-		if list, ok := left.Argument.(*ast.List); ok {
-			if len(list.Value) != 1 {
-				return fmt.Errorf("compiler: exactly one element should be present in an index assignment: a[b] = c")
+		list, ok := node.Argument.(*ast.List)
+		if !ok {
+			break
+		}
+
+		if len(list.Value) != 1 {
+			return fmt.Errorf("compiler: exactly one element should be present in an index assignment: a[b] = c")
+		}
+
+		if t != "assign" {
+			return errors.New("compiler: cannot declare to a subscript[expression], use an assignment instead: a[b] = c")
+		}
+
+		if err := c.CompileExpression(node.Function); err != nil {
+			return err
+		}
+
+		if err := c.CompileExpression(list.Value[0]); err != nil {
+			return err
+		}
+
+		c.push(bytecode.StoreSubscript)
+
+		return nil
+
+	case *ast.Infix:
+		if node.Operator == "." {
+			if t != "assign" {
+				return errors.New("compiler: cannot declare to a field, use an assignment instead: a.b = c")
+			}
+
+			id, ok := node.Right.(*ast.Identifier)
+			if !ok {
+				return errors.New("compiler: expected an identifier to the right of a dot (.)")
+			}
+
+			if err := c.CompileExpression(node.Left); err != nil {
+				return err
+			}
+
+			if _, err := c.addAndLoad(&object.String{Value: id.Value}); err != nil {
+				return err
 			}
 
-			return c.compileAssignToIndex(left.Function, list.Value[0], right, t)
+			c.push(bytecode.StoreSubscript)
+
+			return nil
 		}
 
-		return c.compileAssignToFunction(left, right, t)
-	}
+		if node.Operator != "," {
+			break
+		}
 
-	return nil
-}
+		var elems []ast.Expression
+		if node.Left != nil || node.Right != nil {
+			elems = c.expandTuple(node)
+		}
 
-func (c *Compiler) compileAssignToIdent(ident *ast.Identifier, right ast.Expression, t string) error {
-	if err := c.CompileExpression(right); err != nil {
-		return err
-	}
+		return c.compileUnpack(elems, t)
 
-	index, err := c.addName(ident.Value)
-	if err != nil {
-		return err
-	}
+	case *ast.Prefix:
+		if node.Operator == "," {
+			return c.compileUnpack([]ast.Expression{node.Right}, t)
+		}
 
-	low, high := runeToBytes(rune(index))
-	if t == "assign" {
-		c.push(bytecode.StoreName, high, low)
-	} else {
-		c.push(bytecode.DeclareName, high, low)
-	}
+	case *ast.List:
+		return c.compileUnpack(node.Value, t)
 
-	return nil
-}
+	case *ast.Map:
+		for _, pair := range node.Value {
+			key, val := pair.Key, pair.Value
+
+			// As in map patterns, identifier keys are used as strings
+			if id, ok := key.(*ast.Identifier); ok {
+				if _, err := c.addAndLoad(&object.String{Value: id.Value}); err != nil {
+					return err
+				}
+			} else if err := c.CompileExpression(key); err != nil {
+				return err
+			}
 
-func (c *Compiler) compileAssignToIndex(obj, idx, val ast.Expression, t string) error {
-	if t != "assign" {
-		return errors.New("compiler: cannot declare to a subscript[expression], use an assignment instead: a[b] = c")
-	}
+			c.push(bytecode.UnpackKey)
 
-	if err := c.CompileExpression(val); err != nil {
-		return err
-	}
+			if err := c.compileStoreTarget(val, t); err != nil {
+				return err
+			}
+		}
 
-	if err := c.CompileExpression(obj); err != nil {
-		return err
-	}
+		// Discard the map itself, now each value has been stored
+		c.push(bytecode.Pop)
 
-	if err := c.CompileExpression(idx); err != nil {
-		return err
+		return nil
 	}
 
-	c.push(bytecode.StoreSubscript)
+	return fmt.Errorf("compiler: cannot assign to a %s", reflect.TypeOf(target))
+}
+
+func (c *Compiler) compileUnpack(targets []ast.Expression, t string) error {
+	low, high := runeToBytes(rune(len(targets)))
+	c.push(bytecode.UnpackSequence, high, low)
+
+	// UnpackSequence leaves the first item on top of the stack
+	for _, target := range targets {
+		if err := c.compileStoreTarget(target, t); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
 func (c *Compiler) compileAssignToFunction(function *ast.Call, body ast.Expression, t string) error {
-	fn := &object.Function{}
-
 	params, err := c.getParameterList(function.Argument)
 	if err != nil {
 		return err
 	}
 
-	fn.Parameters = params
+	params.returns = function.Returns
 
-	// Compile the function body in a new Compiler instance
-	subCompiler := New()
-	subCompiler.CompileExpression(body)
+	err = c.compileFunction(params, func(sub *Compiler) error {
+		return sub.CompileExpression(body)
+	})
 
-	code, err := bytecode.Read(bytes.NewReader(subCompiler.Bytes))
 	if err != nil {
 		return err
 	}
 
-	fn.Code = code
-	fn.Constants = subCompiler.Constants
-	fn.Names = subCompiler.Names
-	fn.Jumps = subCompiler.Jumps
-
-	c.addAndLoad(fn)
-
 	switch name := function.Function.(type) {
 	case *ast.Identifier:
 		index, err := c.addName(name.Value)
@@ -258,11 +675,7 @@ func (c *Compiler) compileAssignToFunction(function *ast.Call, body ast.Expressi
 		}
 
 		low, high := runeToBytes(rune(index))
-		if t == "assign" {
-			c.push(bytecode.StoreName, high, low)
-		} else {
-			c.push(bytecode.DeclareName, high, low)
-		}
+		c.push(storeInstruction(t), high, low)
 
 	case *ast.Infix:
 		if t != "assign" {
@@ -366,8 +779,30 @@ func (c *Compiler) compileList(node *ast.List) error {
 	return nil
 }
 
+func (c *Compiler) compileSlice(node *ast.Slice) error {
+	for _, part := range []ast.Expression{node.Start, node.Stop, node.Step} {
+		if part == nil {
+			if _, err := c.addAndLoad(&object.Nil{}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := c.CompileExpression(part); err != nil {
+			return err
+		}
+	}
+
+	c.push(bytecode.MakeSlice)
+
+	return nil
+}
+
 func (c *Compiler) compileMap(node *ast.Map) error {
-	for key, val := range node.Value {
+	for _, pair := range node.Value {
+		key, val := pair.Key, pair.Value
+
 		if err := c.CompileExpression(key); err != nil {
 			return err
 		}
@@ -384,14 +819,39 @@ func (c *Compiler) compileMap(node *ast.Map) error {
 }
 
 func (c *Compiler) compileCall(node *ast.Call) error {
+	if node.Returns != nil {
+		return errors.New("compiler: a return annotation (->) can only be used in a function definition")
+	}
+
 	var args []ast.Expression
 
 	if tupInf, ok := node.Argument.(*ast.Infix); ok && tupInf.Operator == "," {
-		args = c.expandTuple(tupInf)
+		if tupInf.Left != nil || tupInf.Right != nil {
+			args = c.expandTuple(tupInf)
+		}
 	} else {
 		args = []ast.Expression{node.Argument}
 	}
 
+	// Keyword arguments are always at the end
+	var keywords []*ast.Keyword
+
+	for len(args) > 0 {
+		kw, ok := args[len(args)-1].(*ast.Keyword)
+		if !ok {
+			break
+		}
+
+		keywords = append([]*ast.Keyword{kw}, keywords...)
+		args = args[:len(args)-1]
+	}
+
+	for _, arg := range args {
+		if _, ok := arg.(*ast.Keyword); ok {
+			return errors.New("compiler: positional arguments can't follow keyword arguments")
+		}
+	}
+
 	// Iterate arguments in reverse order
 	for i := len(args) - 1; i >= 0; i-- {
 		if err := c.CompileExpression(args[i]); err != nil {
@@ -399,6 +859,10 @@ func (c *Compiler) compileCall(node *ast.Call) error {
 		}
 	}
 
+	if len(keywords) > 0 {
+		return c.compileKeywordCall(node.Function, len(args), keywords)
+	}
+
 	if err := c.CompileExpression(node.Function); err != nil {
 		return err
 	}
@@ -409,6 +873,40 @@ func (c *Compiler) compileCall(node *ast.Call) error {
 	return nil
 }
 
+// compileKeywordCall compiles a call with keyword arguments, once the positional
+// arguments have been compiled. The keyword values are pushed, followed by a
+// tuple of their names and the function.
+func (c *Compiler) compileKeywordCall(function ast.Expression, argCount int, keywords []*ast.Keyword) error {
+	names := &object.Tuple{}
+
+	for _, kw := range keywords {
+		for _, name := range names.Value {
+			if name.(*object.String).Value == kw.Name {
+				return fmt.Errorf("compiler: the keyword argument %s is given more than once", kw.Name)
+			}
+		}
+
+		names.Value = append(names.Value, &object.String{Value: kw.Name})
+
+		if err := c.CompileExpression(kw.Value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.addAndLoad(names); err != nil {
+		return err
+	}
+
+	if err := c.CompileExpression(function); err != nil {
+		return err
+	}
+
+	low, high := runeToBytes(rune(argCount))
+	c.push(bytecode.CallKeywords, high, low)
+
+	return nil
+}
+
 func (c *Compiler) compileBlock(node *ast.Block) error {
 	c.pushScope()
 	defer c.popScope()
@@ -430,12 +928,16 @@ func (c *Compiler) compileMatch(node *ast.Match) error {
 		return err
 	}
 
-	c.push(bytecode.StartMatch)
+	c.push(bytecode.StartMatch, 0, 0)
+	start := len(c.Bytes) - 3
 
-	var wildcard ast.Expression
+	var (
+		wildcard ast.Expression
+		ends     []int
+	)
 
 	for _, branch := range node.Branches {
-		if id, ok := branch.Condition.(*ast.Identifier); ok && id.Value == "_" {
+		if id, ok := branch.Condition.(*ast.Identifier); ok && id.Value == "_" && branch.Guard == nil {
 			if wildcard != nil {
 				return errors.New("compiler: only one wildcard branch is permitted per match-expression")
 			}
@@ -444,28 +946,55 @@ func (c *Compiler) compileMatch(node *ast.Match) error {
 			continue
 		}
 
-		if err := c.CompileExpression(branch.Condition); err != nil {
+		// Each branch has its own scope, so the names bound by its pattern
+		// are only visible in its guard and body.
+		c.pushScope()
+		c.push(bytecode.LoadMatchInput)
+
+		var fails []int
+
+		if err := c.compilePattern(branch.Condition, &fails); err != nil {
 			return err
 		}
 
-		c.push(bytecode.StartBranch)
+		if branch.Guard != nil {
+			if err := c.CompileExpression(branch.Guard); err != nil {
+				return err
+			}
+
+			c.pushFailJump(bytecode.JumpUnless, &fails)
+		}
 
 		if err := c.CompileExpression(branch.Body); err != nil {
 			return err
 		}
 
-		c.push(bytecode.EndBranch)
+		c.popScope()
+
+		// Jump past the other branches to the END_MATCH
+		c.push(bytecode.Jump, 0, 0)
+		ends = append(ends, len(c.Bytes)-3)
+
+		// If the branch doesn't match, its scope is popped and the next
+		// branch is tried
+		for _, fail := range fails {
+			c.setJumpArg(fail, len(c.Bytes)+1)
+		}
+
+		c.popScope()
 	}
 
 	if wildcard == nil {
 		c.addAndLoad(&object.Nil{})
-	} else {
-		if err := c.CompileExpression(wildcard); err != nil {
-			return err
-		}
+	} else if err := c.encloseExpression(wildcard); err != nil {
+		return err
 	}
 
 	c.push(bytecode.EndMatch)
 
+	for _, end := range append(ends, start) {
+		c.setJumpArg(end, len(c.Bytes))
+	}
+
 	return nil
 }
@@ -25,13 +25,18 @@ func bytesToRune(low, high byte) rune {
 }
 
 func (c *Compiler) addConst(val object.Object) (rune, error) {
+	// Objects of different types can be equal, such as 1 and 1.0, but
+	// shouldn't share a constant. Neither should 1.0d and 1.00d, which are
+	// equal but print differently.
 	for i, cst := range c.Constants {
-		if val.Equals(cst) {
+		if val.Type() == cst.Type() && val.Equals(cst) && val.String() == cst.String() {
 			return rune(i), nil
 		}
 	}
 
-	c.Constants = append(c.Constants, val)
+	// Constants are shared by every use of them, so are frozen to make sure a
+	// script can never modify one
+	c.Constants = append(c.Constants, object.Freeze(val))
 	index := len(c.Constants) - 1
 
 	if index >= maxRune {
@@ -86,6 +91,19 @@ func (c *Compiler) compileName(name string) error {
 	return nil
 }
 
+// storeInstruction returns the instruction which stores a value in a name, for a
+// kind of store t, which is either "assign", "declare" or "const".
+func storeInstruction(t string) byte {
+	switch t {
+	case "assign":
+		return bytecode.StoreName
+	case "const":
+		return bytecode.DeclareConst
+	default:
+		return bytecode.DeclareName
+	}
+}
+
 func (c *Compiler) push(bytes ...byte) {
 	c.Bytes = append(c.Bytes, bytes...)
 }
@@ -102,36 +120,108 @@ func (c *Compiler) expandTuple(e *ast.Infix) []ast.Expression {
 	panic("compiler: non-tuple expression passed to expandTuple!")
 }
 
-func (c *Compiler) getParameterList(arg ast.Expression) ([]string, error) {
-	params := make([]string, 0)
+// A parameterList is the list of parameters of a function definition.
+type parameterList struct {
+	names []string
 
-	switch a := arg.(type) {
-	case *ast.Identifier:
-		params = append(params, a.Value)
+	// defaults are the default values of the last len(defaults) names
+	defaults []ast.Expression
 
-	case *ast.Infix:
-		if a.Operator != "," {
-			return nil, errors.New("compiler: function parameters must be identifiers")
-		}
+	// rest is the name of the rest parameter, or "" if there isn't one
+	rest string
+
+	// annotated are the names of the parameters with annotations, and
+	// annotations are their annotations, in the same order
+	annotated   []string
+	annotations []ast.Expression
 
-		expanded := c.expandTuple(a)
+	// returns is the annotation of the return value, or nil if there isn't one
+	returns ast.Expression
+}
 
-		for _, param := range expanded {
-			sub, err := c.getParameterList(param)
-			if err != nil {
-				return nil, err
+// getParameterList gets the parameters of a function definition from the argument
+// of its call expression. Each parameter is an identifier, `name = default`, or,
+// only as the last parameter, `...rest`. An identifier can be annotated, as in
+// `name: annotation` or `name: annotation = default`.
+func (c *Compiler) getParameterList(arg ast.Expression) (*parameterList, error) {
+	var (
+		params = &parameterList{}
+		elems  = c.flattenParameters(arg)
+	)
+
+	for i, elem := range elems {
+		switch e := params.annotate(elem).(type) {
+		case *ast.Identifier:
+			if len(params.defaults) > 0 {
+				return nil, fmt.Errorf("compiler: the parameter %s needs a default value, since it follows one with a default value", e.Value)
+			}
+
+			params.names = append(params.names, e.Value)
+			continue
+
+		case *ast.Infix:
+			if id, ok := e.Left.(*ast.Identifier); ok && e.Operator == "=" {
+				params.names = append(params.names, id.Value)
+				params.defaults = append(params.defaults, e.Right)
+				continue
 			}
 
-			params = append(params, sub...)
+		case *ast.Prefix:
+			if id, ok := e.Right.(*ast.Identifier); ok && e.Operator == "..." {
+				if i != len(elems)-1 {
+					return nil, errors.New("compiler: a rest parameter (...) must be the last parameter")
+				}
+
+				params.rest = id.Value
+				continue
+			}
 		}
 
-	default:
 		return nil, errors.New("compiler: function parameters must be identifiers")
 	}
 
 	return params, nil
 }
 
+// annotate records the annotation of a parameter, if it has one, returning the
+// parameter without it.
+func (p *parameterList) annotate(param ast.Expression) ast.Expression {
+	switch e := param.(type) {
+	case *ast.Keyword:
+		p.annotated = append(p.annotated, e.Name)
+		p.annotations = append(p.annotations, e.Value)
+
+		return &ast.Identifier{Value: e.Name}
+
+	case *ast.Infix:
+		if _, ok := e.Left.(*ast.Keyword); ok && e.Operator == "=" {
+			return &ast.Infix{Operator: "=", Left: p.annotate(e.Left), Right: e.Right}
+		}
+	}
+
+	return param
+}
+
+func (c *Compiler) flattenParameters(arg ast.Expression) []ast.Expression {
+	tup, ok := arg.(*ast.Infix)
+	if !ok || tup.Operator != "," {
+		return []ast.Expression{arg}
+	}
+
+	// An empty tuple, (), means there are no parameters
+	if tup.Left == nil && tup.Right == nil {
+		return nil
+	}
+
+	var params []ast.Expression
+
+	for _, elem := range c.expandTuple(tup) {
+		params = append(params, c.flattenParameters(elem)...)
+	}
+
+	return params
+}
+
 func (c *Compiler) addJump(target int) (rune, error) {
 	for i, jmp := range c.Jumps {
 		if jmp == target {
@@ -0,0 +1,195 @@
+package compiler
+
+import (
+	"errors"
+
+	"github.com/Zac-Garby/radon/ast"
+	"github.com/Zac-Garby/radon/bytecode"
+	"github.com/Zac-Garby/radon/object"
+)
+
+// TypeNames contains the names which, when called with a pattern as the argument,
+// make a type pattern, e.g. `number n` or `list [x, y]`. They're also the names
+// which can be used as type annotations, e.g. `double x: number = x * 2`.
+var TypeNames = map[string]bool{
+	object.NumberType:   true,
+	object.IntegerType:  true,
+	object.DecimalType:  true,
+	object.BooleanType:  true,
+	object.StringType:   true,
+	object.ListType:     true,
+	object.TupleType:    true,
+	object.MapType:      true,
+	object.SetType:      true,
+	object.SliceType:    true,
+	object.RegexType:    true,
+	object.StreamType:   true,
+	object.NilType:      true,
+	object.FunctionType: true,
+	object.MethodType:   true,
+	object.BuiltinType:  true,
+	object.ModelType:    true,
+	object.ProtocolType: true,
+	object.IterType:     true,
+}
+
+// compilePattern compiles a pattern which is matched against the top of the stack,
+// consuming it. The positions of the jump instructions made if the pattern fails to
+// match are appended to fails, so their targets can be set later on.
+//
+//   - `_` matches anything
+//   - An identifier matches anything, and binds it to that name
+//   - A pinned expression, e.g. `^limit`, is evaluated and matches an equal
+//     object, so a variable can be matched against instead of being rebound
+//   - A tuple or list of patterns matches a tuple or list whose items match each
+//     pattern. The last pattern can be a rest element, `...rest`, which binds the
+//     remaining items
+//   - A map of patterns matches a map containing each key whose value matches the
+//     corresponding pattern. Identifier keys are used as strings, as in `{name: n}`
+//   - A type name applied to a pattern, e.g. `number n`, matches an object of that
+//     type which also matches the pattern
+//   - Anything else is evaluated and matches an equal object
+func (c *Compiler) compilePattern(pattern ast.Expression, fails *[]int) error {
+	switch node := pattern.(type) {
+	case *ast.Identifier:
+		if node.Value == "_" {
+			c.push(bytecode.Pop)
+			return nil
+		}
+
+		index, err := c.addName(node.Value)
+		if err != nil {
+			return err
+		}
+
+		low, high := runeToBytes(index)
+		c.push(bytecode.DeclareName, high, low)
+
+		return nil
+
+	case *ast.Infix:
+		if node.Operator != "," {
+			break
+		}
+
+		var elems []ast.Expression
+		if node.Left != nil || node.Right != nil {
+			elems = c.expandTuple(node)
+		}
+
+		return c.compileSequencePattern(object.TupleType, elems, fails)
+
+	case *ast.Prefix:
+		switch node.Operator {
+		case ",":
+			return c.compileSequencePattern(object.TupleType, []ast.Expression{node.Right}, fails)
+		case "...":
+			return errors.New("compiler: a rest pattern (...) can only be the last element of a list or tuple pattern")
+		case "^":
+			pattern = node.Right
+		}
+
+	case *ast.List:
+		return c.compileSequencePattern(object.ListType, node.Value, fails)
+
+	case *ast.Map:
+		return c.compileMapPattern(node, fails)
+
+	case *ast.Call:
+		if id, ok := node.Function.(*ast.Identifier); ok && TypeNames[id.Value] {
+			if _, err := c.addAndLoad(&object.String{Value: id.Value}); err != nil {
+				return err
+			}
+
+			c.pushFailJump(bytecode.MatchType, fails)
+
+			return c.compilePattern(node.Argument, fails)
+		}
+	}
+
+	if err := c.CompileExpression(pattern); err != nil {
+		return err
+	}
+
+	c.pushFailJump(bytecode.MatchValue, fails)
+
+	return nil
+}
+
+func (c *Compiler) compileSequencePattern(t string, elems []ast.Expression, fails *[]int) error {
+	var rest ast.Expression
+
+	if len(elems) > 0 {
+		if pre, ok := elems[len(elems)-1].(*ast.Prefix); ok && pre.Operator == "..." {
+			rest = pre.Right
+			elems = elems[:len(elems)-1]
+		}
+	}
+
+	if _, err := c.addAndLoad(&object.String{Value: t}); err != nil {
+		return err
+	}
+
+	c.pushFailJump(bytecode.MatchType, fails)
+
+	if _, err := c.addAndLoad(&object.Integer{Value: int64(len(elems))}); err != nil {
+		return err
+	}
+
+	if _, err := c.addAndLoad(&object.Boolean{Value: rest != nil}); err != nil {
+		return err
+	}
+
+	c.pushFailJump(bytecode.MatchSequence, fails)
+
+	// MatchSequence leaves the first item on top of the stack
+	for _, elem := range elems {
+		if err := c.compilePattern(elem, fails); err != nil {
+			return err
+		}
+	}
+
+	if rest != nil {
+		return c.compilePattern(rest, fails)
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileMapPattern(node *ast.Map, fails *[]int) error {
+	if _, err := c.addAndLoad(&object.String{Value: object.MapType}); err != nil {
+		return err
+	}
+
+	c.pushFailJump(bytecode.MatchType, fails)
+
+	for _, pair := range node.Value {
+		key, val := pair.Key, pair.Value
+
+		if id, ok := key.(*ast.Identifier); ok {
+			if _, err := c.addAndLoad(&object.String{Value: id.Value}); err != nil {
+				return err
+			}
+		} else if err := c.CompileExpression(key); err != nil {
+			return err
+		}
+
+		c.pushFailJump(bytecode.MatchKey, fails)
+
+		if err := c.compilePattern(val, fails); err != nil {
+			return err
+		}
+	}
+
+	// Discard the map itself, now each value has been matched
+	c.push(bytecode.Pop)
+
+	return nil
+}
+
+// pushFailJump pushes an instruction whose argument is the jump target if the
+// match fails, and appends its position to fails.
+func (c *Compiler) pushFailJump(instr byte, fails *[]int) {
+	c.push(instr, 0, 0)
+	*fails = append(*fails, len(c.Bytes)-3)
+}
@@ -26,6 +26,8 @@ func (c *Compiler) CompileStatement(s ast.Statement) error {
 		return c.compileFor(node)
 	case *ast.Export:
 		return c.compileExport(node)
+	case *ast.Const:
+		return c.compileAssignOrDeclare(node.Target, node.Value, "const")
 	default:
 		return fmt.Errorf("compiler: compilation not yet implemented for %s", reflect.TypeOf(s))
 	}
@@ -52,10 +54,11 @@ func (c *Compiler) compileBreak(node *ast.Break) error {
 }
 
 func (c *Compiler) compileWhile(node *ast.While) error {
-	c.push(bytecode.StartLoop)
+	c.push(bytecode.StartLoop, 0, 0)
+	loopStart := len(c.Bytes) - 3
 
-	// Jump here for the next iteration
-	start := len(c.Bytes) - 1
+	// Jump here for the next iteration, after the START_LOOP
+	start := len(c.Bytes) + 1
 
 	if err := c.CompileExpression(node.Condition); err != nil {
 		return err
@@ -82,6 +85,7 @@ func (c *Compiler) compileWhile(node *ast.While) error {
 	c.setJumpArg(skipJump, len(c.Bytes)+1)
 
 	c.push(bytecode.EndLoop)
+	c.setJumpArg(loopStart, len(c.Bytes))
 
 	return nil
 }
@@ -91,35 +95,34 @@ func (c *Compiler) compileFor(node *ast.For) error {
 		return err
 	}
 
-	c.push(bytecode.PushIter, bytecode.StartLoop)
+	c.push(bytecode.PushIter, bytecode.StartLoop, 0, 0)
+	loopStart := len(c.Bytes) - 3
 
-	start := len(c.Bytes)
+	// Jump here for the next iteration, after the START_LOOP
+	start := len(c.Bytes) + 1
 
-	id, ok := node.Var.(*ast.Identifier)
-	if !ok {
-		return errors.New("compiler: a for-loop counter must be an identifier")
-	}
+	// The counter can be any assignment target, e.g. `for k, v in m`
+	c.push(bytecode.AdvIterFor)
 
-	index, err := c.addName(id.Value)
-	if err != nil {
+	if err := c.compileStoreTarget(node.Var, "declare"); err != nil {
 		return err
 	}
 
-	low, high := runeToBytes(rune(index))
-	c.push(bytecode.AdvIterFor, high, low)
-
 	if err := c.CompileExpression(node.Body); err != nil {
 		return err
 	}
 
-	index, err = c.addJump(start)
+	index, err := c.addJump(start)
 	if err != nil {
 		return err
 	}
-	low, high = runeToBytes(index)
+	low, high := runeToBytes(index)
 	c.push(bytecode.Jump, high, low)
 
-	c.push(bytecode.EndLoop, bytecode.PopIter)
+	c.push(bytecode.EndLoop)
+	c.setJumpArg(loopStart, len(c.Bytes))
+
+	c.push(bytecode.PopIter)
 
 	return nil
 }
@@ -1,106 +1,139 @@
 package lexer
 
 import (
-	"strings"
+	"unicode"
 
 	"github.com/Zac-Garby/radon/token"
 )
 
-type transformer func(token.Type, string, string) (token.Type, string, string)
-type handler func([]string) (token.Type, string, string)
-
-func lexemeHandler(t token.Type, group int, transformer transformer) handler {
-	return func(m []string) (token.Type, string, string) {
-		return transformer(t, m[group], m[0])
-	}
+// escapes maps the character following a backslash in a quoted string to the
+// rune the escape sequence represents. \x and \u escapes are handled
+// separately, since they take arguments.
+var escapes = map[byte]rune{
+	'n':  '\n',
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+	'{':  '{',
+	'}':  '}',
 }
 
-func none(t token.Type, literal, whole string) (token.Type, string, string) {
-	return t, literal, whole
+// punctuation maps every operator and punctuation literal to its token type.
+// When scanning, the longest matching literal is always chosen, so `//=` is
+// preferred over `//`, which is preferred over `/`.
+var punctuation = map[string]token.Type{
+	"->":  token.RightArrow,
+	"=>":  token.LambdaArrow,
+	"+=":  token.PlusEquals,
+	"+":   token.Plus,
+	"-=":  token.MinusEquals,
+	"-":   token.Minus,
+	"^=":  token.ExpEquals,
+	"^":   token.Exp,
+	"*=":  token.StarEquals,
+	"*":   token.Star,
+	"//=": token.FloorDivEquals,
+	"//":  token.FloorDiv,
+	"/=":  token.SlashEquals,
+	"/":   token.Slash,
+	"(":   token.LeftParen,
+	")":   token.RightParen,
+	"<=":  token.LessThanEq,
+	">=":  token.GreaterThanEq,
+	"<":   token.LessThan,
+	">":   token.GreaterThan,
+	"{":   token.LeftBrace,
+	"}":   token.RightBrace,
+	"[":   token.LeftSquare,
+	"]":   token.RightSquare,
+	";":   token.Semi,
+	"==":  token.Equal,
+	"!=":  token.NotEqual,
+	"||=": token.OrEquals,
+	"||":  token.Or,
+	"&&=": token.AndEquals,
+	"&&":  token.And,
+	"|=":  token.BitOrEquals,
+	"|>":  token.Pipe,
+	"|":   token.BitOr,
+	"&=":  token.BitAndEquals,
+	"&":   token.BitAnd,
+	"=":   token.Assign,
+	":=":  token.Declare,
+	",":   token.Comma,
+	":":   token.Colon,
+	"%=":  token.ModEquals,
+	"%":   token.Mod,
+	"...": token.Ellipsis,
+	".":   token.Dot,
+	"!":   token.Bang,
+	"$":   token.Apply,
 }
 
-func stringTransformer(t token.Type, literal, whole string) (token.Type, string, string) {
-	escapes := map[string]string{
-		`\n`: "\n",
-		`\"`: "\"",
-		`\a`: "\a",
-		`\b`: "\b",
-		`\f`: "\f",
-		`\r`: "\r",
-		`\t`: "\t",
-		`\v`: "\v",
-	}
+// maxPunctuationLength is the length, in bytes, of the longest literal in
+// punctuation.
+const maxPunctuationLength = 3
 
-	for k, v := range escapes {
-		literal = strings.Replace(literal, k, v, -1)
-	}
+// lineEndings are the token types after which a semicolon is inserted if
+// they're followed by a newline or the `end` keyword.
+var lineEndings = map[token.Type]bool{
+	token.ID:          true,
+	token.String:      true,
+	token.Number:      true,
+	token.True:        true,
+	token.False:       true,
+	token.Nil:         true,
+	token.Break:       true,
+	token.Next:        true,
+	token.Return:      true,
+	token.RightParen:  true,
+	token.RightSquare: true,
+	token.RightBrace:  true,
+	token.End:         true,
+}
 
-	return t, literal, whole
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
 }
 
-func idTransformer(t token.Type, literal, whole string) (token.Type, string, string) {
-	if kwType, ok := token.Keywords[literal]; ok {
-		return kwType, literal, whole
-	}
+func isIDStart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || r == '_' || r == '@'
+}
 
-	return t, literal, whole
+func isIDPart(r rune) bool {
+	return isIDStart(r) || isDigit(r) || r == '-' || r == '!' || r == '?'
 }
 
-type lexicalPair struct {
-	regex   string
-	handler handler
+func isHexDigit(r rune) bool {
+	return isDigit(r) || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F'
 }
 
-var lexicalDictionary = []lexicalPair{
-	// Literals
-	{regex: `^\d+(?:\.\d+)?`, handler: lexemeHandler(token.Number, 0, none)},
-	{regex: `^"((\\"|[^"])*)"`, handler: lexemeHandler(token.String, 1, stringTransformer)},
-	{regex: `^'((\\'|[^'])*)'`, handler: lexemeHandler(token.String, 1, stringTransformer)},
-	{regex: "^`([^`]*)`", handler: lexemeHandler(token.String, 1, none)},
-	{regex: `^[\p{L}\p{M}_@][\p{L}\p{M}\d_\-!?@]*`, handler: lexemeHandler(token.ID, 0, idTransformer)},
+// baseNames maps each base a number literal can be written in to its name.
+var baseNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	10: "decimal",
+	16: "hexadecimal",
+}
+
+// digitValue returns the value of the digit c, or 16 (larger than any
+// supported base) if c isn't a digit.
+func digitValue(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c-'a') + 10
+	case 'A' <= c && c <= 'F':
+		return int(c-'A') + 10
+	}
 
-	// Punctuation
-	{regex: `^->`, handler: lexemeHandler(token.RightArrow, 0, none)},
-	{regex: `^=>`, handler: lexemeHandler(token.LambdaArrow, 0, none)},
-	{regex: `^\+=`, handler: lexemeHandler(token.PlusEquals, 0, none)},
-	{regex: `^\+`, handler: lexemeHandler(token.Plus, 0, none)},
-	{regex: `^-=`, handler: lexemeHandler(token.MinusEquals, 0, none)},
-	{regex: `^-`, handler: lexemeHandler(token.Minus, 0, none)},
-	{regex: `^\^=`, handler: lexemeHandler(token.ExpEquals, 0, none)},
-	{regex: `^\^`, handler: lexemeHandler(token.Exp, 0, none)},
-	{regex: `^\*=`, handler: lexemeHandler(token.StarEquals, 0, none)},
-	{regex: `^\*`, handler: lexemeHandler(token.Star, 0, none)},
-	{regex: `^\/\/=`, handler: lexemeHandler(token.FloorDivEquals, 0, none)},
-	{regex: `^\/\/`, handler: lexemeHandler(token.FloorDiv, 0, none)},
-	{regex: `^\/=`, handler: lexemeHandler(token.SlashEquals, 0, none)},
-	{regex: `^\/`, handler: lexemeHandler(token.Slash, 0, none)},
-	{regex: `^\(`, handler: lexemeHandler(token.LeftParen, 0, none)},
-	{regex: `^\)`, handler: lexemeHandler(token.RightParen, 0, none)},
-	{regex: `^<=`, handler: lexemeHandler(token.LessThanEq, 0, none)},
-	{regex: `^>=`, handler: lexemeHandler(token.GreaterThanEq, 0, none)},
-	{regex: `^<`, handler: lexemeHandler(token.LessThan, 0, none)},
-	{regex: `^>`, handler: lexemeHandler(token.GreaterThan, 0, none)},
-	{regex: `^{`, handler: lexemeHandler(token.LeftBrace, 0, none)},
-	{regex: `^}`, handler: lexemeHandler(token.RightBrace, 0, none)},
-	{regex: `^\[`, handler: lexemeHandler(token.LeftSquare, 0, none)},
-	{regex: `^]`, handler: lexemeHandler(token.RightSquare, 0, none)},
-	{regex: `^;`, handler: lexemeHandler(token.Semi, 0, none)},
-	{regex: `^==`, handler: lexemeHandler(token.Equal, 0, none)},
-	{regex: `^!=`, handler: lexemeHandler(token.NotEqual, 0, none)},
-	{regex: `^\|\|=`, handler: lexemeHandler(token.OrEquals, 0, none)},
-	{regex: `^\|\|`, handler: lexemeHandler(token.Or, 0, none)},
-	{regex: `^&&=`, handler: lexemeHandler(token.AndEquals, 0, none)},
-	{regex: `^&&`, handler: lexemeHandler(token.And, 0, none)},
-	{regex: `^\|=`, handler: lexemeHandler(token.BitOrEquals, 0, none)},
-	{regex: `^\|`, handler: lexemeHandler(token.BitOr, 0, none)},
-	{regex: `^&=`, handler: lexemeHandler(token.BitAndEquals, 0, none)},
-	{regex: `^&`, handler: lexemeHandler(token.BitAnd, 0, none)},
-	{regex: `^=`, handler: lexemeHandler(token.Assign, 0, none)},
-	{regex: `^:=`, handler: lexemeHandler(token.Declare, 0, none)},
-	{regex: `^\,`, handler: lexemeHandler(token.Comma, 0, none)},
-	{regex: `^:`, handler: lexemeHandler(token.Colon, 0, none)},
-	{regex: `^%=`, handler: lexemeHandler(token.ModEquals, 0, none)},
-	{regex: `^%`, handler: lexemeHandler(token.Mod, 0, none)},
-	{regex: `^\.`, handler: lexemeHandler(token.Dot, 0, none)},
-	{regex: `^!`, handler: lexemeHandler(token.Bang, 0, none)},
+	return 16
 }
@@ -1,162 +1,645 @@
 package lexer
 
 import (
-	"regexp"
+	"fmt"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/Zac-Garby/radon/token"
 )
 
-var lineEndings = []token.Type{
-	token.ID,
-	token.String,
-	token.Number,
-	token.True,
-	token.False,
-	token.Nil,
-	token.Break,
-	token.Next,
-	token.Return,
-	token.RightParen,
-	token.RightSquare,
-	token.RightBrace,
-	token.End,
-}
-
 // Lexer takes a string and returns a stream of tokens
 // The stream of tokens is in the form of a function
-// which returns the next token.
+// which returns the next token. Once the end of the
+// input is reached, every call returns an EOF token.
 func Lexer(str, file string) func() token.Token {
+	s := &scanner{
+		src:  str,
+		file: file,
+		line: 1,
+		col:  1,
+	}
+
+	return s.next
+}
+
+// A scanner holds the state of a Lexer. It reads the source one rune at a
+// time, keeping track of the line and column (in runes, not bytes) of the
+// current position.
+type scanner struct {
+	src, file string
+	index     int
+	line, col int
+
+	// last is the position of the most recently consumed rune, which is
+	// used as the end position of the token being scanned.
+	last token.Position
+
+	// pending holds tokens which have already been scanned, such as inserted
+	// semicolons, and should be returned before scanning any more.
+	pending []token.Token
+
+	// interps is a stack of the string interpolations currently being
+	// scanned, the innermost last.
+	interps []interpolation
+
+	// resume is set after the end of an interpolated expression, meaning
+	// the next token is the rest of the string, delimited by resume.
+	resume string
+}
+
+// An interpolation is an expression embedded in a string literal, between a
+// pair of braces.
+type interpolation struct {
+	// delim is the delimiter of the string being interpolated into.
+	delim string
+
+	// depth is the number of braces opened inside the expression which are
+	// yet to be closed.
+	depth int
+}
+
+func (s *scanner) next() token.Token {
+	if len(s.pending) > 0 {
+		tok := s.pending[0]
+		copy(s.pending, s.pending[1:])
+		s.pending = s.pending[:len(s.pending)-1]
+
+		return tok
+	}
+
+	if s.resume != "" {
+		delim := s.resume
+		s.resume = ""
+
+		return s.insertSemi(s.scanStringBody(delim, s.pos()))
+	}
+
+	s.skipWhitespace()
+
+	if s.index >= len(s.src) {
+		pos := s.pos()
+
+		return token.Token{
+			Type:  token.EOF,
+			Start: pos,
+			End:   pos,
+		}
+	}
+
+	return s.insertSemi(s.scan())
+}
+
+// insertSemi queues a semicolon to be returned after tok if the end of the
+// line (or input) has been reached, or the `end` keyword is next. Semicolons
+// are never inserted inside interpolated expressions.
+func (s *scanner) insertSemi(tok token.Token) token.Token {
+	if tok.Type == token.Illegal || tok.Type == token.Malformed || tok.Type == token.InterpEnd || len(s.interps) > 0 {
+		return tok
+	}
+
+	s.skipLineWhitespace()
+
+	if s.index >= len(s.src) || lineEndings[tok.Type] && (s.src[s.index] == '\n' || s.atKeyword("end")) {
+		pos := s.pos()
+
+		s.pending = append(s.pending, token.Token{
+			Type:    token.Semi,
+			Literal: ";",
+			Start:   pos,
+			End:     pos,
+		})
+	}
+
+	return tok
+}
+
+// scan scans a single token starting at the current position, which mustn't
+// be whitespace or the end of the input.
+func (s *scanner) scan() token.Token {
+	r, _ := utf8.DecodeRuneInString(s.src[s.index:])
+
+	switch {
+	case isDigit(r):
+		return s.scanNumber()
+
+	case r == '"', r == '\'':
+		return s.scanString(r)
+
+	case r == '`':
+		return s.scanRawString()
+
+	case isIDStart(r):
+		return s.scanIdentifier()
+
+	case len(s.interps) > 0 && (r == '{' || r == '}'):
+		return s.scanInterpolationBrace(r)
+	}
+
+	return s.scanPunctuation()
+}
+
+func (s *scanner) scanNumber() token.Token {
+	tok := s.scanNumberLiteral()
+
+	// Skip the rest of a malformed number, so it isn't scanned as an
+	// identifier.
+	if tok.Type == token.Malformed {
+		for s.index < len(s.src) {
+			if r, _ := utf8.DecodeRuneInString(s.src[s.index:]); r != '_' && !isDigit(r) && !unicode.IsLetter(r) {
+				break
+			}
+
+			s.advance()
+		}
+	}
+
+	return tok
+}
+
+func (s *scanner) scanNumberLiteral() token.Token {
+	var (
+		start = s.pos()
+		begin = s.index
+		base  = 10
+	)
+
+	if s.peekByte(0) == '0' {
+		switch s.peekByte(1) {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+	}
+
+	if base != 10 {
+		s.advance()
+		s.advance()
+	}
+
+	if msg := s.scanDigits(base, base != 10); msg != "" {
+		return s.malformed(start, "%s", msg)
+	}
+
+	if base == 10 {
+		if s.peekByte(0) == '.' && isDigit(rune(s.peekByte(1))) {
+			s.advance()
+
+			if msg := s.scanDigits(10, false); msg != "" {
+				return s.malformed(start, "%s", msg)
+			}
+		}
+
+		if c := s.peekByte(0); c == 'e' || c == 'E' {
+			s.advance()
+
+			if c := s.peekByte(0); c == '+' || c == '-' {
+				s.advance()
+			}
+
+			if !isDigit(rune(s.peekByte(0))) {
+				return s.malformed(start, "exponent has no digits")
+			}
+
+			if msg := s.scanDigits(10, false); msg != "" {
+				return s.malformed(start, "%s", msg)
+			}
+		}
+
+		// A 'd' suffix makes the literal a decimal.
+		if s.peekByte(0) == 'd' {
+			s.advance()
+		}
+	}
+
+	// A literal can't run into an identifier, as in 12abc, or have digits after
+	// it which aren't valid in its base.
+	if r, _ := utf8.DecodeRuneInString(s.src[s.index:]); isDigit(r) || isIDStart(r) {
+		s.advance()
+		return s.malformed(start, "invalid digit '%c' in %s literal", r, baseNames[base])
+	}
+
+	return s.token(token.Number, s.src[begin:s.index], start)
+}
+
+// scanDigits scans a sequence of digits in the given base, which can be
+// separated by underscores. If the digits are malformed, a message describing
+// the problem is returned. A leading underscore is only allowed directly after
+// a base prefix, such as 0x.
+func (s *scanner) scanDigits(base int, afterPrefix bool) string {
+	var (
+		digits     = 0
+		underscore = false
+	)
+
+	for s.index < len(s.src) {
+		c := s.src[s.index]
+
+		if c == '_' {
+			if underscore || digits == 0 && !afterPrefix {
+				return "'_' must separate successive digits"
+			}
+
+			underscore = true
+		} else if digitValue(c) < base {
+			digits++
+			underscore = false
+		} else {
+			break
+		}
+
+		s.advance()
+	}
+
+	if digits == 0 {
+		return fmt.Sprintf("%s literal has no digits", baseNames[base])
+	}
+
+	if underscore {
+		return "'_' must separate successive digits"
+	}
+
+	return ""
+}
+
+func (s *scanner) scanString(quote rune) token.Token {
+	var (
+		start = s.pos()
+		delim = `'`
+	)
+
+	if quote == '"' {
+		delim = `"`
+
+		if strings.HasPrefix(s.src[s.index:], `"""`) {
+			delim = `"""`
+		}
+	}
+
+	for range delim {
+		s.advance()
+	}
+
+	// A newline directly after the opening delimiter of a multiline
+	// string isn't part of the string.
+	if delim == `"""` && s.peekByte(0) == '\n' {
+		s.advance()
+	}
+
+	return s.scanStringBody(delim, start)
+}
+
+// scanStringBody scans the contents of a quoted string, from the current
+// position up to and including either the closing delimiter or the opening
+// brace of an interpolated expression. In the latter case, an InterpStart
+// token is queued to follow the returned String token. Only double-quoted
+// strings are interpolated, so braces in single-quoted strings are literal.
+func (s *scanner) scanStringBody(delim string, start token.Position) token.Token {
+	var (
+		begin   = s.index
+		escaped strings.Builder
+	)
+
+	// literal returns the string scanned so far. escaped only contains
+	// anything if an escape sequence has been scanned.
+	literal := func() string {
+		if escaped.Len() == 0 {
+			return s.src[begin:s.index]
+		}
+
+		escaped.WriteString(s.src[begin:s.index])
+		return escaped.String()
+	}
+
+	for s.index < len(s.src) {
+		switch {
+		case strings.HasPrefix(s.src[s.index:], delim):
+			str := literal()
+
+			for range delim {
+				s.advance()
+			}
+
+			return s.token(token.String, str, start)
+
+		case delim != `'` && s.src[s.index] == '{':
+			var (
+				tok   = s.token(token.String, literal(), start)
+				brace = s.pos()
+			)
+
+			s.advance()
+
+			s.interps = append(s.interps, interpolation{delim: delim})
+			s.pending = append(s.pending, token.Token{
+				Type:    token.InterpStart,
+				Literal: "{",
+				Start:   brace,
+				End:     brace,
+			})
+
+			return tok
+
+		case s.src[s.index] == '\\':
+			escaped.WriteString(s.src[begin:s.index])
+
+			if tok, ok := s.scanEscape(&escaped); !ok {
+				s.skipStringBody(delim)
+				return tok
+			}
+
+			begin = s.index
+
+		default:
+			s.advance()
+		}
+	}
+
+	return s.malformed(start, "unterminated string literal")
+}
+
+// skipStringBody skips the rest of a quoted string after an error, so that
+// scanning can continue after it.
+func (s *scanner) skipStringBody(delim string) {
+	for s.index < len(s.src) && !strings.HasPrefix(s.src[s.index:], delim) {
+		if s.advance() == '\\' && s.index < len(s.src) {
+			s.advance()
+		}
+	}
+
+	for i := 0; i < len(delim) && s.index < len(s.src); i++ {
+		s.advance()
+	}
+}
+
+// scanEscape scans an escape sequence, starting at the backslash, and writes
+// the rune it represents to buf. If the sequence is invalid, a Malformed token
+// is returned along with false.
+func (s *scanner) scanEscape(buf *strings.Builder) (token.Token, bool) {
+	start := s.pos()
+	s.advance()
+
+	if s.index >= len(s.src) {
+		return s.malformed(start, "unterminated string literal"), false
+	}
+
+	c := s.src[s.index]
+
+	if r, ok := escapes[c]; ok {
+		s.advance()
+		buf.WriteRune(r)
+		return token.Token{}, true
+	}
+
+	var digits string
+
+	switch c {
+	case 'x':
+		s.advance()
+		digits = s.scanHexDigits(2)
+
+		if len(digits) != 2 {
+			return s.malformed(start, "\\x must be followed by exactly two hexadecimal digits"), false
+		}
+
+	case 'u':
+		s.advance()
+
+		if s.peekByte(0) == '{' {
+			s.advance()
+			digits = s.scanHexDigits(6)
+
+			if len(digits) == 0 || s.peekByte(0) != '}' {
+				return s.malformed(start, "\\u{...} must contain between one and six hexadecimal digits"), false
+			}
+
+			s.advance()
+		} else if digits = s.scanHexDigits(4); len(digits) != 4 {
+			return s.malformed(start, "\\u must be followed by exactly four hexadecimal digits, or {...}"), false
+		}
+
+	default:
+		r, _ := utf8.DecodeRuneInString(s.src[s.index:])
+		s.advance()
+
+		return s.malformed(start, "unknown escape sequence: \\%c", r), false
+	}
+
+	code, _ := strconv.ParseUint(digits, 16, 32)
+	r := rune(code)
+
+	if !utf8.ValidRune(r) {
+		return s.malformed(start, "invalid unicode code point: %U", r), false
+	}
+
+	buf.WriteRune(r)
+
+	return token.Token{}, true
+}
+
+// scanHexDigits scans at most max hexadecimal digits, returning them.
+func (s *scanner) scanHexDigits(max int) string {
+	begin := s.index
+
+	for s.index-begin < max && isHexDigit(rune(s.peekByte(0))) {
+		s.advance()
+	}
+
+	return s.src[begin:s.index]
+}
+
+// scanInterpolationBrace scans a brace inside an interpolated expression. The
+// brace which closes the expression becomes an InterpEnd token, and the next
+// token scanned will be the remainder of the string.
+func (s *scanner) scanInterpolationBrace(brace rune) token.Token {
+	interp := &s.interps[len(s.interps)-1]
+
+	if brace == '{' {
+		interp.depth++
+		return s.scanPunctuation()
+	}
+
+	if interp.depth > 0 {
+		interp.depth--
+		return s.scanPunctuation()
+	}
+
+	s.resume = interp.delim
+	s.interps = s.interps[:len(s.interps)-1]
+
+	start := s.pos()
+	s.advance()
+
+	return s.token(token.InterpEnd, "}", start)
+}
+
+func (s *scanner) scanRawString() token.Token {
 	var (
-		index = 0
-		col   = 1
-		line  = 1
-		ch    = make(chan token.Token)
+		start = s.pos()
+		begin = s.index
 	)
 
-	go func() {
-		for {
-			if index < len(str) {
-				foundSpace := false
-
-				for index < len(str) && (unicode.IsSpace(rune(str[index])) || str[index] == '#') {
-					if unicode.IsSpace(rune(str[index])) {
-						index++
-						col++
-
-						if str[index-1] == '\n' {
-							col = 1
-							line++
-						}
-
-						foundSpace = true
-					} else {
-						for index < len(str) && str[index] != '\n' {
-							index++
-						}
-
-						col = 1
-					}
-				}
-
-				if foundSpace {
-					continue
-				}
-
-				found := false
-
-				remainingSubstring := str[index:]
-
-				for _, pair := range lexicalDictionary {
-					var (
-						regex   = pair.regex
-						handler = pair.handler
-						pattern = regexp.MustCompile(regex)
-						match   = pattern.FindStringSubmatch(remainingSubstring)
-					)
-
-					if len(match) > 0 {
-						found = true
-						t, literal, whole := handler(match)
-						l := len(whole)
-
-						ch <- token.Token{
-							Type:    t,
-							Literal: literal,
-							Start:   token.Position{Line: line, Column: col, Filename: file},
-							End:     token.Position{Line: line, Column: col + l - 1, Filename: file},
-						}
-
-						index += l
-						col += l
-
-						for index < len(str) && unicode.IsSpace(rune(str[index])) && str[index] != '\n' {
-							index++
-							col++
-						}
-
-						if index < len(str) && str[index] == '#' {
-							for index < len(str) && str[index] != '\n' {
-								index++
-							}
-						}
-
-						isLineEnding := false
-
-						for _, ending := range lineEndings {
-							if t == ending {
-								isLineEnding = true
-							}
-						}
-
-						// HACK: This is a horrible piece of code, but it works.
-						var isEnd bool
-						if index < len(str) {
-							isEnd = str[index] == '\n'
-							if index+2 < len(str) {
-								isEnd = isEnd || str[index] == 'e' && str[index+1] == 'n' && str[index+2] == 'd'
-							}
-						}
-
-						if (isLineEnding && index < len(str) && isEnd) || index >= len(str) {
-							ch <- token.Token{
-								Type:    token.Semi,
-								Literal: ";",
-								Start:   token.Position{Line: line, Column: col, Filename: file},
-								End:     token.Position{Line: line, Column: col, Filename: file},
-							}
-						}
-
-						break
-					}
-				}
-
-				if !found && index < len(str) {
-					ch <- token.Token{
-						Type:    token.Illegal,
-						Literal: string(str[index]),
-						Start:   token.Position{Line: line, Column: col, Filename: file},
-						End:     token.Position{Line: line, Column: col, Filename: file},
-					}
-
-					index++
-					col++
-				}
-			} else {
-				index++
-				col++
-
-				ch <- token.Token{
-					Type:    token.EOF,
-					Literal: "",
-					Start:   token.Position{Line: line, Column: col, Filename: file},
-					End:     token.Position{Line: line, Column: col, Filename: file},
-				}
+	s.advance()
+
+	for s.index < len(s.src) {
+		if s.advance() == '`' {
+			return s.token(token.String, s.src[begin+1:s.index-1], start)
+		}
+	}
+
+	return s.malformed(start, "unterminated string literal")
+}
+
+func (s *scanner) scanIdentifier() token.Token {
+	var (
+		start = s.pos()
+		begin = s.index
+	)
+
+	s.advance()
+
+	for s.index < len(s.src) {
+		r, _ := utf8.DecodeRuneInString(s.src[s.index:])
+		if !isIDPart(r) {
+			break
+		}
+
+		s.advance()
+	}
+
+	literal := s.src[begin:s.index]
+
+	if kwType, ok := token.Keywords[literal]; ok {
+		return s.token(kwType, literal, start)
+	}
+
+	return s.token(token.ID, literal, start)
+}
+
+func (s *scanner) scanPunctuation() token.Token {
+	start := s.pos()
+
+	for n := maxPunctuationLength; n > 0; n-- {
+		if s.index+n > len(s.src) {
+			continue
+		}
+
+		literal := s.src[s.index : s.index+n]
+
+		if t, ok := punctuation[literal]; ok {
+			for i := 0; i < n; i++ {
+				s.advance()
 			}
+
+			return s.token(t, literal, start)
+		}
+	}
+
+	return s.token(token.Illegal, string(s.advance()), start)
+}
+
+// advance consumes the rune at the current position, returning it.
+func (s *scanner) advance() rune {
+	r, size := utf8.DecodeRuneInString(s.src[s.index:])
+
+	s.last = s.pos()
+	s.index += size
+
+	if r == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+
+	return r
+}
+
+// peekByte returns the byte n bytes after the current position, or 0 if
+// that's past the end of the input.
+func (s *scanner) peekByte(n int) byte {
+	if s.index+n >= len(s.src) {
+		return 0
+	}
+
+	return s.src[s.index+n]
+}
+
+// skipWhitespace skips all whitespace, including newlines, and comments.
+func (s *scanner) skipWhitespace() {
+	for s.index < len(s.src) {
+		r, _ := utf8.DecodeRuneInString(s.src[s.index:])
+
+		if r == '#' {
+			s.skipComment()
+		} else if unicode.IsSpace(r) {
+			s.advance()
+		} else {
+			return
+		}
+	}
+}
+
+// skipLineWhitespace skips whitespace up to (but not including) the next
+// newline, and a comment if the line ends with one.
+func (s *scanner) skipLineWhitespace() {
+	for s.index < len(s.src) && s.src[s.index] != '\n' {
+		r, _ := utf8.DecodeRuneInString(s.src[s.index:])
+
+		if r == '#' {
+			s.skipComment()
+		} else if unicode.IsSpace(r) {
+			s.advance()
+		} else {
+			return
 		}
-	}()
+	}
+}
+
+// skipComment skips to the end of the line, leaving the newline unconsumed.
+func (s *scanner) skipComment() {
+	for s.index < len(s.src) && s.src[s.index] != '\n' {
+		s.advance()
+	}
+}
+
+// atKeyword checks whether the input at the current position is the keyword
+// kw, and not just an identifier beginning with it.
+func (s *scanner) atKeyword(kw string) bool {
+	if !strings.HasPrefix(s.src[s.index:], kw) {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(s.src[s.index+len(kw):])
+
+	return s.index+len(kw) >= len(s.src) || !isIDPart(r)
+}
+
+func (s *scanner) pos() token.Position {
+	return token.Position{
+		Line:     s.line,
+		Column:   s.col,
+		Filename: s.file,
+	}
+}
+
+// malformed makes a Malformed token from start up to the last consumed rune,
+// describing the problem with format and args.
+func (s *scanner) malformed(start token.Position, format string, args ...interface{}) token.Token {
+	return s.token(token.Malformed, fmt.Sprintf(format, args...), start)
+}
 
-	return func() token.Token {
-		return <-ch
+// token makes a token of type t, from start up to the last consumed rune.
+func (s *scanner) token(t token.Type, literal string, start token.Position) token.Token {
+	return token.Token{
+		Type:    t,
+		Literal: literal,
+		Start:   start,
+		End:     s.last,
 	}
 }
@@ -1,6 +1,7 @@
 package lexer_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/Zac-Garby/radon/lexer"
@@ -11,14 +12,14 @@ func TestLexing(t *testing.T) {
 	input := `123.50 1 2#;
 	"hello" "hello world" foo bar # where a semicolon should be inserted
 	+-*^/ //%()<><=>={}[];==!=||&& # a comment after a line
-	| & = := , -> : . ! += -=#this comment touches the token
+	| & = := , -> : . ... ! |> $ += -=#this comment touches the token
 	*= ^= /= //= %= ||= &&= |= &= #nospacesnospacesnospaces!!
 
 	# keywords now :)
 	return true false nil if then else
 	while for next break match model in
 
-	$
+	~
 	`
 
 	expected := []Type{
@@ -29,7 +30,7 @@ func TestLexing(t *testing.T) {
 		LessThanEq, GreaterThanEq, LeftBrace, RightBrace,
 		LeftSquare, RightSquare, Semi, Equal, NotEqual,
 		Or, And, BitOr, BitAnd, Assign, Declare,
-		Comma, RightArrow, Colon, Dot, Bang,
+		Comma, RightArrow, Colon, Dot, Ellipsis, Bang, Pipe, Apply,
 		PlusEquals, MinusEquals, StarEquals, ExpEquals,
 		SlashEquals, FloorDivEquals, ModEquals, OrEquals,
 		AndEquals, BitOrEquals, BitAndEquals,
@@ -56,3 +57,294 @@ func TestLexing(t *testing.T) {
 		}
 	}
 }
+
+func TestPositions(t *testing.T) {
+	input := `naïve = "héllo"
+	ünï + 1`
+
+	expected := []struct {
+		typ        Type
+		start, end int
+		line       int
+	}{
+		{ID, 1, 5, 1},
+		{Assign, 7, 7, 1},
+		{String, 9, 15, 1},
+		{Semi, 16, 16, 1},
+		{ID, 2, 4, 2},
+		{Plus, 6, 6, 2},
+		{Number, 8, 8, 2},
+		{Semi, 9, 9, 2},
+	}
+
+	next := lexer.Lexer(input, "test")
+
+	for i, exp := range expected {
+		tok := next()
+
+		if tok.Type != exp.typ {
+			t.Errorf("(%v) expected %s, got %s\n", i, exp.typ, tok.Type)
+			continue
+		}
+
+		if tok.Start.Column != exp.start || tok.End.Column != exp.end || tok.Start.Line != exp.line {
+			t.Errorf("(%v) expected %d:%d-%d, got %s → %s\n", i, exp.line, exp.start, exp.end, tok.Start.String(), tok.End.String())
+		}
+	}
+}
+
+func TestSemicolonInsertion(t *testing.T) {
+	tests := map[string][]Type{
+		"do x end":      {Do, ID, Semi, End, Semi},
+		"do x ending":   {Do, ID, ID, Semi},
+		"x +\ny":        {ID, Plus, ID, Semi},
+		"x # comment\n": {ID, Semi},
+		"f (\n1)":       {ID, LeftParen, Number, RightParen, Semi},
+	}
+
+	for input, expected := range tests {
+		next := lexer.Lexer(input, "test")
+
+		for i, exp := range expected {
+			if tok := next(); tok.Type != exp {
+				t.Errorf("%q (%v): expected %s, got %s\n", input, i, exp, tok.Type)
+				break
+			}
+		}
+	}
+}
+
+func TestTermination(t *testing.T) {
+	next := lexer.Lexer("a", "test")
+
+	for _, exp := range []Type{ID, Semi, EOF, EOF, EOF} {
+		if tok := next(); tok.Type != exp {
+			t.Errorf("expected %s, got %s\n", exp, tok.Type)
+		}
+	}
+}
+
+func BenchmarkLexer(b *testing.B) {
+	chunk := `calc input = do
+    result = 0 # a comment
+
+    for character in input do
+        result = match character where
+            | "+" -> result + 1,
+            | "-" -> result - 1,
+            | "*" -> result * 2.5,
+            | "/" -> result // 2
+    end
+end
+
+print calc "++*++/", {a: [1, 2, 3], b: 'naïve'}
+`
+
+	input := strings.Repeat(chunk, 1000)
+
+	b.SetBytes(int64(len(input)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		next := lexer.Lexer(input, "bench")
+
+		for tok := next(); tok.Type != EOF; tok = next() {
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := map[string]string{
+		`"a\nb"`:             "a\nb",
+		`"\\"`:               `\`,
+		`'it\'s'`:            "it's",
+		`"\"q\""`:            `"q"`,
+		`"\x41\x62"`:         "Ab",
+		`"é"`:                "é",
+		`"\u{1F600}"`:        "😀",
+		`"\{not\}"`:          "{not}",
+		`'{not} {x}'`:        "{not} {x}",
+		"`raw \\n {x}`":      `raw \n {x}`,
+		`"""a "b" c"""`:      `a "b" c`,
+		"\"\"\"\nline\"\"\"": "line",
+	}
+
+	for input, expected := range tests {
+		tok := lexer.Lexer(input, "test")()
+
+		if tok.Type != String {
+			t.Errorf("%s: expected a string, got %s (%s)\n", input, tok.Type, tok.Literal)
+			continue
+		}
+
+		if tok.Literal != expected {
+			t.Errorf("%s: expected %q, got %q\n", input, expected, tok.Literal)
+		}
+	}
+}
+
+func TestMalformedStrings(t *testing.T) {
+	tests := map[string]string{
+		`"\q"`:         `unknown escape sequence: \q`,
+		`"\x4"`:        `\x must be followed by exactly two hexadecimal digits`,
+		`"\u12"`:       `\u must be followed by exactly four hexadecimal digits, or {...}`,
+		`"\u{}"`:       `\u{...} must contain between one and six hexadecimal digits`,
+		`"\u{110000}"`: `invalid unicode code point: U+110000`,
+		`"abc`:         `unterminated string literal`,
+		"`abc":         `unterminated string literal`,
+	}
+
+	for input, expected := range tests {
+		tok := lexer.Lexer(input, "test")()
+
+		if tok.Type != Malformed {
+			t.Errorf("%s: expected a malformed token, got %s\n", input, tok.Type)
+			continue
+		}
+
+		if tok.Literal != expected {
+			t.Errorf("%s: expected %q, got %q\n", input, expected, tok.Literal)
+		}
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	input := `"a {b} c {d + {e: "f {g}"}} h" i`
+
+	expected := []struct {
+		typ     Type
+		literal string
+	}{
+		{String, "a "},
+		{InterpStart, "{"},
+		{ID, "b"},
+		{InterpEnd, "}"},
+		{String, " c "},
+		{InterpStart, "{"},
+		{ID, "d"},
+		{Plus, "+"},
+		{LeftBrace, "{"},
+		{ID, "e"},
+		{Colon, ":"},
+		{String, "f "},
+		{InterpStart, "{"},
+		{ID, "g"},
+		{InterpEnd, "}"},
+		{String, ""},
+		{RightBrace, "}"},
+		{InterpEnd, "}"},
+		{String, " h"},
+		{ID, "i"},
+		{Semi, ";"},
+		{EOF, ""},
+	}
+
+	next := lexer.Lexer(input, "test")
+
+	for i, exp := range expected {
+		tok := next()
+
+		if tok.Type != exp.typ || tok.Literal != exp.literal {
+			t.Errorf("(%v) expected %s `%s`, got %s `%s`\n", i, exp.typ, exp.literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestHashComments(t *testing.T) {
+	input := `#{ a comment
+	f #{ note } x
+	"#{b}" #{ after a string
+	{} #{ after a brace
+	5 #{`
+
+	expected := []struct {
+		typ     Type
+		literal string
+	}{
+		{ID, "f"},
+		{Semi, ";"},
+		{String, "#"},
+		{InterpStart, "{"},
+		{ID, "b"},
+		{InterpEnd, "}"},
+		{String, ""},
+		{Semi, ";"},
+		{LeftBrace, "{"},
+		{RightBrace, "}"},
+		{Semi, ";"},
+		{Number, "5"},
+		{Semi, ";"},
+		{EOF, ""},
+	}
+
+	next := lexer.Lexer(input, "test")
+
+	for i, exp := range expected {
+		tok := next()
+
+		if tok.Type != exp.typ || tok.Literal != exp.literal {
+			t.Errorf("(%v) expected %s `%s`, got %s `%s`\n", i, exp.typ, exp.literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []string{
+		"0", "123", "1.5", "1_000_000", "1_000.000_1",
+		"1e9", "1E9", "1.5e-3", "2e+10", "1_0e1_0",
+		"0xff", "0XFF", "0x_dead_beef", "0b1010", "0B1", "0o17", "0O7_7",
+		"1d", "12.34d", "1_000.50d", "1e3d",
+	}
+
+	for _, input := range tests {
+		next := lexer.Lexer(input, "test")
+
+		if tok := next(); tok.Type != Number || tok.Literal != input {
+			t.Errorf("%s: expected a number, got %s `%s`\n", input, tok.Type, tok.Literal)
+		}
+
+		if tok := next(); tok.Type != Semi {
+			t.Errorf("%s: expected the number to be a single token, got %s after it\n", input, tok.Type)
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	tests := map[string]string{
+		"0x":      "hexadecimal literal has no digits",
+		"0b":      "binary literal has no digits",
+		"0b102":   "invalid digit '2' in binary literal",
+		"0o8":     "octal literal has no digits",
+		"0o78":    "invalid digit '8' in octal literal",
+		"0xfg":    "invalid digit 'g' in hexadecimal literal",
+		"1__000":  "'_' must separate successive digits",
+		"1_":      "'_' must separate successive digits",
+		"0x__1":   "'_' must separate successive digits",
+		"1e":      "exponent has no digits",
+		"1.5e+":   "exponent has no digits",
+		"1.5e_1":  "exponent has no digits",
+		"2e10_":   "'_' must separate successive digits",
+		"0b1_1__": "'_' must separate successive digits",
+		"12abc":   "invalid digit 'a' in decimal literal",
+		"1.5x":    "invalid digit 'x' in decimal literal",
+		"1e3f":    "invalid digit 'f' in decimal literal",
+		"1dx":     "invalid digit 'x' in decimal literal",
+		"1d5":     "invalid digit '5' in decimal literal",
+		"2do":     "invalid digit 'o' in decimal literal",
+		"12é":     "invalid digit 'é' in decimal literal",
+		"12א":     "invalid digit 'א' in decimal literal",
+		"1aא":     "invalid digit 'a' in decimal literal",
+	}
+
+	for input, expected := range tests {
+		next := lexer.Lexer(input, "test")
+
+		if tok := next(); tok.Type != Malformed || tok.Literal != expected {
+			t.Errorf("%s: expected malformed `%s`, got %s `%s`\n", input, expected, tok.Type, tok.Literal)
+		}
+
+		if tok := next(); tok.Type != EOF {
+			t.Errorf("%s: expected the rest of the number to be skipped, got %s\n", input, tok.Type)
+		}
+	}
+}
@@ -2,16 +2,58 @@ package object
 
 import (
 	"fmt"
+	"io"
+	"math/rand"
 )
 
 // A Builtin is a function which has been written in Go but is callable from
-// a Radon program.
+// a Radon program. If Arity is non-zero, calling a builtin with fewer arguments
+// than its arity returns a Partial instead of calling Fn.
 type Builtin struct {
 	defaults
-	Name string
-	Fn   func(args ...Object) (result Object, errorType string, errorMessage string)
+	Name  string
+	Arity int
+	Fn    func(args ...Object) (result Object, errorType string, errorMessage string)
+
+	// Contextual is used instead of Fn by builtins which need to know about the
+	// program calling them, such as re.replace, which calls the function passed to
+	// it, and input, which reads from the program's input.
+	Contextual func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string)
+}
+
+// A Context is given to contextual builtins by the virtual machine running the
+// program which calls them.
+type Context struct {
+	// Call calls a function passed to the builtin.
+	Call Caller
+
+	// In is the program's input, and Out is where its output is written.
+	In  *Stream
+	Out io.Writer
+
+	// Env looks up an environment variable, returning false if it isn't set. It may
+	// be nil, in which case no variables are set.
+	Env func(name string) (string, bool)
+
+	// FileRoot is the directory which the fs module is confined to. If it's empty,
+	// programs can access any file.
+	FileRoot string
+
+	// Decimals holds the settings for decimal arithmetic, which the program can
+	// change.
+	Decimals *DecimalContext
+
+	// Random is the generator used by the random module, which random.seed seeds.
+	Random *rand.Rand
+
+	// Exit records the code which the program exits with, when exit is called.
+	Exit func(code int)
 }
 
+// A Caller calls a function, or anything else which can be called, from inside a
+// builtin. If the call fails, the type and message of the error are returned.
+type Caller func(fn Object, args ...Object) (result Object, errorType string, errorMessage string)
+
 func (b *Builtin) String() string {
 	return fmt.Sprintf("<builtin %s>", b.Name)
 }
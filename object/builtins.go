@@ -2,6 +2,7 @@ package object
 
 import (
 	"fmt"
+	"io"
 )
 
 // Builtins contains every builtin.
@@ -10,41 +11,15 @@ var Builtins = make(map[string]*Builtin)
 func init() {
 	Builtins["print"] = &Builtin{
 		Name: "print",
-		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
-			for i, arg := range args {
-				if str, ok := arg.(*String); ok {
-					fmt.Print(str.Value)
-				} else {
-					fmt.Print(arg)
-				}
-
-				if i+1 < len(args) {
-					fmt.Print(" ")
-				}
-			}
-
-			fmt.Print("\n")
-
-			return &Nil{}, "", ""
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			return write(ctx.Out, args, "\n")
 		},
 	}
 
 	Builtins["put"] = &Builtin{
 		Name: "put",
-		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
-			for i, arg := range args {
-				if str, ok := arg.(*String); ok {
-					fmt.Print(str.Value)
-				} else {
-					fmt.Print(arg)
-				}
-
-				if i+1 < len(args) {
-					fmt.Print(" ")
-				}
-			}
-
-			return &Nil{}, "", ""
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			return write(ctx.Out, args, "")
 		},
 	}
 
@@ -62,7 +37,7 @@ func init() {
 				total += len(items)
 			}
 
-			return &Number{Value: float64(total)}, "", ""
+			return &Integer{Value: int64(total)}, "", ""
 		},
 	}
 
@@ -71,7 +46,7 @@ func init() {
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) == 1 {
 				if items, ok := args[0].Items(); ok {
-					return &Tuple{Value: items}, "", ""
+					return &Tuple{Value: append([]Object(nil), items...)}, "", ""
 				}
 			}
 
@@ -84,7 +59,7 @@ func init() {
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) == 1 {
 				if items, ok := args[0].Items(); ok {
-					return &List{Value: items}, "", ""
+					return &List{Value: append([]Object(nil), items...)}, "", ""
 				}
 			}
 
@@ -94,19 +69,155 @@ func init() {
 
 	Builtins["items"] = Builtins["list"]
 
+	Builtins["set"] = &Builtin{
+		Name: "set",
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			items := args
+
+			if len(args) == 1 {
+				if argItems, ok := args[0].Items(); ok {
+					items = argItems
+				}
+			}
+
+			set := NewSet(len(items))
+
+			for _, item := range items {
+				if !set.Add(item) {
+					return nil, "Type", fmt.Sprintf("a value of type %s can't be in a set, since it isn't hashable", item.Type())
+				}
+			}
+
+			return set, "", ""
+		},
+	}
+
+	Builtins["slice"] = &Builtin{
+		Name: "slice",
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			parts := []Object{&Nil{}, &Nil{}, &Nil{}}
+
+			switch len(args) {
+			case 1:
+				parts[1] = args[0]
+			case 2, 3:
+				copy(parts, args)
+			default:
+				return nil, "Argument", "expected between one and three arguments to slice(...)"
+			}
+
+			slice, ok := NewSlice(parts[0], parts[1], parts[2])
+			if !ok {
+				return nil, "Type", "the arguments to slice(...) should be integers or nil"
+			}
+
+			return slice, "", ""
+		},
+	}
+
 	Builtins["str"] = &Builtin{
-		Name: "str",
+		Name:  "str",
+		Arity: 1,
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) != 1 {
 				return nil, "Argument", "expected exactly one argument to str(...)"
 			}
 
+			// Strings are copied rather than returned as they are, since they can
+			// be mutated through subscripts.
+			if str, ok := args[0].(*String); ok {
+				return &String{Value: str.Value}, "", ""
+			}
+
 			return &String{Value: args[0].String()}, "", ""
 		},
 	}
 
+	Builtins["decimal"] = &Builtin{
+		Name: "decimal",
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) < 1 || len(args) > 3 {
+				return nil, "Argument", "expected between one and three arguments to decimal(...)"
+			}
+
+			var (
+				dec *Decimal
+				ok  bool
+			)
+
+			if str, isStr := args[0].(*String); isStr {
+				dec, ok = ParseDecimal(str.Value)
+			} else {
+				dec, ok = ToDecimal(args[0])
+			}
+
+			if !ok {
+				return nil, "Type", fmt.Sprintf("cannot convert %s to a decimal", args[0])
+			}
+
+			if len(args) == 1 {
+				return dec, "", ""
+			}
+
+			places, ok := ToInt(args[1])
+			if !ok || places < 0 {
+				return nil, "Type", "the second argument to decimal(...) should be a non-negative number of decimal places"
+			}
+
+			mode := ctx.Decimals.Rounding
+			if len(args) == 3 {
+				if mode, ok = toRoundingMode(args[2]); !ok {
+					return nil, "Type", fmt.Sprintf("unknown rounding mode: %s", args[2])
+				}
+			}
+
+			return dec.Round(places, mode), "", ""
+		},
+	}
+
+	Builtins["decimal-precision"] = &Builtin{
+		Name:  "decimal-precision",
+		Arity: 1,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to decimal-precision(...)"
+			}
+
+			precision, ok := ToInt(args[0])
+			if !ok || precision < 1 {
+				return nil, "Type", "the decimal precision should be a positive number of digits"
+			}
+
+			previous := ctx.Decimals.Precision
+			ctx.Decimals.Precision = precision
+
+			return &Integer{Value: int64(previous)}, "", ""
+		},
+	}
+
+	Builtins["decimal-rounding"] = &Builtin{
+		Name:  "decimal-rounding",
+		Arity: 1,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to decimal-rounding(...)"
+			}
+
+			mode, ok := toRoundingMode(args[0])
+			if !ok {
+				return nil, "Type", fmt.Sprintf("unknown rounding mode: %s", args[0])
+			}
+
+			previous := ctx.Decimals.Rounding
+			ctx.Decimals.Rounding = mode
+
+			return &String{Value: string(previous)}, "", ""
+		},
+	}
+
 	Builtins["id"] = &Builtin{
-		Name: "id",
+		Name:  "id",
+		Arity: 1,
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) != 1 {
 				return nil, "Argument", "expected exactly one argument to id(...)"
@@ -116,8 +227,35 @@ func init() {
 		},
 	}
 
+	// freeze returns a frozen copy of its argument, leaving the argument mutable,
+	// except that a model is frozen in place.
+	Builtins["freeze"] = &Builtin{
+		Name:  "freeze",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to freeze(...)"
+			}
+
+			return FrozenCopy(args[0]), "", ""
+		},
+	}
+
+	Builtins["frozen"] = &Builtin{
+		Name:  "frozen",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to frozen(...)"
+			}
+
+			return &Boolean{Value: IsFrozen(args[0])}, "", ""
+		},
+	}
+
 	Builtins["type"] = &Builtin{
-		Name: "type",
+		Name:  "type",
+		Arity: 1,
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) != 1 {
 				return nil, "Argument", "expected exactly one argument to type(...)"
@@ -127,8 +265,28 @@ func init() {
 		},
 	}
 
+	Builtins["implements"] = &Builtin{
+		Name:  "implements",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 2 {
+				return nil, "Argument", "expected exactly two arguments to implements(...)"
+			}
+
+			protocol, ok := args[1].(*Protocol)
+			if !ok {
+				return nil, "Type", fmt.Sprintf("the second argument to implements(...) should be a protocol, not a %s", args[1].Type())
+			}
+
+			implements, _ := protocol.Implements(args[0])
+
+			return &Boolean{Value: implements}, "", ""
+		},
+	}
+
 	Builtins["prefix"] = &Builtin{
-		Name: "prefix",
+		Name:  "prefix",
+		Arity: 2,
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) != 2 {
 				return nil, "Argument", "expected exactly two arguments to prefix(...)"
@@ -153,7 +311,8 @@ func init() {
 	}
 
 	Builtins["infix"] = &Builtin{
-		Name: "infix",
+		Name:  "infix",
+		Arity: 3,
 		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
 			if len(args) != 3 {
 				return nil, "Argument", "expected exactly three arguments to infix(...)"
@@ -178,3 +337,41 @@ func init() {
 		},
 	}
 }
+
+// toRoundingMode converts a String, such as "half-up", to a RoundingMode.
+func toRoundingMode(o Object) (RoundingMode, bool) {
+	if str, ok := o.(*String); ok {
+		for _, mode := range RoundingModes {
+			if string(mode) == str.Value {
+				return mode, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// write writes args to w, separated by spaces and followed by end, for print and
+// put. Strings are written without quotes.
+func write(w io.Writer, args []Object, end string) (Object, string, string) {
+	for i, arg := range args {
+		text := arg.String()
+		if str, ok := arg.(*String); ok {
+			text = str.Value
+		}
+
+		if i+1 < len(args) {
+			text += " "
+		}
+
+		if _, err := io.WriteString(w, text); err != nil {
+			return nil, "IO", fmt.Sprintf("cannot write the output: %s", err)
+		}
+	}
+
+	if _, err := io.WriteString(w, end); err != nil {
+		return nil, "IO", fmt.Sprintf("cannot write the output: %s", err)
+	}
+
+	return &Nil{}, "", ""
+}
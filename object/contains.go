@@ -0,0 +1,58 @@
+package object
+
+import "strings"
+
+// Contains checks whether container contains item. Containers are checked with their
+// Contains method, and any other object which can be iterated is searched for an
+// equal item. The second return value is false if container can't contain anything.
+func Contains(container, item Object) (bool, bool) {
+	if c, ok := container.(Container); ok {
+		return c.Contains(item), true
+	}
+
+	iter, ok := container.Iter()
+	if !ok {
+		return false, false
+	}
+
+	for elem, ok := iter.Next(); ok; elem, ok = iter.Next() {
+		if elem.Equals(item) {
+			return true, true
+		}
+	}
+
+	return false, true
+}
+
+// containsItem checks whether any of items is equal to item.
+func containsItem(items []Object, item Object) bool {
+	for _, elem := range items {
+		if elem.Equals(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Contains checks whether the list has an item equal to item.
+func (l *List) Contains(item Object) bool {
+	return containsItem(l.Value, item)
+}
+
+// Contains checks whether the tuple has an item equal to item.
+func (t *Tuple) Contains(item Object) bool {
+	return containsItem(t.Value, item)
+}
+
+// Contains checks whether the map has the key item.
+func (m *Map) Contains(item Object) bool {
+	_, ok := m.Get(item)
+	return ok
+}
+
+// Contains checks whether item is a substring of the string.
+func (s *String) Contains(item Object) bool {
+	sub, ok := item.(*String)
+	return ok && strings.Contains(s.Value, sub.Value)
+}
@@ -0,0 +1,469 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// A Decimal is an exact base-10 number, equal to Unscaled × 10^-Scale. Scale is
+// never negative, and is kept as written, so 1.50 and 1.5 are equal but print
+// differently.
+type Decimal struct {
+	defaults
+	Unscaled *big.Int
+	Scale    int
+}
+
+// A RoundingMode specifies how a Decimal is rounded when digits are removed.
+type RoundingMode string
+
+// The available rounding modes.
+const (
+	// RoundHalfEven rounds to the nearest neighbour, or the even one if
+	// equidistant. It's also known as banker's rounding.
+	RoundHalfEven RoundingMode = "half-even"
+
+	// RoundHalfUp rounds to the nearest neighbour, or away from zero if
+	// equidistant.
+	RoundHalfUp RoundingMode = "half-up"
+
+	// RoundHalfDown rounds to the nearest neighbour, or towards zero if
+	// equidistant.
+	RoundHalfDown RoundingMode = "half-down"
+
+	// RoundUp rounds away from zero.
+	RoundUp RoundingMode = "up"
+
+	// RoundDown rounds towards zero, i.e. truncates.
+	RoundDown RoundingMode = "down"
+
+	// RoundCeiling rounds towards positive infinity.
+	RoundCeiling RoundingMode = "ceiling"
+
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor RoundingMode = "floor"
+)
+
+// RoundingModes contains every valid RoundingMode.
+var RoundingModes = []RoundingMode{
+	RoundHalfEven, RoundHalfUp, RoundHalfDown, RoundUp, RoundDown, RoundCeiling, RoundFloor,
+}
+
+// A DecimalContext holds the settings for decimal arithmetic which can't be exact.
+// Each virtual machine has its own, which its program can change.
+type DecimalContext struct {
+	// Precision is the number of significant digits kept in the result of dividing
+	// two Decimals, if it can't be represented exactly.
+	Precision int
+
+	// Rounding is the rounding mode used when dividing Decimals, and when rounding
+	// a Decimal to a number of decimal places without specifying a mode.
+	Rounding RoundingMode
+}
+
+// DefaultDecimalContext returns the DecimalContext which programs start with, and
+// which the Infix method of a Decimal uses.
+func DefaultDecimalContext() DecimalContext {
+	return DecimalContext{
+		Precision: 28,
+		Rounding:  RoundHalfEven,
+	}
+}
+
+// maxDecimalScale is the largest number of decimal places, or zeros before the
+// decimal point, which a parsed Decimal can have. Larger exponents would take too
+// long to compute with.
+const maxDecimalScale = 100000
+
+// maxDecimalBits is the number of bits in a number with maxDecimalScale digits.
+const maxDecimalBits = maxDecimalScale * 3322 / 1000
+
+var bigTen = big.NewInt(10)
+
+// ParseDecimal parses a string such as "-12.34" or "1.5e3" into a Decimal. It
+// returns false if the string isn't a valid decimal number, or its exponent is too
+// big.
+func ParseDecimal(str string) (*Decimal, bool) {
+	str = strings.Replace(str, "_", "", -1)
+
+	exp := 0
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		e, err := strconv.Atoi(str[i+1:])
+		if err != nil {
+			return nil, false
+		}
+
+		exp = e
+		str = str[:i]
+	}
+
+	scale := 0
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		scale = len(str) - i - 1
+		str = str[:i] + str[i+1:]
+	}
+
+	if scale-exp > maxDecimalScale || exp-scale > maxDecimalScale {
+		return nil, false
+	}
+
+	unscaled, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, false
+	}
+
+	return makeDecimal(unscaled, scale-exp), true
+}
+
+// makeDecimal makes a Decimal, making the scale zero if it's negative.
+func makeDecimal(unscaled *big.Int, scale int) *Decimal {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+
+	return &Decimal{
+		Unscaled: unscaled,
+		Scale:    scale,
+	}
+}
+
+// ToDecimal converts an Integer, Number or Decimal to a Decimal. A Number is
+// converted via its shortest decimal representation, so 0.1 becomes exactly
+// 0.1. False is returned for other types, and for infinite and NaN Numbers.
+func ToDecimal(o Object) (*Decimal, bool) {
+	switch n := o.(type) {
+	case *Decimal:
+		return n, true
+
+	case *Integer:
+		return &Decimal{Unscaled: n.big()}, true
+
+	case *Number:
+		if math.IsNaN(n.Value) || math.IsInf(n.Value, 0) {
+			return nil, false
+		}
+
+		return ParseDecimal(strconv.FormatFloat(n.Value, 'f', -1, 64))
+	}
+
+	return nil, false
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.Unscaled).String()
+
+	if d.Scale > 0 {
+		if len(digits) <= d.Scale {
+			digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+		}
+
+		digits = digits[:len(digits)-d.Scale] + "." + digits[len(digits)-d.Scale:]
+	}
+
+	if d.Unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+
+	return digits
+}
+
+// Type returns the type of an Object.
+func (d *Decimal) Type() Type {
+	return DecimalType
+}
+
+// Equals checks whether or not two objects are equal to each other. A Decimal is
+// equal to any Integer, Number or Decimal with the same value, regardless of scale.
+func (d *Decimal) Equals(other Object) bool {
+	o, ok := ToDecimal(other)
+	if !ok {
+		return false
+	}
+
+	return d.cmp(o) == 0
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (d *Decimal) Prefix(op string) (Object, bool) {
+	switch op {
+	case "+":
+		return d, true
+
+	case "-":
+		return &Decimal{Unscaled: new(big.Int).Neg(d.Unscaled), Scale: d.Scale}, true
+
+	case ",":
+		return &Tuple{Value: []Object{d}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned. The right operand can be an Integer,
+// a Number, or another Decimal; the result is always exact, except for division.
+func (d *Decimal) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{
+			Value: []Object{d, right},
+		}, true
+	}
+
+	r, ok := ToDecimal(right)
+	if !ok {
+		return nil, false
+	}
+
+	switch op {
+	case "+":
+		lu, ru, scale := align(d, r)
+		return &Decimal{Unscaled: lu.Add(lu, ru), Scale: scale}, true
+
+	case "-":
+		lu, ru, scale := align(d, r)
+		return &Decimal{Unscaled: lu.Sub(lu, ru), Scale: scale}, true
+
+	case "*":
+		return &Decimal{Unscaled: new(big.Int).Mul(d.Unscaled, r.Unscaled), Scale: d.Scale + r.Scale}, true
+
+	case "/":
+		return d.Quo(r, DefaultDecimalContext())
+
+	case "//", "%":
+		if r.Unscaled.Sign() == 0 {
+			return nil, false
+		}
+
+		lu, ru, scale := align(d, r)
+
+		quo, rem := lu.QuoRem(lu, ru, new(big.Int))
+		if rem.Sign() != 0 && rem.Sign() != ru.Sign() {
+			quo.Sub(quo, big.NewInt(1))
+			rem.Add(rem, ru)
+		}
+
+		if op == "//" {
+			return &Decimal{Unscaled: quo}, true
+		}
+
+		return &Decimal{Unscaled: rem, Scale: scale}, true
+
+	case "^":
+		if exp, ok := wholeExponent(right); ok && exp.Sign() >= 0 {
+			if d.tooBigPower(exp) {
+				return nil, false
+			}
+
+			// The scale can only be non-zero if exp is small, since the result's
+			// scale is limited.
+			scale := 0
+			if d.Scale > 0 {
+				scale = d.Scale * int(exp.Int64())
+			}
+
+			return &Decimal{
+				Unscaled: new(big.Int).Exp(d.Unscaled, exp, nil),
+				Scale:    scale,
+			}, true
+		}
+
+		// Zero to a negative power would divide by zero.
+		if d.Unscaled.Sign() == 0 && r.Unscaled.Sign() < 0 {
+			return nil, false
+		}
+
+		var (
+			left, _ = d.Numeric()
+			exp, _  = right.Numeric()
+		)
+
+		return &Number{Value: math.Pow(left, exp)}, true
+
+	case "<":
+		return &Boolean{Value: d.cmp(r) < 0}, true
+	case ">":
+		return &Boolean{Value: d.cmp(r) > 0}, true
+	case "<=":
+		return &Boolean{Value: d.cmp(r) <= 0}, true
+	case ">=":
+		return &Boolean{Value: d.cmp(r) >= 0}, true
+	}
+
+	return nil, false
+}
+
+// Numeric returns the numeric value of an object, or false if it can't be a number.
+func (d *Decimal) Numeric() (float64, bool) {
+	f, err := strconv.ParseFloat(d.String(), 64)
+	return f, err == nil
+}
+
+// Round rounds a Decimal to the given number of decimal places, using mode. If
+// the Decimal already has fewer decimal places, it is padded with zeros.
+func (d *Decimal) Round(places int, mode RoundingMode) *Decimal {
+	if places >= d.Scale {
+		return &Decimal{
+			Unscaled: new(big.Int).Mul(d.Unscaled, pow10(places-d.Scale)),
+			Scale:    places,
+		}
+	}
+
+	var (
+		divisor  = pow10(d.Scale - places)
+		quo, rem = new(big.Int).QuoRem(d.Unscaled, divisor, new(big.Int))
+		sign     = d.Unscaled.Sign()
+		half     = new(big.Int).Abs(rem)
+		away     bool
+	)
+
+	// half is compared to the divisor to find whether the removed digits are
+	// more or less than half way to the next value.
+	half.Mul(half, big.NewInt(2))
+	cmpHalf := half.Cmp(divisor)
+
+	if rem.Sign() != 0 {
+		switch mode {
+		case RoundUp:
+			away = true
+		case RoundCeiling:
+			away = sign > 0
+		case RoundFloor:
+			away = sign < 0
+		case RoundHalfUp:
+			away = cmpHalf >= 0
+		case RoundHalfDown:
+			away = cmpHalf > 0
+		case RoundHalfEven:
+			away = cmpHalf > 0 || cmpHalf == 0 && quo.Bit(0) == 1
+		}
+	}
+
+	if away {
+		quo.Add(quo, big.NewInt(int64(sign)))
+	}
+
+	return makeDecimal(quo, places)
+}
+
+// Quo divides d by r. If the result can't be represented exactly, it's rounded to
+// the precision of ctx using its rounding mode. It returns false if r is zero.
+func (d *Decimal) Quo(r *Decimal, ctx DecimalContext) (Object, bool) {
+	if r.Unscaled.Sign() == 0 {
+		return nil, false
+	}
+
+	// Compute the quotient with one more digit than necessary, so it can be
+	// rounded properly.
+	var (
+		lDigits = len(new(big.Int).Abs(d.Unscaled).String())
+		rDigits = len(new(big.Int).Abs(r.Unscaled).String())
+		shift   = ctx.Precision + rDigits - lDigits + 1
+	)
+
+	if shift < 0 {
+		shift = 0
+	}
+
+	num := new(big.Int).Mul(d.Unscaled, pow10(shift))
+	quo, rem := new(big.Int).QuoRem(num, r.Unscaled, new(big.Int))
+
+	result := makeDecimal(quo, d.Scale-r.Scale+shift)
+
+	// The ideal scale of an exact quotient is the difference of the scales,
+	// so trailing zeros beyond it are removed.
+	ideal := d.Scale - r.Scale
+	if ideal < 0 {
+		ideal = 0
+	}
+
+	if rem.Sign() != 0 {
+		// Append a non-zero digit so the lost remainder still affects rounding,
+		// e.g. when the truncated quotient ends in exactly 5.
+		result.Unscaled.Mul(result.Unscaled, bigTen)
+		result.Unscaled.Add(result.Unscaled, big.NewInt(int64(num.Sign()*r.Unscaled.Sign())))
+		result.Scale++
+	}
+
+	if digits := len(new(big.Int).Abs(result.Unscaled).String()); digits > ctx.Precision {
+		result = result.Round(result.Scale-(digits-ctx.Precision), ctx.Rounding)
+	}
+
+	return result.trim(ideal), true
+}
+
+// tooBigPower checks whether d ^ exp, where exp isn't negative, would have more
+// than maxDecimalScale decimal places, or about that many digits.
+func (d *Decimal) tooBigPower(exp *big.Int) bool {
+	limit := big.NewInt(maxDecimalScale)
+
+	if new(big.Int).Mul(exp, big.NewInt(int64(d.Scale))).Cmp(limit) > 0 {
+		return true
+	}
+
+	return powerBits(d.Unscaled, exp).Cmp(big.NewInt(maxDecimalBits)) > 0
+}
+
+// wholeExponent returns the value of an Integer, or of a Decimal with no
+// fractional part, so that it can be used as an exact exponent.
+func wholeExponent(o Object) (*big.Int, bool) {
+	switch n := o.(type) {
+	case *Integer:
+		return n.big(), true
+
+	case *Decimal:
+		if whole := n.trim(0); whole.Scale == 0 {
+			return whole.Unscaled, true
+		}
+	}
+
+	return nil, false
+}
+
+// trim removes trailing zeros after the decimal point, keeping at least min
+// decimal places.
+func (d *Decimal) trim(min int) *Decimal {
+	var (
+		unscaled = new(big.Int).Set(d.Unscaled)
+		scale    = d.Scale
+		rem      = new(big.Int)
+		quo      = new(big.Int)
+	)
+
+	for scale > min {
+		quo.QuoRem(unscaled, bigTen, rem)
+		if rem.Sign() != 0 {
+			break
+		}
+
+		unscaled.Set(quo)
+		scale--
+	}
+
+	return &Decimal{Unscaled: unscaled, Scale: scale}
+}
+
+func (d *Decimal) cmp(r *Decimal) int {
+	lu, ru, _ := align(d, r)
+	return lu.Cmp(ru)
+}
+
+// align returns the unscaled values of two Decimals, scaled to the same (larger)
+// scale, along with that scale. The returned big.Ints can be safely modified.
+func align(l, r *Decimal) (*big.Int, *big.Int, int) {
+	scale := l.Scale
+	if r.Scale > scale {
+		scale = r.Scale
+	}
+
+	return new(big.Int).Mul(l.Unscaled, pow10(scale-l.Scale)),
+		new(big.Int).Mul(r.Unscaled, pow10(scale-r.Scale)),
+		scale
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,192 @@
+package object
+
+// A Freezable object is mutable, but can be frozen to make it immutable. Once
+// frozen, SetSubscript always fails, and an object can't be unfrozen.
+type Freezable interface {
+	Object
+
+	// Freeze freezes the object, along with every object inside it.
+	Freeze()
+
+	// IsFrozen checks whether or not the object has been frozen.
+	IsFrozen() bool
+}
+
+// Freeze freezes o, if it can be frozen, and returns it.
+func Freeze(o Object) Object {
+	if f, ok := o.(Freezable); ok {
+		f.Freeze()
+	}
+
+	return o
+}
+
+// FrozenCopy returns a frozen copy of o, made by copying it and every object inside
+// it, so o itself can still be mutated. Objects which are already frozen, or can't be
+// frozen, can't change, so are returned as they are. A model is shared by all of its
+// instances, so it's frozen in place instead, and models inside o aren't copied.
+func FrozenCopy(o Object) Object {
+	if m, ok := o.(*Model); ok {
+		m.Freeze()
+		return m
+	}
+
+	return copyObject(o, true, make(map[Object]Object))
+}
+
+// Copy returns a copy of o, made by copying it and every mutable object inside it,
+// so that mutating the copy doesn't change o, or the other way round. Objects which
+// can't be mutated, and models, are shared instead of copied.
+func Copy(o Object) Object {
+	return copyObject(o, false, make(map[Object]Object))
+}
+
+// copyObject copies o, freezing the copy if frozen is true. copies holds the copies
+// which have already been made, so an object which contains itself is only copied
+// once.
+func copyObject(o Object, frozen bool, copies map[Object]Object) Object {
+	if _, ok := o.(*Model); ok || IsFrozen(o) {
+		return o
+	}
+
+	if c, ok := copies[o]; ok {
+		return c
+	}
+
+	switch v := o.(type) {
+	case *List:
+		c := &List{Frozen: frozen}
+		copies[o] = c
+		c.Value = copyItems(v.Value, frozen, copies)
+
+		return c
+
+	case *Tuple:
+		c := &Tuple{Frozen: frozen}
+		copies[o] = c
+		c.Value = copyItems(v.Value, frozen, copies)
+
+		return c
+
+	case *Map:
+		c := NewMap(len(v.entries))
+		c.Frozen = frozen
+		copies[o] = c
+
+		// Keys are already stored frozen, or are immutable
+		for _, e := range v.entries {
+			c.Set(e.key, copyObject(e.value, frozen, copies))
+		}
+
+		return c
+
+	case *String:
+		return &String{Value: v.Value, Frozen: frozen}
+	}
+
+	return o
+}
+
+func copyItems(items []Object, frozen bool, copies map[Object]Object) []Object {
+	copied := make([]Object, len(items))
+
+	for i, item := range items {
+		copied[i] = copyObject(item, frozen, copies)
+	}
+
+	return copied
+}
+
+// IsFrozen checks whether o can't be mutated. Objects which can't be frozen are
+// never mutable, so are always frozen.
+func IsFrozen(o Object) bool {
+	if f, ok := o.(Freezable); ok {
+		return f.IsFrozen()
+	}
+
+	return true
+}
+
+// freezeItem freezes an object inside another which is being frozen. Models are
+// shared by all of their instances, so aren't frozen along with them.
+func freezeItem(o Object) {
+	if _, ok := o.(*Model); !ok {
+		Freeze(o)
+	}
+}
+
+// Freeze freezes the list and each of its items.
+func (l *List) Freeze() {
+	if l.Frozen {
+		return
+	}
+
+	l.Frozen = true
+
+	for _, item := range l.Value {
+		freezeItem(item)
+	}
+}
+
+// IsFrozen checks whether or not the list has been frozen.
+func (l *List) IsFrozen() bool {
+	return l.Frozen
+}
+
+// Freeze freezes the tuple and each of its items.
+func (t *Tuple) Freeze() {
+	if t.Frozen {
+		return
+	}
+
+	t.Frozen = true
+
+	for _, item := range t.Value {
+		freezeItem(item)
+	}
+}
+
+// IsFrozen checks whether or not the tuple has been frozen.
+func (t *Tuple) IsFrozen() bool {
+	return t.Frozen
+}
+
+// Freeze freezes the map and each of its keys and values.
+func (m *Map) Freeze() {
+	if m.Frozen {
+		return
+	}
+
+	m.Frozen = true
+
+	for _, e := range m.entries {
+		freezeItem(e.key)
+		freezeItem(e.value)
+	}
+}
+
+// IsFrozen checks whether or not the map has been frozen.
+func (m *Map) IsFrozen() bool {
+	return m.Frozen
+}
+
+// Freeze freezes the string.
+func (s *String) Freeze() {
+	s.Frozen = true
+}
+
+// IsFrozen checks whether or not the string has been frozen.
+func (s *String) IsFrozen() bool {
+	return s.Frozen
+}
+
+// Freeze freezes the model's methods, so no more can be defined. Its ancestors
+// aren't frozen.
+func (m *Model) Freeze() {
+	m.Methods.Freeze()
+}
+
+// IsFrozen checks whether or not the model has been frozen.
+func (m *Model) IsFrozen() bool {
+	return m.Methods.Frozen
+}
@@ -0,0 +1,378 @@
+package object
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// resolvePath converts a path given to the fs builtin called name into a path in
+// the real file system, confining it to fileRoot if it's set. If it is, symbolic
+// links are evaluated, so the returned path is the one which was checked. If the
+// path leaves fileRoot, the error type and message are returned.
+func resolvePath(fileRoot, name, p string) (string, string, string) {
+	if fileRoot == "" {
+		return p, "", ""
+	}
+
+	root, err := realPath(fileRoot)
+	if err != nil {
+		return "", "IO", fmt.Sprintf("the file root %s is inaccessible", fileRoot)
+	}
+
+	full := filepath.Join(root, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(p))))
+
+	real, err := realPath(full)
+	if err != nil || !within(root, real) {
+		return "", "IO", fmt.Sprintf("fs.%s(...) cannot access %s, since it's outside of the file root", name, p)
+	}
+
+	return real, "", ""
+}
+
+// realPath makes p absolute and evaluates any symbolic links in it. Parts of the
+// path which don't exist yet are kept as they are, but a symbolic link whose
+// target doesn't exist is an error, since creating the file would follow it.
+func realPath(p string) (string, error) {
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+
+	for {
+		real, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{real}, missing...)...), nil
+		}
+
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		if _, lerr := os.Lstat(p); lerr == nil {
+			return "", err
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+
+		missing = append([]string{filepath.Base(p)}, missing...)
+		p = parent
+	}
+}
+
+// within checks whether p is root, or inside it.
+func within(root, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ioError converts err, which was returned by an operation on the file at p, into
+// the error type and message returned by a builtin. The path in err is replaced
+// with p, so that the file root isn't revealed.
+func ioError(action, p string, err error) (Object, string, string) {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	return nil, "IO", fmt.Sprintf("cannot %s %s: %s", action, p, err)
+}
+
+// fsArgs checks the arguments to the fs builtin called name, which should be a
+// path followed by n-1 other arguments, and resolves the path in ctx. If they're
+// invalid, the error type and message are returned.
+func fsArgs(ctx *Context, name string, n int, args []Object) (string, string, string, string) {
+	if len(args) != n {
+		return "", "", "Argument", fmt.Sprintf("expected exactly %d argument(s) to fs.%s(...)", n, name)
+	}
+
+	p, ok := args[0].(*String)
+	if !ok {
+		return "", "", "Type", fmt.Sprintf("the path passed to fs.%s(...) should be a string, not %s", name, args[0].Type())
+	}
+
+	full, errType, errMsg := resolvePath(ctx.FileRoot, name, p.Value)
+
+	return p.Value, full, errType, errMsg
+}
+
+// toBytes converts a list of integers, each between 0 and 255, to a byte slice.
+func toBytes(o Object) ([]byte, bool) {
+	items, ok := o.Items()
+	if !ok {
+		return nil, false
+	}
+
+	data := make([]byte, len(items))
+
+	for i, item := range items {
+		n, ok := item.(*Integer)
+		if !ok || n.Big != nil || n.Value < 0 || n.Value > 255 {
+			return nil, false
+		}
+
+		data[i] = byte(n.Value)
+	}
+
+	return data, true
+}
+
+// writeFile writes data to the file at p, creating it if it doesn't exist. If
+// appending is true, data is added to the end of the file instead of replacing it.
+func writeFile(p string, data []byte, appending bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(p, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// writingBuiltin makes an fs builtin which writes a string, or a list of bytes if
+// binary is true, to a file.
+func writingBuiltin(name string, binary, appending bool) *Builtin {
+	return &Builtin{
+		Arity: 2,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			p, full, errType, errMsg := fsArgs(ctx, name, 2, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			var data []byte
+
+			if binary {
+				bytes, ok := toBytes(args[1])
+				if !ok {
+					return nil, "Type", fmt.Sprintf("fs.%s(...) can only write a list of integers between 0 and 255", name)
+				}
+
+				data = bytes
+			} else {
+				str, ok := args[1].(*String)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("fs.%s(...) can only write strings, not values of type %s", name, args[1].Type())
+				}
+
+				data = []byte(str.Value)
+			}
+
+			if err := writeFile(full, data, appending); err != nil {
+				return ioError("write to", p, err)
+			}
+
+			return &Nil{}, "", ""
+		},
+	}
+}
+
+func init() {
+	defineModule("fs", map[string]Object{
+		"read": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "read", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				data, err := ioutil.ReadFile(full)
+				if err != nil {
+					return ioError("read", p, err)
+				}
+
+				return &String{Value: string(data)}, "", ""
+			},
+		},
+
+		"read-bytes": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "read-bytes", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				data, err := ioutil.ReadFile(full)
+				if err != nil {
+					return ioError("read", p, err)
+				}
+
+				items := make([]Object, len(data))
+
+				for i, b := range data {
+					items[i] = &Integer{Value: int64(b)}
+				}
+
+				return &List{Value: items}, "", ""
+			},
+		},
+
+		"write":       writingBuiltin("write", false, false),
+		"write-bytes": writingBuiltin("write-bytes", true, false),
+		"append":      writingBuiltin("append", false, true),
+
+		"list": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "list", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				infos, err := ioutil.ReadDir(full)
+				if err != nil {
+					return ioError("list", p, err)
+				}
+
+				names := make([]Object, len(infos))
+
+				for i, info := range infos {
+					names[i] = &String{Value: info.Name()}
+				}
+
+				return &List{Value: names}, "", ""
+			},
+		},
+
+		"exists?": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				_, full, errType, errMsg := fsArgs(ctx, "exists?", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				_, err := os.Stat(full)
+
+				return &Boolean{Value: err == nil}, "", ""
+			},
+		},
+
+		// fs.stat returns a map describing a file: its name, its size in bytes,
+		// whether it's a directory, its permissions, e.g. "-rw-r--r--", and when it
+		// was last modified, in seconds since the Unix epoch.
+		"stat": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "stat", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				info, err := os.Stat(full)
+				if err != nil {
+					return ioError("stat", p, err)
+				}
+
+				stat := NewMap(5)
+				stat.Set(&String{Value: "name"}, &String{Value: info.Name()})
+				stat.Set(&String{Value: "size"}, &Integer{Value: info.Size()})
+				stat.Set(&String{Value: "dir?"}, &Boolean{Value: info.IsDir()})
+				stat.Set(&String{Value: "mode"}, &String{Value: info.Mode().String()})
+				stat.Set(&String{Value: "modified"}, &Integer{Value: info.ModTime().Unix()})
+
+				return stat, "", ""
+			},
+		},
+
+		"glob": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				pattern, full, errType, errMsg := fsArgs(ctx, "glob", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				matches, err := filepath.Glob(full)
+				if err != nil {
+					return nil, "Argument", fmt.Sprintf("invalid glob pattern %s", pattern)
+				}
+
+				sort.Strings(matches)
+
+				paths := make([]Object, 0, len(matches))
+
+				for _, match := range matches {
+					if ctx.FileRoot != "" {
+						// The pattern is inside the root, but it can still match files
+						// outside of it through symbolic links, which are left out.
+						root, _ := realPath(ctx.FileRoot)
+
+						if real, err := realPath(match); err != nil || !within(root, real) {
+							continue
+						}
+
+						if match, err = filepath.Rel(root, match); err != nil {
+							continue
+						}
+					}
+
+					paths = append(paths, &String{Value: match})
+				}
+
+				return &List{Value: paths}, "", ""
+			},
+		},
+
+		"mkdir": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "mkdir", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				if err := os.MkdirAll(full, 0755); err != nil {
+					return ioError("make the directory", p, err)
+				}
+
+				return &Nil{}, "", ""
+			},
+		},
+
+		// fs.remove removes a file or an empty directory.
+		"remove": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				p, full, errType, errMsg := fsArgs(ctx, "remove", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				// Every path which resolves to the root itself, such as "." or a
+				// link to it, is refused, so that a script can't remove its own
+				// file root.
+				if ctx.FileRoot != "" {
+					if root, err := realPath(ctx.FileRoot); err != nil || full == root {
+						return nil, "IO", "fs.remove(...) cannot remove the file root"
+					}
+				}
+
+				if err := os.Remove(full); err != nil {
+					return ioError("remove", p, err)
+				}
+
+				return &Nil{}, "", ""
+			},
+		},
+	})
+}
@@ -17,6 +17,18 @@ type Function struct {
 	Names      []string
 	Jumps      []int
 	Self       *Map
+
+	// Defaults are the default values of the last len(Defaults) parameters.
+	Defaults []Object
+
+	// Rest is the name of the parameter which the extra arguments are put in
+	// as a tuple, or "" if the function doesn't take any extra arguments.
+	Rest string
+
+	// Annotations maps the names of annotated parameters to their annotations,
+	// which are types, models or protocols. The annotation of the return value
+	// is under the name return.
+	Annotations map[string]Object
 }
 
 func (f *Function) String() string {
@@ -29,6 +41,10 @@ func (f *Function) String() string {
 
 // Type returns the type of an Object.
 func (f *Function) Type() Type {
+	if f.IsMethod() {
+		return MethodType
+	}
+
 	return FunctionType
 }
 
@@ -59,6 +75,12 @@ func (f *Function) Infix(op string, right Object) (Object, bool) {
 	return nil, false
 }
 
+// Arity returns the amount of parameters without default values, i.e. the
+// least amount of arguments the function can be called with.
+func (f *Function) Arity() int {
+	return len(f.Parameters) - len(f.Defaults)
+}
+
 // IsMethod checks whether on not a function is a method - i.e., a
 // function is a method if Self != nil.
 func (f *Function) IsMethod() bool {
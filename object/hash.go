@@ -0,0 +1,218 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// A Hashable object can be used as a key in a Map. Objects which are equal to each
+// other have the same hash, even if they're of different types, so 1, 1.0 and 1.00d
+// are all the same key. Hash returns false if the object can't be hashed, such as a
+// list which isn't frozen, since changing it would change its hash.
+type Hashable interface {
+	Object
+	Hash() (uint64, bool)
+}
+
+// Hash hashes o, returning false if it isn't hashable.
+func Hash(o Object) (uint64, bool) {
+	if h, ok := o.(Hashable); ok {
+		return h.Hash()
+	}
+
+	return 0, false
+}
+
+// IsHashable checks whether o can be used as a key in a Map.
+func IsHashable(o Object) bool {
+	_, ok := Hash(o)
+	return ok
+}
+
+// The parameters of the 64-bit FNV-1a hash, which every hash is based on.
+const (
+	offset64 uint64 = 14695981039346656037
+	prime64  uint64 = 1099511628211
+)
+
+// hashString adds the bytes of s to the hash h.
+func hashString(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+
+	return h
+}
+
+// hashBytes adds the bytes b to the hash h.
+func hashBytes(h uint64, b []byte) uint64 {
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= prime64
+	}
+
+	return h
+}
+
+// hashUint adds the eight bytes of v to the hash h.
+func hashUint(h, v uint64) uint64 {
+	for i := uint(0); i < 64; i += 8 {
+		h ^= (v >> i) & 0xff
+		h *= prime64
+	}
+
+	return h
+}
+
+// hashType starts a hash for an object of type t, so objects of different types
+// with the same contents, such as a tuple and a list, usually hash differently.
+func hashType(t Type) uint64 {
+	return hashString(offset64, string(t))
+}
+
+// hashInt hashes an integral value which fits in an int64.
+func hashInt(v int64) uint64 {
+	return hashUint(hashType(IntegerType), uint64(v))
+}
+
+// hashBig hashes an integral value, which fits in an int64 or not.
+func hashBig(b *big.Int) uint64 {
+	if b.IsInt64() {
+		return hashInt(b.Int64())
+	}
+
+	h := hashType(IntegerType)
+	h = hashUint(h, uint64(b.Sign()))
+
+	return hashBytes(h, b.Bytes())
+}
+
+// hashFloat hashes a float64 by its bits. It's only used for values which aren't
+// integral, since those are hashed as the equivalent Integer.
+func hashFloat(f float64) uint64 {
+	return hashUint(hashType(NumberType), math.Float64bits(f))
+}
+
+// hashItems hashes a sequence of items, in order, returning false if any of them
+// can't be hashed.
+func hashItems(t Type, items []Object) (uint64, bool) {
+	h := hashType(t)
+
+	for _, item := range items {
+		ih, ok := Hash(item)
+		if !ok {
+			return 0, false
+		}
+
+		h = hashUint(h, ih)
+	}
+
+	return h, true
+}
+
+// Hash hashes the number. Integral numbers are hashed as the equivalent Integer, and
+// others by their bits.
+func (n *Number) Hash() (uint64, bool) {
+	if math.IsNaN(n.Value) || math.IsInf(n.Value, 0) {
+		return hashFloat(n.Value), true
+	}
+
+	if n.Value == math.Trunc(n.Value) {
+		if n.Value > math.MinInt64 && n.Value < math.MaxInt64 {
+			return hashInt(int64(n.Value)), true
+		}
+
+		i, _ := big.NewFloat(n.Value).Int(nil)
+		return hashBig(i), true
+	}
+
+	return hashFloat(n.Value), true
+}
+
+// Hash hashes the integer.
+func (i *Integer) Hash() (uint64, bool) {
+	if i.Big == nil {
+		return hashInt(i.Value), true
+	}
+
+	return hashBig(i.Big), true
+}
+
+// Hash hashes the decimal, without any trailing zeros, so 0.5d and 0.50d hash the
+// same. Integral decimals are hashed as the equivalent Integer, and decimals equal to
+// a Number, i.e. those which a float64 is formatted as, are hashed as that Number.
+func (d *Decimal) Hash() (uint64, bool) {
+	trimmed := d.trim(0)
+	if trimmed.Scale == 0 {
+		return hashBig(trimmed.Unscaled), true
+	}
+
+	if f, err := strconv.ParseFloat(trimmed.String(), 64); err == nil {
+		if n, ok := ToDecimal(&Number{Value: f}); ok && n.cmp(trimmed) == 0 {
+			return hashFloat(f), true
+		}
+	}
+
+	h := hashType(DecimalType)
+	h = hashUint(h, uint64(trimmed.Scale))
+	h = hashUint(h, uint64(trimmed.Unscaled.Sign()))
+
+	return hashBytes(h, trimmed.Unscaled.Bytes()), true
+}
+
+// Hash hashes the string.
+func (s *String) Hash() (uint64, bool) {
+	return hashString(hashType(StringType), s.Value), true
+}
+
+// Hash hashes the boolean.
+func (b *Boolean) Hash() (uint64, bool) {
+	if b.Value {
+		return hashUint(hashType(BooleanType), 1), true
+	}
+
+	return hashUint(hashType(BooleanType), 0), true
+}
+
+// Hash hashes nil.
+func (n *Nil) Hash() (uint64, bool) {
+	return hashType(NilType), true
+}
+
+// Hash hashes the tuple, if each of its items can be hashed.
+func (t *Tuple) Hash() (uint64, bool) {
+	return hashItems(TupleType, t.Value)
+}
+
+// Hash hashes the list, if it's frozen and each of its items can be hashed.
+func (l *List) Hash() (uint64, bool) {
+	if !l.Frozen {
+		return 0, false
+	}
+
+	return hashItems(ListType, l.Value)
+}
+
+// Hash hashes the map, if it's frozen and each of its keys and values can be
+// hashed. The order of its entries doesn't affect the hash, since it doesn't
+// affect equality.
+func (m *Map) Hash() (uint64, bool) {
+	if !m.Frozen {
+		return 0, false
+	}
+
+	sum := hashType(MapType)
+
+	for _, e := range m.entries {
+		vh, ok := Hash(e.value)
+		if !ok {
+			return 0, false
+		}
+
+		sum += hashUint(e.hash, vh)
+	}
+
+	return sum, true
+}
@@ -0,0 +1,366 @@
+package object
+
+import (
+	"math"
+	"math/big"
+)
+
+// An Integer is an arbitrary-precision integer. Integers which fit in 64 bits
+// are stored in Value, and Big is nil. Otherwise, Big holds the value and
+// Value is unused.
+type Integer struct {
+	defaults
+	Value int64
+	Big   *big.Int
+}
+
+// MaxIntegerBits is the most bits which the result of raising an Integer to a power
+// can have. Larger powers would take too long to compute.
+const MaxIntegerBits = 1 << 24
+
+// makeInteger makes an Integer from a big.Int, only keeping the big.Int if
+// the value doesn't fit in an int64.
+func makeInteger(b *big.Int) *Integer {
+	if b.IsInt64() {
+		return &Integer{Value: b.Int64()}
+	}
+
+	return &Integer{Big: b}
+}
+
+func (i *Integer) String() string {
+	return i.big().String()
+}
+
+// Type returns the type of an Object.
+func (i *Integer) Type() Type {
+	return IntegerType
+}
+
+// Equals checks whether or not two objects are equal to each other. An Integer
+// is equal to a Number or Decimal with the same value.
+func (i *Integer) Equals(other Object) bool {
+	switch o := other.(type) {
+	case *Integer:
+		if i.Big == nil && o.Big == nil {
+			return i.Value == o.Value
+		}
+
+		return i.big().Cmp(o.big()) == 0
+
+	case *Number, *Decimal:
+		return o.Equals(i)
+
+	default:
+		return false
+	}
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (i *Integer) Prefix(op string) (Object, bool) {
+	switch op {
+	case "+":
+		return i, true
+
+	case "-":
+		if i.Big == nil && i.Value != math.MinInt64 {
+			return &Integer{Value: -i.Value}, true
+		}
+
+		return makeInteger(new(big.Int).Neg(i.big())), true
+
+	case ",":
+		return &Tuple{Value: []Object{i}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned. Operations between two Integers are
+// exact, except for /, which always gives a Number. If the right operand is a Decimal,
+// the operation is performed on Decimals, and otherwise on Numbers.
+func (i *Integer) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{
+			Value: []Object{i, right},
+		}, true
+	}
+
+	if _, ok := right.(*Decimal); ok {
+		left, _ := ToDecimal(i)
+		return left.Infix(op, right)
+	}
+
+	r, ok := right.(*Integer)
+	if !ok || op == "/" {
+		left, _ := i.Numeric()
+		return (&Number{Value: left}).Infix(op, right)
+	}
+
+	if i.Big == nil && r.Big == nil {
+		if result, ok := smallInfix(op, i.Value, r.Value); ok {
+			return result, true
+		}
+	}
+
+	return bigInfix(op, i.big(), r.big())
+}
+
+// Numeric returns the numeric value of an object, or false if it can't be a number.
+func (i *Integer) Numeric() (float64, bool) {
+	if i.Big == nil {
+		return float64(i.Value), true
+	}
+
+	f, _ := new(big.Float).SetInt(i.Big).Float64()
+	return f, true
+}
+
+// Int returns the value of the Integer as an int, or false if it's too big to
+// fit in one.
+func (i *Integer) Int() (int, bool) {
+	if i.Big != nil || int64(int(i.Value)) != i.Value {
+		return 0, false
+	}
+
+	return int(i.Value), true
+}
+
+// big returns the value of the Integer as a big.Int. The result mustn't be
+// modified, since it may be i.Big itself.
+func (i *Integer) big() *big.Int {
+	if i.Big != nil {
+		return i.Big
+	}
+
+	return big.NewInt(i.Value)
+}
+
+// smallInfix performs an infix operation on two int64s, returning false if the
+// result overflows or is otherwise not computable as an int64.
+func smallInfix(op string, l, r int64) (Object, bool) {
+	switch op {
+	case "+":
+		sum := l + r
+		if (l^sum)&(r^sum) < 0 {
+			return nil, false
+		}
+
+		return &Integer{Value: sum}, true
+
+	case "-":
+		diff := l - r
+		if (l^r)&(l^diff) < 0 {
+			return nil, false
+		}
+
+		return &Integer{Value: diff}, true
+
+	case "*":
+		prod, ok := multiply(l, r)
+		if !ok {
+			return nil, false
+		}
+
+		return &Integer{Value: prod}, true
+
+	case "^":
+		if r < 0 {
+			return nil, false
+		}
+
+		result := int64(1)
+
+		for base := l; r > 0; r >>= 1 {
+			var ok bool
+
+			if r&1 == 1 {
+				if result, ok = multiply(result, base); !ok {
+					return nil, false
+				}
+			}
+
+			if r > 1 {
+				if base, ok = multiply(base, base); !ok {
+					return nil, false
+				}
+			}
+		}
+
+		return &Integer{Value: result}, true
+
+	case "//":
+		if r == 0 || (l == math.MinInt64 && r == -1) {
+			return nil, false
+		}
+
+		quo := l / r
+		if l%r != 0 && (l < 0) != (r < 0) {
+			quo--
+		}
+
+		return &Integer{Value: quo}, true
+
+	case "%":
+		if r == 0 {
+			return nil, false
+		}
+
+		rem := l % r
+		if rem != 0 && (rem < 0) != (r < 0) {
+			rem += r
+		}
+
+		return &Integer{Value: rem}, true
+
+	case "|":
+		return &Integer{Value: l | r}, true
+	case "&":
+		return &Integer{Value: l & r}, true
+	case "<":
+		return &Boolean{Value: l < r}, true
+	case ">":
+		return &Boolean{Value: l > r}, true
+	case "<=":
+		return &Boolean{Value: l <= r}, true
+	case ">=":
+		return &Boolean{Value: l >= r}, true
+	}
+
+	return nil, false
+}
+
+// multiply multiplies two int64s, returning false if the result overflows.
+func multiply(l, r int64) (int64, bool) {
+	if l == 0 || r == 0 {
+		return 0, true
+	}
+
+	prod := l * r
+	if prod/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64) {
+		return 0, false
+	}
+
+	return prod, true
+}
+
+// bigInfix performs an infix operation on two big.Ints. // and % use floored
+// division, so the result of % always has the same sign as the divisor.
+func bigInfix(op string, l, r *big.Int) (Object, bool) {
+	switch op {
+	case "+":
+		return makeInteger(new(big.Int).Add(l, r)), true
+	case "-":
+		return makeInteger(new(big.Int).Sub(l, r)), true
+	case "*":
+		return makeInteger(new(big.Int).Mul(l, r)), true
+
+	case "//", "%":
+		if r.Sign() == 0 {
+			return nil, false
+		}
+
+		quo, rem := new(big.Int).QuoRem(l, r, new(big.Int))
+		if rem.Sign() != 0 && rem.Sign() != r.Sign() {
+			quo.Sub(quo, big.NewInt(1))
+			rem.Add(rem, r)
+		}
+
+		if op == "//" {
+			return makeInteger(quo), true
+		}
+
+		return makeInteger(rem), true
+
+	case "^":
+		if r.Sign() < 0 {
+			left, _ := new(big.Float).SetInt(l).Float64()
+			right, _ := new(big.Float).SetInt(r).Float64()
+
+			return &Number{Value: math.Pow(left, right)}, true
+		}
+
+		if tooBigPower(l, r) {
+			return nil, false
+		}
+
+		return makeInteger(new(big.Int).Exp(l, r, nil)), true
+
+	case "|":
+		return makeInteger(new(big.Int).Or(l, r)), true
+	case "&":
+		return makeInteger(new(big.Int).And(l, r)), true
+	case "<":
+		return &Boolean{Value: l.Cmp(r) < 0}, true
+	case ">":
+		return &Boolean{Value: l.Cmp(r) > 0}, true
+	case "<=":
+		return &Boolean{Value: l.Cmp(r) <= 0}, true
+	case ">=":
+		return &Boolean{Value: l.Cmp(r) >= 0}, true
+	}
+
+	return nil, false
+}
+
+// powerBits returns a lower bound of the number of bits in l ^ r, where r isn't
+// negative.
+func powerBits(l, r *big.Int) *big.Int {
+	// The magnitude of 0, 1 and -1 doesn't grow, however big r is.
+	if l.BitLen() <= 1 {
+		return new(big.Int)
+	}
+
+	return new(big.Int).Mul(r, big.NewInt(int64(l.BitLen()-1)))
+}
+
+// tooBigPower checks whether l ^ r, where r isn't negative, would have more than
+// MaxIntegerBits bits.
+func tooBigPower(l, r *big.Int) bool {
+	return powerBits(l, r).Cmp(big.NewInt(MaxIntegerBits)) > 0
+}
+
+// TooBigPower checks whether base ^ exp is a power of an Integer or a Decimal which
+// is too big to compute. If exp is a Decimal, base is raised to it as a Decimal.
+func TooBigPower(base, exp Object) bool {
+	r, ok := wholeExponent(exp)
+	if !ok || r.Sign() < 0 {
+		return false
+	}
+
+	if _, ok := exp.(*Decimal); ok {
+		if d, ok := ToDecimal(base); ok {
+			base = d
+		}
+	}
+
+	switch l := base.(type) {
+	case *Integer:
+		return tooBigPower(l.big(), r)
+
+	case *Decimal:
+		return l.tooBigPower(r)
+	}
+
+	return false
+}
+
+// ToInt converts an Integer, or a whole Number, to an int. It returns false if o is
+// neither, or if its value doesn't fit in an int.
+func ToInt(o Object) (int, bool) {
+	switch n := o.(type) {
+	case *Integer:
+		return n.Int()
+
+	case *Number:
+		if n.Value != math.Trunc(n.Value) || n.Value <= math.MinInt64 || n.Value >= math.MaxInt64 {
+			return 0, false
+		}
+
+		return int(n.Value), true
+	}
+
+	return 0, false
+}
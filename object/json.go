@@ -0,0 +1,383 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// ParseJSON parses a JSON document into an object. Objects become Maps, keeping
+// the order of their keys, arrays become Lists, and numbers become Integers if
+// they're written without a fraction or exponent, or Numbers otherwise.
+func ParseJSON(text string) (Object, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	val, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+
+	return val, nil
+}
+
+// parseJSONValue parses the next value from dec.
+func parseJSONValue(dec *json.Decoder) (Object, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, fmt.Errorf("unexpected end of JSON input")
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case nil:
+		return &Nil{}, nil
+
+	case bool:
+		return &Boolean{Value: t}, nil
+
+	case string:
+		return &String{Value: t}, nil
+
+	case json.Number:
+		return parseJSONNumber(t)
+
+	case json.Delim:
+		if t == '[' {
+			list := &List{Value: []Object{}}
+
+			for dec.More() {
+				item, err := parseJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				list.Value = append(list.Value, item)
+			}
+
+			_, err := dec.Token()
+			return list, err
+		}
+
+		m := NewMap(0)
+
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := parseJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			m.Set(&String{Value: key.(string)}, val)
+		}
+
+		_, err := dec.Token()
+		return m, err
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+// parseJSONNumber converts a JSON number to an Integer or a Number.
+func parseJSONNumber(n json.Number) (Object, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, ok := new(big.Int).SetString(string(n), 10); ok {
+			return makeInteger(i), nil
+		}
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("%s is out of range", n)
+	}
+
+	return &Number{Value: f}, nil
+}
+
+// ToJSON converts an object to JSON. Maps, whose keys must all be strings, become
+// objects, Lists and Tuples become arrays, and numeric values become numbers. If
+// indent isn't empty, each element is put on its own line and indented by it. If
+// sortKeys is true, the keys of each object are sorted, otherwise they're in the
+// map's order.
+func ToJSON(o Object, indent string, sortKeys bool) (string, error) {
+	e := &jsonEncoder{
+		sortKeys: sortKeys,
+		seen:     make(map[Object]bool),
+	}
+
+	if err := e.encode(o); err != nil {
+		return "", err
+	}
+
+	if indent == "" {
+		return e.buf.String(), nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, e.buf.Bytes(), "", indent); err != nil {
+		return "", err
+	}
+
+	return indented.String(), nil
+}
+
+// A jsonEncoder writes objects to buf as compact JSON.
+type jsonEncoder struct {
+	buf      bytes.Buffer
+	sortKeys bool
+
+	// seen contains the collections which are currently being encoded, so that
+	// ones which contain themselves can be rejected.
+	seen map[Object]bool
+}
+
+func (e *jsonEncoder) encode(o Object) error {
+	switch v := o.(type) {
+	case *Nil:
+		e.buf.WriteString("null")
+
+	case *Boolean:
+		if v.Value {
+			e.buf.WriteString("true")
+		} else {
+			e.buf.WriteString("false")
+		}
+
+	case *Integer:
+		e.buf.WriteString(v.String())
+
+	case *Decimal:
+		e.buf.WriteString(v.String())
+
+	case *Number:
+		if math.IsNaN(v.Value) || math.IsInf(v.Value, 0) {
+			return fmt.Errorf("cannot represent %s in JSON", v)
+		}
+
+		data, _ := json.Marshal(v.Value)
+		e.buf.Write(data)
+
+	case *String:
+		e.encodeString(v.Value)
+
+	case *List:
+		return e.encodeArray(v, v.Value)
+
+	case *Tuple:
+		return e.encodeArray(v, v.Value)
+
+	case *Map:
+		return e.encodeMap(v)
+
+	default:
+		return fmt.Errorf("a value of type %s can't be represented in JSON", o.Type())
+	}
+
+	return nil
+}
+
+// encodeString writes s as a JSON string, without escaping HTML characters.
+func (e *jsonEncoder) encodeString(s string) {
+	enc := json.NewEncoder(&e.buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+
+	// Encode adds a newline after the string.
+	e.buf.Truncate(e.buf.Len() - 1)
+}
+
+// enter marks the collection o as being encoded, returning an error if it
+// already is, since it must contain itself.
+func (e *jsonEncoder) enter(o Object) error {
+	if e.seen[o] {
+		return fmt.Errorf("cannot represent a %s which contains itself in JSON", o.Type())
+	}
+
+	e.seen[o] = true
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeArray(o Object, items []Object) error {
+	if err := e.enter(o); err != nil {
+		return err
+	}
+
+	defer delete(e.seen, o)
+
+	e.buf.WriteByte('[')
+
+	for i, item := range items {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+
+		if err := e.encode(item); err != nil {
+			return err
+		}
+	}
+
+	e.buf.WriteByte(']')
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeMap(m *Map) error {
+	if err := e.enter(m); err != nil {
+		return err
+	}
+
+	defer delete(e.seen, m)
+
+	keys := m.Keys()
+
+	for _, key := range keys {
+		if _, ok := key.(*String); !ok {
+			return fmt.Errorf("only maps with string keys can be represented in JSON, not ones with %s keys", key.Type())
+		}
+	}
+
+	if e.sortKeys {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return keys[i].(*String).Value < keys[j].(*String).Value
+		})
+	}
+
+	e.buf.WriteByte('{')
+
+	for i, key := range keys {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+
+		val, _ := m.Get(key)
+
+		e.encodeString(key.(*String).Value)
+		e.buf.WriteByte(':')
+
+		if err := e.encode(val); err != nil {
+			return err
+		}
+	}
+
+	e.buf.WriteByte('}')
+
+	return nil
+}
+
+func init() {
+	defineModule("json", map[string]Object{
+		"parse": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to json.parse(...)"
+				}
+
+				str, ok := args[0].(*String)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("the argument to json.parse(...) should be a string, not %s", args[0].Type())
+				}
+
+				val, err := ParseJSON(str.Value)
+				if err != nil {
+					return nil, "Argument", fmt.Sprintf("invalid JSON: %s", err)
+				}
+
+				return val, "", ""
+			},
+		},
+
+		// json.stringify takes the value to stringify, optionally preceded by a
+		// map of options: "indent", which is a string or a number of spaces, and
+		// "sort-keys", which is a boolean.
+		"stringify": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 && len(args) != 2 {
+					return nil, "Argument", "expected one or two arguments to json.stringify(...)"
+				}
+
+				var (
+					indent   string
+					sortKeys bool
+				)
+
+				if len(args) == 2 {
+					var errType, errMsg string
+
+					indent, sortKeys, errType, errMsg = jsonOptions(args[0])
+					if errType != "" {
+						return nil, errType, errMsg
+					}
+				}
+
+				str, err := ToJSON(args[len(args)-1], indent, sortKeys)
+				if err != nil {
+					return nil, "Type", err.Error()
+				}
+
+				return &String{Value: str}, "", ""
+			},
+		},
+	})
+}
+
+// jsonOptions reads the options map passed to json.stringify.
+func jsonOptions(o Object) (indent string, sortKeys bool, errType, errMsg string) {
+	opts, ok := o.(*Map)
+	if !ok {
+		return "", false, "Type", fmt.Sprintf("the options passed to json.stringify(...) should be a map, not %s", o.Type())
+	}
+
+	for _, key := range opts.Keys() {
+		var (
+			val, _  = opts.Get(key)
+			name, _ = key.(*String)
+		)
+
+		if name == nil {
+			return "", false, "Type", fmt.Sprintf("the options passed to json.stringify(...) should have string keys, not %s", key.Type())
+		}
+
+		switch name.Value {
+		case "indent":
+			if str, ok := val.(*String); ok {
+				indent = str.Value
+			} else if n, ok := ToInt(val); ok && n > maxStringLength {
+				return "", false, "Argument", fmt.Sprintf("cannot indent by %d spaces", n)
+			} else if ok && n >= 0 {
+				indent = strings.Repeat(" ", n)
+			} else {
+				return "", false, "Type", "the indent option should be a string or a non-negative integer"
+			}
+
+		case "sort-keys":
+			b, ok := val.(*Boolean)
+			if !ok {
+				return "", false, "Type", "the sort-keys option should be a boolean"
+			}
+
+			sortKeys = b.Value
+
+		default:
+			return "", false, "Argument", fmt.Sprintf("json.stringify(...) has no option %s", key)
+		}
+	}
+
+	return indent, sortKeys, "", ""
+}
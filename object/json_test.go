@@ -0,0 +1,193 @@
+package object_test
+
+import (
+	"math"
+	"testing"
+
+	. "github.com/Zac-Garby/radon/object"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := map[string]Object{
+		`null`:                      &Nil{},
+		`true`:                      b(true),
+		`"a\nbé"`:                   s("a\nbé"),
+		`12`:                        i(12),
+		`-3`:                        i(-3),
+		`123456789012345678901234`:  bi("123456789012345678901234"),
+		`1.5`:                       n(1.5),
+		`2e3`:                       n(2000),
+		`[]`:                        l(),
+		`[1, "a", [null]]`:          l(i(1), s("a"), l(&Nil{})),
+		`{}`:                        m(),
+		`{"b": 1, "a": {"c": [2]}}`: m(s("b"), i(1), s("a"), m(s("c"), l(i(2)))),
+		` { "x" : false } `:         m(s("x"), b(false)),
+	}
+
+	for text, expected := range tests {
+		result, err := ParseJSON(text)
+		if err != nil {
+			t.Errorf("%s: %s", text, err)
+			continue
+		}
+
+		if !result.Equals(expected) || result.Type() != expected.Type() {
+			t.Errorf("%s: expected %s, got %s", text, expected, result)
+		}
+	}
+}
+
+func TestParseJSONOrder(t *testing.T) {
+	result, err := ParseJSON(`{"z": 1, "a": 2, "m": 3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.String() != `{"z": 1, "a": 2, "m": 3}` {
+		t.Errorf("expected the keys to keep their order, got %s", result)
+	}
+}
+
+func TestParseJSONErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`[1, 2`,
+		`{"a" 1}`,
+		`{1: 2}`,
+		`[1] [2]`,
+		`nul`,
+		`1e999`,
+		`'a'`,
+	}
+
+	for _, text := range tests {
+		if result, err := ParseJSON(text); err == nil {
+			t.Errorf("%s: expected an error, got %s", text, result)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	recursive := l(i(1))
+	recursive.Value = append(recursive.Value, recursive)
+
+	tests := []struct {
+		value    Object
+		expected string
+	}{
+		{&Nil{}, `null`},
+		{b(false), `false`},
+		{i(5), `5`},
+		{bi("123456789012345678901234"), `123456789012345678901234`},
+		{n(1.5), `1.5`},
+		{n(1e21), `1e+21`},
+		{d("1.50"), `1.50`},
+		{s("<a \"b\">\n"), `"<a \"b\">\n"`},
+		{l(i(1), s("a")), `[1,"a"]`},
+		{tu(i(1), b(true)), `[1,true]`},
+		{m(s("b"), i(1), s("a"), l()), `{"b":1,"a":[]}`},
+		{l(recursive.Value[0], m(s("x"), recursive.Value[0])), `[1,{"x":1}]`},
+	}
+
+	for _, test := range tests {
+		result, err := ToJSON(test.value, "", false)
+		if err != nil {
+			t.Errorf("%s: %s", test.value, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.value, test.expected, result)
+		}
+	}
+}
+
+func TestToJSONOptions(t *testing.T) {
+	value := m(s("b"), l(i(1), i(2)), s("a"), m(s("d"), &Nil{}, s("c"), i(3)))
+
+	sorted, err := ToJSON(value, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"a":{"c":3,"d":null},"b":[1,2]}`; sorted != expected {
+		t.Errorf("expected %s, got %s", expected, sorted)
+	}
+
+	indented, err := ToJSON(value, "  ", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "b": [
+    1,
+    2
+  ],
+  "a": {
+    "d": null,
+    "c": 3
+  }
+}`
+
+	if indented != expected {
+		t.Errorf("expected %s, got %s", expected, indented)
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	recursive := l()
+	recursive.Value = append(recursive.Value, recursive)
+
+	tests := []Object{
+		f(nil),
+		&Builtin{Name: "x"},
+		set(i(1)),
+		n(math.NaN()),
+		m(i(1), s("a")),
+		l(s("a"), m(s("b"), f(nil))),
+		recursive,
+	}
+
+	for _, test := range tests {
+		if result, err := ToJSON(test, "", false); err == nil {
+			t.Errorf("%s: expected an error, got %s", test, result)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []Object{
+		&Nil{},
+		b(true),
+		i(-42),
+		bi("-98765432109876543210"),
+		n(0.1),
+		n(-2.5e-10),
+		s("tab\t, quote \", unicode ☃ and <html>"),
+		l(),
+		m(),
+		l(i(1), l(i(2), l(i(3))), m(s("k"), s("v"))),
+		m(s("name"), s("radon"), s("tags"), l(s("a"), s("b")), s("meta"), m(s("ok"), b(true), s("n"), &Nil{})),
+	}
+
+	for _, test := range tests {
+		for _, indent := range []string{"", "\t"} {
+			text, err := ToJSON(test, indent, false)
+			if err != nil {
+				t.Errorf("%s: %s", test, err)
+				continue
+			}
+
+			result, err := ParseJSON(text)
+			if err != nil {
+				t.Errorf("%s: %s", text, err)
+				continue
+			}
+
+			if !result.Equals(test) || result.String() != test.String() {
+				t.Errorf("%s: round-tripped to %s via %s", test, result, text)
+			}
+		}
+	}
+}
@@ -8,7 +8,8 @@ import (
 // A List is a dynamic mutable linked list.
 type List struct {
 	defaults
-	Value []Object
+	Value  []Object
+	Frozen bool
 }
 
 func (l *List) String() string {
@@ -73,7 +74,11 @@ func (l *List) Infix(op string, right Object) (Object, bool) {
 			return nil, false
 		}
 
-		return &List{Value: append(l.Value, other.Value...)}, true
+		// The result is copied, so it never shares its items with either operand
+		value := make([]Object, 0, len(l.Value)+len(other.Value))
+		value = append(value, l.Value...)
+
+		return &List{Value: append(value, other.Value...)}, true
 	}
 
 	return nil, false
@@ -85,15 +90,19 @@ func (l *List) Items() ([]Object, bool) {
 }
 
 // SetSubscript sets the value of a subscript of an Object, e.g. foo[bar] = baz.
-// Returns false if it can't be done.
+// Returns false if it can't be done. The index can be negative to count from the
+// end, or a slice to replace the items it selects.
 func (l *List) SetSubscript(index Object, to Object) bool {
-	num, ok := index.(*Number)
-	if !ok {
+	if l.Frozen {
 		return false
 	}
 
-	i := int(num.Value)
-	if i < 0 || i >= len(l.Value) {
+	if slice, ok := index.(*Slice); ok {
+		return l.setSlice(slice, to)
+	}
+
+	i, ok := normaliseIndex(index, len(l.Value))
+	if !ok {
 		return false
 	}
 
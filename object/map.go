@@ -3,37 +3,55 @@ package object
 import (
 	"fmt"
 	"strings"
-
-	"github.com/cnf/structhash"
 )
 
-// A Map maps keys to values, where keys and values can be any type.
-//
-// Keys maps the hashes to the keys they were
-// hashed from, and Values maps the hashes to
-// the keys' corresponding values.
+// A Map maps keys to values, where keys can be any Hashable object and values can
+// be any object. Entries are kept in the order their keys were first added, which
+// is the order they're printed and iterated in.
 type Map struct {
 	defaults
-	Keys   map[string]Object
-	Values map[string]Object
+	Frozen bool
+
+	entries []mapEntry
+
+	// index maps each hash to the index of the latest entry whose key has it.
+	// Earlier entries with the same hash are found through prev.
+	index map[uint64]int
+}
+
+// A mapEntry is a key and its value in a Map, along with the key's hash.
+type mapEntry struct {
+	key, value Object
+	hash       uint64
+
+	// prev is the index of the previous entry whose key has the same hash, or -1
+	prev int
+}
+
+// modelKey is the key of the model which a map is an instance of, if any.
+var modelKey = &String{Value: "__model", Frozen: true}
+
+// NewMap makes a new empty Map, with space for size entries.
+func NewMap(size int) *Map {
+	return &Map{
+		entries: make([]mapEntry, 0, size),
+		index:   make(map[uint64]int, size),
+	}
 }
 
 func (m *Map) String() string {
-	if len(m.Keys) == 0 {
+	if len(m.entries) == 0 {
 		return "{}"
 	}
 
-	stringArr := make([]string, len(m.Values))
-	i := 0
+	stringArr := make([]string, len(m.entries))
 
-	for k, v := range m.Values {
+	for i, e := range m.entries {
 		stringArr[i] = fmt.Sprintf(
 			"%s: %s",
-			m.Keys[k].String(),
-			v.String(),
+			e.key.String(),
+			e.value.String(),
 		)
-
-		i++
 	}
 
 	return fmt.Sprintf("{%s}", strings.Join(stringArr, ", "))
@@ -44,19 +62,17 @@ func (m *Map) Type() Type {
 	return MapType
 }
 
-// Equals checks whether or not two objects are equal to each other.
+// Equals checks whether or not two objects are equal to each other. Two maps are
+// equal if they have equal values for the same keys, in any order.
 func (m *Map) Equals(o Object) bool {
 	if other, ok := o.(*Map); ok {
-		if len(other.Values) != len(m.Values) {
+		if len(other.entries) != len(m.entries) {
 			return false
 		}
 
-		for k, v := range m.Values {
-			if _, ok := other.Values[k]; !ok {
-				return false
-			}
-
-			if !v.Equals(other.Values[k]) {
+		for _, e := range m.entries {
+			val, ok := other.Get(e.key)
+			if !ok || !e.value.Equals(val) {
 				return false
 			}
 		}
@@ -67,6 +83,114 @@ func (m *Map) Equals(o Object) bool {
 	return false
 }
 
+// Len returns the number of entries in the map.
+func (m *Map) Len() int {
+	return len(m.entries)
+}
+
+// Keys returns the map's keys, in the order they were added.
+func (m *Map) Keys() []Object {
+	keys := make([]Object, len(m.entries))
+
+	for i, e := range m.entries {
+		keys[i] = e.key
+	}
+
+	return keys
+}
+
+// find returns the index of the entry whose key is equal to key, which has the hash
+// h, or -1 if there isn't one.
+func (m *Map) find(key Object, h uint64) int {
+	i, ok := m.index[h]
+	if !ok {
+		return -1
+	}
+
+	for ; i >= 0; i = m.entries[i].prev {
+		if m.entries[i].key.Equals(key) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Get gets the value of key in the map. False is returned if the key isn't in the
+// map, or isn't hashable.
+func (m *Map) Get(key Object) (Object, bool) {
+	h, ok := Hash(key)
+	if !ok {
+		return nil, false
+	}
+
+	if i := m.find(key, h); i >= 0 {
+		return m.entries[i].value, true
+	}
+
+	return nil, false
+}
+
+// Set sets the value of key in the map, adding it to the end of the map if it isn't
+// already there. False is returned if the key isn't hashable. Set doesn't check
+// whether the map is frozen, so should only be used to build new maps.
+func (m *Map) Set(key, val Object) bool {
+	h, ok := Hash(key)
+	if !ok {
+		return false
+	}
+
+	if i := m.find(key, h); i >= 0 {
+		m.entries[i].value = val
+		return true
+	}
+
+	if m.index == nil {
+		m.index = make(map[uint64]int)
+	}
+
+	prev, ok := m.index[h]
+	if !ok {
+		prev = -1
+	}
+
+	m.entries = append(m.entries, mapEntry{
+		key:   storedKey(key),
+		value: val,
+		hash:  h,
+		prev:  prev,
+	})
+
+	m.index[h] = len(m.entries) - 1
+
+	return true
+}
+
+// storedKey returns the object which is stored in a map for the key k. Strings and
+// tuples can be modified, which would change their hashes, so a frozen copy of them
+// is stored instead. Any other hashable object is already immutable.
+func storedKey(k Object) Object {
+	switch key := k.(type) {
+	case *String:
+		if !key.Frozen {
+			return &String{Value: key.Value, Frozen: true}
+		}
+
+	case *Tuple:
+		if !key.Frozen {
+			items := make([]Object, len(key.Value))
+
+			for i, item := range key.Value {
+				items[i] = storedKey(item)
+			}
+
+			return &Tuple{Value: items, Frozen: true}
+		}
+	}
+
+	return k
+}
+
 // Prefix applies a prefix operator to an object, returning the result. If the operation
 // cannot be performed, (nil, false) is returned.
 func (m *Map) Prefix(op string) (Object, bool) {
@@ -91,43 +215,75 @@ func (m *Map) Infix(op string, right Object) (Object, bool) {
 
 // Items returns a slice containing all objects in an Object, or false otherwise.
 func (m *Map) Items() ([]Object, bool) {
-	var pairs []Object
+	pairs := make([]Object, len(m.entries))
 
-	for hash, key := range m.Keys {
-		val := m.Values[hash]
-		pairs = append(pairs, &Tuple{
+	for i, e := range m.entries {
+		pairs[i] = &Tuple{
 			Value: []Object{
-				key, val,
+				e.key, e.value,
 			},
-		})
+		}
 	}
 
 	return pairs, true
 }
 
+// Iter creates an iterable from an Object. Iterating over a map gives a (key, value)
+// tuple for each entry.
+func (m *Map) Iter() (Iterable, bool) {
+	pairs, _ := m.Items()
+
+	return &ListIterable{
+		List:  &List{Value: pairs},
+		Index: 0,
+	}, true
+}
+
 // Subscript subscrips an Object, e.g. foo[bar], or returns false if it can't be
 // done.
 func (m *Map) Subscript(key Object) (Object, bool) {
-	if hash, err := structhash.Hash(key, 1); err == nil {
-		if val, ok := m.Values[hash]; ok {
-			return val, true
-		}
+	if val, ok := m.Get(key); ok {
+		return val, true
 	}
 
-	// TODO: Implement model methods
+	if method, ok := m.Method(key); ok {
+		return method, true
+	}
 
 	return nil, false
 }
 
+// Method looks up a method in the map's model, if it's an instance of one. A
+// Function is returned as a method bound to the map, i.e. with Self set to it.
+func (m *Map) Method(key Object) (Object, bool) {
+	val, _ := m.Get(modelKey)
+
+	model, ok := val.(*Model)
+	if !ok {
+		return nil, false
+	}
+
+	method, ok := model.Method(key)
+	if !ok {
+		return nil, false
+	}
+
+	if fn, ok := method.(*Function); ok {
+		bound := *fn
+		bound.Self = m
+
+		return &bound, true
+	}
+
+	return method, true
+}
+
 // SetSubscript sets the value of a subscript of an Object, e.g. foo[bar] = baz.
 // Returns false if it can't be done.
 func (m *Map) SetSubscript(key Object, val Object) bool {
-	if hash, err := structhash.Hash(key, 1); err == nil {
-		m.Values[hash] = val
-		m.Keys[hash] = key
-
-		return true
+	if m.Frozen {
+		return false
 	}
 
-	return false
+	return m.Set(key, val)
 }
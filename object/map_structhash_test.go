@@ -0,0 +1,63 @@
+//go:build structhash
+// +build structhash
+
+package object_test
+
+import (
+	"testing"
+
+	"github.com/cnf/structhash"
+
+	. "github.com/Zac-Garby/radon/object"
+)
+
+// The structhash benchmarks measure maps as they were before Hashable objects,
+// which hashed each key with structhash and stored entries in Go maps keyed by the
+// hash, as a baseline for BenchmarkMapSet and BenchmarkMapGet. They're only built
+// with the structhash tag, since nothing else depends on structhash:
+//
+//	go test -tags structhash -run NONE -bench . ./object
+
+func BenchmarkStructhashSet(b *testing.B) {
+	for kind := range benchmarkKeySets {
+		keys := makeKeys(kind)
+
+		b.Run(kind, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				var (
+					mapKeys   = make(map[string]Object)
+					mapValues = make(map[string]Object)
+				)
+
+				for _, key := range keys {
+					hash, _ := structhash.Hash(key, 1)
+					mapKeys[hash] = key
+					mapValues[hash] = key
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStructhashGet(b *testing.B) {
+	for kind := range benchmarkKeySets {
+		var (
+			keys      = makeKeys(kind)
+			mapValues = make(map[string]Object)
+		)
+
+		for _, key := range keys {
+			hash, _ := structhash.Hash(key, 1)
+			mapValues[hash] = key
+		}
+
+		b.Run(kind, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, key := range keys {
+					hash, _ := structhash.Hash(key, 1)
+					_ = mapValues[hash]
+				}
+			}
+		})
+	}
+}
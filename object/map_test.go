@@ -0,0 +1,65 @@
+package object_test
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/Zac-Garby/radon/object"
+)
+
+const benchmarkKeys = 1000
+
+// benchmarkKeySets contains, for each kind of key benchmarked, the keys to use.
+var benchmarkKeySets = map[string]func(int) Object{
+	"string":  func(n int) Object { return s(fmt.Sprintf("key-%d", n)) },
+	"integer": func(n int) Object { return i(int64(n)) },
+	"number":  func(n int) Object { return &Number{Value: float64(n) + 0.5} },
+	"tuple":   func(n int) Object { return tu(i(int64(n)), s("a")) },
+}
+
+func makeKeys(kind string) []Object {
+	keys := make([]Object, benchmarkKeys)
+
+	for n := range keys {
+		keys[n] = benchmarkKeySets[kind](n)
+	}
+
+	return keys
+}
+
+func BenchmarkMapSet(b *testing.B) {
+	for kind := range benchmarkKeySets {
+		keys := makeKeys(kind)
+
+		b.Run(kind, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				m := NewMap(0)
+
+				for _, key := range keys {
+					m.SetSubscript(key, key)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkMapGet(b *testing.B) {
+	for kind := range benchmarkKeySets {
+		var (
+			keys = makeKeys(kind)
+			m    = NewMap(len(keys))
+		)
+
+		for _, key := range keys {
+			m.SetSubscript(key, key)
+		}
+
+		b.Run(kind, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, key := range keys {
+					m.Subscript(key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// The math module mirrors Go's math package. Functions of real numbers accept
+// integers, numbers and decimals, and return numbers. The rounding functions
+// return integers, and abs, min and max keep the type of their arguments.
+func init() {
+	members := map[string]Object{
+		"pi":  &Number{Value: math.Pi},
+		"e":   &Number{Value: math.E},
+		"inf": &Number{Value: math.Inf(1)},
+		"nan": &Number{Value: math.NaN()},
+
+		"floor": roundingFunction("floor", RoundFloor),
+		"ceil":  roundingFunction("ceil", RoundCeiling),
+		"round": roundingFunction("round", RoundHalfUp),
+		"trunc": roundingFunction("trunc", RoundDown),
+
+		"nan?": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				xs, errType, errMsg := mathArgs("nan?", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return &Boolean{Value: math.IsNaN(xs[0])}, "", ""
+			},
+		},
+
+		"inf?": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				xs, errType, errMsg := mathArgs("inf?", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return &Boolean{Value: math.IsInf(xs[0], 0)}, "", ""
+			},
+		},
+
+		"abs": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to math.abs(...)"
+				}
+
+				switch n := args[0].(type) {
+				case *Integer:
+					return makeInteger(new(big.Int).Abs(n.big())), "", ""
+
+				case *Number:
+					return &Number{Value: math.Abs(n.Value)}, "", ""
+
+				case *Decimal:
+					return &Decimal{Unscaled: new(big.Int).Abs(n.Unscaled), Scale: n.Scale}, "", ""
+				}
+
+				return nil, "Type", fmt.Sprintf("the argument to math.abs(...) should be numeric, not %s", args[0].Type())
+			},
+		},
+
+		"min": extremeFunction("min", "<"),
+		"max": extremeFunction("max", ">"),
+
+		"gcd": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 2 {
+					return nil, "Argument", "expected exactly two arguments to math.gcd(...)"
+				}
+
+				a, ok := args[0].(*Integer)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("the arguments to math.gcd(...) should be integers, not %s", args[0].Type())
+				}
+
+				b, ok := args[1].(*Integer)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("the arguments to math.gcd(...) should be integers, not %s", args[1].Type())
+				}
+
+				gcd := new(big.Int).GCD(nil, nil, new(big.Int).Abs(a.big()), new(big.Int).Abs(b.big()))
+
+				return makeInteger(gcd), "", ""
+			},
+		},
+	}
+
+	for name, fn := range map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"cbrt":  math.Cbrt,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"sinh":  math.Sinh,
+		"cosh":  math.Cosh,
+		"tanh":  math.Tanh,
+	} {
+		fn := fn
+		members[name] = realFunction(name, 1, func(xs []float64) float64 { return fn(xs[0]) })
+	}
+
+	members["atan2"] = realFunction("atan2", 2, func(xs []float64) float64 { return math.Atan2(xs[0], xs[1]) })
+	members["hypot"] = realFunction("hypot", 2, func(xs []float64) float64 { return math.Hypot(xs[0], xs[1]) })
+
+	defineModule("math", members)
+}
+
+// mathArgs checks that exactly n numeric arguments were passed to the math builtin
+// called name, and returns their values. If they weren't, the error type and
+// message are returned instead.
+func mathArgs(name string, n int, args []Object) ([]float64, string, string) {
+	if len(args) != n {
+		return nil, "Argument", fmt.Sprintf("expected exactly %d argument(s) to math.%s(...)", n, name)
+	}
+
+	xs := make([]float64, n)
+
+	for i, arg := range args {
+		switch arg.(type) {
+		case *Integer, *Number, *Decimal:
+			xs[i], _ = arg.Numeric()
+
+		default:
+			return nil, "Type", fmt.Sprintf("the arguments to math.%s(...) should be numeric, not %s", name, arg.Type())
+		}
+	}
+
+	return xs, "", ""
+}
+
+// realFunction makes a builtin which applies fn to n real numbers. If fn gives NaN
+// when none of its arguments were, the arguments were outside of its domain and an
+// Argument error is returned, so math.sqrt -1 is an error rather than NaN.
+func realFunction(name string, n int, fn func([]float64) float64) *Builtin {
+	return &Builtin{
+		Arity: n,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			xs, errType, errMsg := mathArgs(name, n, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			val := fn(xs)
+
+			if math.IsNaN(val) {
+				for _, x := range xs {
+					if math.IsNaN(x) {
+						return &Number{Value: val}, "", ""
+					}
+				}
+
+				strs := make([]string, len(args))
+				for i, arg := range args {
+					strs[i] = arg.String()
+				}
+
+				return nil, "Argument", fmt.Sprintf("math.%s(...) isn't defined for %s", name, strings.Join(strs, ", "))
+			}
+
+			return &Number{Value: val}, "", ""
+		},
+	}
+}
+
+// roundingFunction makes a builtin which rounds a numeric value to an integer
+// using mode. Integers are returned unchanged.
+func roundingFunction(name string, mode RoundingMode) *Builtin {
+	return &Builtin{
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", fmt.Sprintf("expected exactly one argument to math.%s(...)", name)
+			}
+
+			switch n := args[0].(type) {
+			case *Integer:
+				return n, "", ""
+
+			case *Number, *Decimal:
+				d, ok := ToDecimal(n)
+				if !ok {
+					return nil, "Argument", fmt.Sprintf("cannot round %s to an integer", n)
+				}
+
+				return makeInteger(d.Round(0, mode).Unscaled), "", ""
+			}
+
+			return nil, "Type", fmt.Sprintf("the argument to math.%s(...) should be numeric, not %s", name, args[0].Type())
+		},
+	}
+}
+
+// extremeFunction makes a builtin which finds the smallest or largest of its
+// arguments, or of the items of its only argument, comparing them with op.
+func extremeFunction(name, op string) *Builtin {
+	return &Builtin{
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			items := args
+
+			if len(args) == 1 {
+				var ok bool
+
+				items, ok = args[0].Items()
+				if !ok {
+					return nil, "Type", fmt.Sprintf("cannot find the %s of a %s", name, args[0].Type())
+				}
+			}
+
+			if len(items) == 0 {
+				return nil, "Argument", fmt.Sprintf("cannot find the %s of nothing", name)
+			}
+
+			best := items[0]
+
+			for _, item := range items[1:] {
+				cmp, ok := item.Infix(op, best)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("cannot compare values of type %s and %s", item.Type(), best.Type())
+				}
+
+				if b, ok := cmp.(*Boolean); ok && b.Value {
+					best = item
+				}
+			}
+
+			return best, "", ""
+		},
+	}
+}
@@ -0,0 +1,96 @@
+package object
+
+import (
+	"fmt"
+)
+
+// A Model is a blueprint for maps, which are known as its instances. Calling a
+// model calls Init with the arguments, which makes an instance containing each
+// parameter, along with the key __model set to the model. Functions stored in
+// a model are its methods, and can be looked up on any of its instances, or
+// the instances of any model with it as an ancestor.
+type Model struct {
+	defaults
+	Init    *Function
+	Parent  *Model
+	Methods *Map
+}
+
+// NewModel makes a new Model with no methods.
+func NewModel(init *Function, parent *Model) *Model {
+	return &Model{
+		Init:    init,
+		Parent:  parent,
+		Methods: NewMap(0),
+	}
+}
+
+func (m *Model) String() string {
+	return fmt.Sprintf("<model (%d)>", len(m.Init.Parameters))
+}
+
+// Type returns the type of an Object.
+func (m *Model) Type() Type {
+	return ModelType
+}
+
+// Equals checks whether or not two objects are equal to each other. A model is
+// only equal to itself.
+func (m *Model) Equals(other Object) bool {
+	o, ok := other.(*Model)
+	return ok && m == o
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (m *Model) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{m}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (m *Model) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{
+			Value: []Object{m, right},
+		}, true
+	}
+
+	return nil, false
+}
+
+// Subscript subscrips an Object, e.g. foo[bar], or returns false if it can't be
+// done. Subscripting a model looks up a method, and model.__parent is the model's
+// parent, or nil if it doesn't have one.
+func (m *Model) Subscript(key Object) (Object, bool) {
+	if str, ok := key.(*String); ok && str.Value == "__parent" {
+		if m.Parent == nil {
+			return &Nil{}, true
+		}
+
+		return m.Parent, true
+	}
+
+	return m.Method(key)
+}
+
+// SetSubscript sets the value of a subscript of an Object, e.g. foo[bar] = baz.
+// Returns false if it can't be done.
+func (m *Model) SetSubscript(key Object, val Object) bool {
+	return m.Methods.SetSubscript(key, val)
+}
+
+// Method looks up a method in the model, then in each of its ancestors in turn.
+func (m *Model) Method(key Object) (Object, bool) {
+	for model := m; model != nil; model = model.Parent {
+		if val, ok := model.Methods.Subscript(key); ok {
+			return val, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,40 @@
+package object
+
+import "sort"
+
+// Modules contains every builtin module, such as re. A module is a frozen map of
+// its members, which are accessed with a dot, e.g. re.compile. Like builtins,
+// modules are defined in every scope.
+var Modules = make(map[string]*Map)
+
+// defineModule defines a module called name, with the given members. Any builtin
+// members without names are named after the module and the member, e.g.
+// re.compile, and the members are sorted by name.
+func defineModule(name string, members map[string]Object) *Map {
+	var (
+		module = NewMap(len(members))
+		names  = make([]string, 0, len(members))
+	)
+
+	module.Frozen = true
+
+	for member := range members {
+		names = append(names, member)
+	}
+
+	sort.Strings(names)
+
+	for _, member := range names {
+		val := members[member]
+
+		if b, ok := val.(*Builtin); ok && b.Name == "" {
+			b.Name = name + "." + member
+		}
+
+		module.Set(&String{Value: member}, val)
+	}
+
+	Modules[name] = module
+
+	return module
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"fmt"
 	"math"
+	"math/big"
 )
 
 // A Number is a 64-bit floating point decimal.
@@ -20,12 +21,28 @@ func (n *Number) Type() Type {
 	return NumberType
 }
 
-// Equals checks whether or not two objects are equal to each other.
+// Equals checks whether or not two objects are equal to each other. A Number
+// is equal to an Integer with exactly the same value, and to a Decimal with
+// the same value as its shortest decimal representation.
 func (n *Number) Equals(other Object) bool {
 	switch o := other.(type) {
 	case *Number:
 		return n.Value == o.Value
 
+	case *Integer:
+		if math.IsNaN(n.Value) || math.IsInf(n.Value, 0) {
+			return false
+		}
+
+		if o.Big == nil && n.Value > math.MinInt64 && n.Value < math.MaxInt64 {
+			return n.Value == math.Trunc(n.Value) && int64(n.Value) == o.Value
+		}
+
+		return big.NewFloat(n.Value).Cmp(new(big.Float).SetInt(o.big())) == 0
+
+	case *Decimal:
+		return o.Equals(n)
+
 	default:
 		return false
 	}
@@ -54,7 +71,8 @@ func (n *Number) Prefix(op string) (Object, bool) {
 }
 
 // Infix applies a infix operator to an object, returning the result. If the operation
-// cannot be performed, (nil, false) is returned.
+// cannot be performed, (nil, false) is returned. If the right operand is a Decimal,
+// the operation is performed on Decimals instead.
 func (n *Number) Infix(op string, right Object) (Object, bool) {
 	if op == "," {
 		return &Tuple{
@@ -62,6 +80,15 @@ func (n *Number) Infix(op string, right Object) (Object, bool) {
 		}, true
 	}
 
+	if _, ok := right.(*Decimal); ok {
+		left, ok := ToDecimal(n)
+		if !ok {
+			return nil, false
+		}
+
+		return left.Infix(op, right)
+	}
+
 	l := n.Value
 
 	r, ok := right.Numeric()
@@ -96,7 +123,14 @@ func (n *Number) Infix(op string, right Object) (Object, bool) {
 	case "&":
 		val = float64(int64(l) & int64(r))
 	case "%":
-		val = float64(int64(l) % int64(r))
+		// The result has the same sign as the divisor, consistent with
+		// floored division.
+		mod := math.Mod(l, r)
+		if mod != 0 && (mod < 0) != (r < 0) {
+			mod += r
+		}
+
+		val = mod
 	default:
 		return nil, false
 	}
@@ -8,16 +8,23 @@ const (
 	_ Type = ""
 
 	NumberType   = "number"
+	IntegerType  = "integer"
+	DecimalType  = "decimal"
 	BooleanType  = "boolean"
 	StringType   = "string"
 	ListType     = "list"
 	TupleType    = "tuple"
 	MapType      = "map"
+	SetType      = "set"
+	SliceType    = "slice"
+	RegexType    = "regex"
+	StreamType   = "stream"
 	NilType      = "nil"
 	FunctionType = "function"
 	MethodType   = "method"
 	BuiltinType  = "builtin"
 	ModelType    = "model"
+	ProtocolType = "protocol"
 	IterType     = "iter"
 )
 
@@ -57,6 +64,13 @@ type Object interface {
 	Iter() (Iterable, bool)
 }
 
+// A Container is an Object which can check whether it contains another, using the
+// in operator.
+type Container interface {
+	Object
+	Contains(Object) bool
+}
+
 // defaults supplies default implementations so other Object types automatically
 // implement the methods.
 type defaults struct{}
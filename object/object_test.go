@@ -2,6 +2,8 @@ package object_test
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
 
 	. "github.com/Zac-Garby/radon/object"
@@ -11,6 +13,20 @@ func n(val float64) *Number {
 	return &Number{Value: val}
 }
 
+func i(val int64) *Integer {
+	return &Integer{Value: val}
+}
+
+func bi(val string) *Integer {
+	b, _ := new(big.Int).SetString(val, 10)
+	return &Integer{Big: b}
+}
+
+func d(val string) *Decimal {
+	dec, _ := ParseDecimal(val)
+	return dec
+}
+
 func b(val bool) *Boolean {
 	return &Boolean{Value: val}
 }
@@ -28,10 +44,7 @@ func tu(vals ...Object) *Tuple {
 }
 
 func m(kvs ...Object) *Map {
-	m := &Map{
-		Keys:   make(map[string]Object),
-		Values: make(map[string]Object),
-	}
+	m := NewMap(len(kvs) / 2)
 
 	if len(kvs)%2 != 0 {
 		panic("expected even amount of key-values")
@@ -44,6 +57,43 @@ func m(kvs ...Object) *Map {
 	return m
 }
 
+func set(vals ...Object) *Set {
+	s := NewSet(len(vals))
+
+	for _, val := range vals {
+		s.Add(val)
+	}
+
+	return s
+}
+
+// sl makes a slice, with nil in place of any parts which are left out.
+func sl(start, stop, step Object) *Slice {
+	parts := []Object{start, stop, step}
+
+	for i, part := range parts {
+		if part == nil {
+			parts[i] = &Nil{}
+		}
+	}
+
+	slice, ok := NewSlice(parts[0], parts[1], parts[2])
+	if !ok {
+		panic("expected integral slice parts")
+	}
+
+	return slice
+}
+
+func re(pattern string) *Regex {
+	r, err := CompileRegex(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
 func f(self *Map, params ...string) *Function {
 	return &Function{
 		Parameters: params,
@@ -53,16 +103,27 @@ func f(self *Map, params ...string) *Function {
 
 func TestStringify(t *testing.T) {
 	cases := map[Object]string{
-		n(5):                        "5",
-		n(3.7):                      "3.7",
-		b(true):                     "true",
-		b(false):                    "false",
-		s("foo"):                    `"foo"`,
-		&Nil{}:                      "nil",
-		l(n(1), n(2), n(3)):         "[1, 2, 3]",
-		tu(n(1), n(2), n(3)):        "(1, 2, 3)",
-		m(s("a"), n(5)):             `{"a": 5}`,
-		f(nil, "foo", "bar", "baz"): "<function (3)>",
+		n(5):                                 "5",
+		n(3.7):                               "3.7",
+		i(-12):                               "-12",
+		bi("123456789012345678901234567890"): "123456789012345678901234567890",
+		d("12.30"):                           "12.30",
+		d("-0.05"):                           "-0.05",
+		d("1.5e3"):                           "1500",
+		b(true):                              "true",
+		b(false):                             "false",
+		s("foo"):                             `"foo"`,
+		&Nil{}:                               "nil",
+		l(n(1), n(2), n(3)):                  "[1, 2, 3]",
+		tu(n(1), n(2), n(3)):                 "(1, 2, 3)",
+		m(s("a"), n(5)):                      `{"a": 5}`,
+		set(n(2), n(1), n(2)):                "set [2, 1]",
+		set():                                "set []",
+		sl(i(1), nil, nil):                   "1:",
+		sl(nil, i(-1), i(2)):                 ":-1:2",
+		m(s("b"), n(1), s("a"), n(2), i(3), n(3)):                           `{"b": 1, "a": 2, 3: 3}`,
+		f(nil, "foo", "bar", "baz"):                                         "<function (3)>",
+		&Partial{Fn: f(nil, "a", "b", "c"), Args: []Object{n(1)}, Arity: 3}: "<partial (2)>",
 	}
 
 	for o, s := range cases {
@@ -82,6 +143,24 @@ func TestEquals(t *testing.T) {
 		{n(10), n(11), false},
 		{n(1), b(true), false},
 
+		{i(5), i(5), true},
+		{i(5), i(6), false},
+		{i(5), n(5), true},
+		{n(5), i(5), true},
+		{i(5), n(5.5), false},
+		{n(math.NaN()), i(0), false},
+		{bi("100000000000000000000"), bi("100000000000000000000"), true},
+		{bi("100000000000000000000"), n(1e20), true},
+		{i(1), b(true), false},
+
+		{d("1.50"), d("1.5"), true},
+		{d("1.50"), d("1.51"), false},
+		{d("0.1"), n(0.1), true},
+		{n(0.1), d("0.1"), true},
+		{i(2), d("2.00"), true},
+		{d("2.5"), i(2), false},
+		{d("1"), b(true), false},
+
 		{b(true), b(true), true},
 		{b(true), b(false), false},
 		{b(false), n(5), false},
@@ -111,6 +190,15 @@ func TestEquals(t *testing.T) {
 		{m(n(1), n(2)), m(n(1), n(2), n(3), n(5)), false},
 		{m(n(1), n(2), n(3), n(4)), m(n(1), n(2)), false},
 
+		{set(n(1), n(2)), set(n(2), n(1)), true},
+		{set(n(1)), set(i(1)), true},
+		{set(n(1)), set(n(1), n(2)), false},
+		{set(), l(), false},
+
+		{re(`a+`), re(`a+`), true},
+		{re(`a+`), re(`a*`), false},
+		{re(`a+`), s("a+"), false},
+
 		{f(nil), f(nil), false},
 		{f(nil), n(5), false},
 	}
@@ -130,6 +218,8 @@ func TestPrefix(t *testing.T) {
 	}{
 		{"-", n(5), n(-5)},
 		{"+", n(5), n(5)},
+		{"-", i(5), i(-5)},
+		{"-", i(math.MinInt64), bi("9223372036854775808")},
 
 		{"!", b(true), b(false)},
 		{"!", b(false), b(true)},
@@ -170,6 +260,49 @@ func TestInfix(t *testing.T) {
 		{n(1), "|", n(2), n(3)},
 		{n(1), "&", n(2), n(0)},
 		{n(1), ",", n(2), tu(n(1), n(2))},
+		{n(-7), "%", n(2), n(1)},
+		{n(7), "%", n(-2), n(-1)},
+
+		{i(1), "+", i(2), i(3)},
+		{i(1), "-", i(2), i(-1)},
+		{i(3), "*", i(4), i(12)},
+		{i(7), "/", i(2), n(3.5)},
+		{i(7), "//", i(2), i(3)},
+		{i(-7), "//", i(2), i(-4)},
+		{i(7), "//", i(-2), i(-4)},
+		{i(7), "%", i(3), i(1)},
+		{i(-7), "%", i(3), i(2)},
+		{i(7), "%", i(-3), i(-2)},
+		{i(2), "^", i(10), i(1024)},
+		{i(2), "^", i(-1), n(0.5)},
+		{i(6), "|", i(3), i(7)},
+		{i(6), "&", i(3), i(2)},
+		{i(1), "<", i(2), b(true)},
+		{i(1), ">=", i(2), b(false)},
+		{i(1), "+", n(0.5), n(1.5)},
+		{n(0.5), "+", i(1), n(1.5)},
+		{i(math.MaxInt64), "+", i(1), bi("9223372036854775808")},
+		{i(math.MinInt64), "-", i(1), bi("-9223372036854775809")},
+		{i(math.MaxInt64), "*", i(2), bi("18446744073709551614")},
+		{i(math.MinInt64), "//", i(-1), bi("9223372036854775808")},
+		{i(2), "^", i(64), bi("18446744073709551616")},
+		{bi("18446744073709551616"), "-", bi("18446744073709551615"), i(1)},
+		{bi("-18446744073709551617"), "//", i(2), bi("-9223372036854775809")},
+		{bi("-18446744073709551617"), "%", i(2), i(1)},
+		{bi("18446744073709551616"), ">", i(1), b(true)},
+
+		{d("0.1"), "+", d("0.2"), d("0.3")},
+		{d("1.5"), "-", d("2"), d("-0.5")},
+		{d("1.1"), "*", d("1.1"), d("1.21")},
+		{d("1"), "/", d("8"), d("0.125")},
+		{d("7.5"), "//", i(2), d("3")},
+		{d("-7.5"), "%", i(2), d("0.5")},
+		{d("1.1"), "^", i(2), d("1.21")},
+		{d("1.5"), "<", i(2), b(true)},
+		{d("0.5"), ">=", n(0.5), b(true)},
+		{d("0.1"), "+", n(0.2), d("0.3")},
+		{n(0.2), "+", d("0.1"), d("0.3")},
+		{i(1), "-", d("0.01"), d("0.99")},
 
 		{b(true), "&&", b(false), b(false)},
 		{b(false), "||", b(true), b(true)},
@@ -201,8 +334,8 @@ func TestInfix(t *testing.T) {
 			continue
 		}
 
-		if !got.Equals(c.out) {
-			fmt.Printf("%v %s %v should equal %v\n", c.left, c.op, c.right, c.out)
+		if !got.Equals(c.out) || got.Type() != c.out.Type() {
+			fmt.Printf("%v %s %v should equal %v (%s)\n", c.left, c.op, c.right, c.out, c.out.Type())
 			t.Fail()
 		}
 	}
@@ -211,6 +344,7 @@ func TestInfix(t *testing.T) {
 func TestNumeric(t *testing.T) {
 	cases := map[Object]float64{
 		n(5): 5,
+		i(5): 5,
 
 		b(true):  1,
 		b(false): 0,
@@ -274,6 +408,32 @@ func TestSubscript(t *testing.T) {
 
 		{m(n(1), n(2), n(3), n(4)), n(4), n(2), false},
 		{m(n(1), n(2), n(3), n(4)), n(2), n(4), false},
+
+		{m(i(1), s("a")), n(1), s("a"), true},
+		{m(n(1), s("a")), i(1), s("a"), true},
+		{m(n(1.5), s("a")), i(1), s("a"), false},
+		{m(bi("100000000000000000000"), s("a")), n(1e20), s("a"), true},
+		{m(d("0.50"), s("a")), n(0.5), s("a"), true},
+		{m(tu(i(1), s("a")), s("b")), tu(n(1), s("a")), s("b"), true},
+		{m(Freeze(l(n(1))), s("a")), Freeze(l(i(1))), s("a"), true},
+		{m(Freeze(l(n(1))), s("a")), l(n(1)), nil, false},
+		{m(Freeze(l(n(1))), s("a")), Freeze(tu(n(1))), nil, false},
+
+		{l(n(1), n(2), n(3)), i(0), n(1), true},
+		{l(n(1), n(2), n(3)), n(-1), n(3), true},
+		{l(n(1), n(2), n(3)), n(-4), nil, false},
+		{l(n(1), n(2), n(3)), n(3), nil, false},
+		{l(n(1), n(2), n(3)), sl(i(1), nil, nil), l(n(2), n(3)), true},
+		{l(n(1), n(2), n(3)), sl(nil, i(-1), nil), l(n(1), n(2)), true},
+		{l(n(1), n(2), n(3)), sl(nil, nil, i(-1)), l(n(3), n(2), n(1)), true},
+		{l(n(1), n(2), n(3)), sl(i(-10), i(10), nil), l(n(1), n(2), n(3)), true},
+		{l(n(1), n(2), n(3)), sl(i(2), i(0), nil), l(), true},
+		{l(n(1), n(2), n(3)), sl(nil, nil, i(0)), nil, false},
+		{tu(n(1), n(2), n(3)), sl(nil, nil, i(2)), tu(n(1), n(3)), true},
+		{tu(n(1), n(2), n(3)), i(-2), n(2), true},
+		{s("héllo"), sl(i(1), i(3), nil), s("él"), true},
+		{s("héllo"), i(-1), s("o"), true},
+		{s("héllo"), sl(nil, nil, i(-2)), s("olh"), true},
 	}
 
 	for _, c := range cases {
@@ -303,10 +463,25 @@ func TestSetSubscript(t *testing.T) {
 	}{
 		{l(n(1), n(2), n(3)), n(1), n(2), true},
 		{l(n(1), n(2), n(3)), n(3), n(2), false},
-		{l(n(1), n(2), n(3)), n(-1), n(2), false},
+		{l(n(1), n(2), n(3)), n(-1), n(2), true},
+		{l(n(1), n(2), n(3)), n(-4), n(2), false},
 		{tu(n(1), n(2), n(3)), n(1), n(2), true},
 		{tu(n(1), n(2), n(3)), n(3), n(2), false},
-		{tu(n(1), n(2), n(3)), n(-1), n(2), false},
+		{tu(n(1), n(2), n(3)), n(-1), n(2), true},
+		{tu(n(1), n(2), n(3)), n(-4), n(2), false},
+		{l(n(1), n(2), n(3)), sl(i(0), i(2), nil), l(n(5)), true},
+		{l(n(1), n(2), n(3)), sl(nil, nil, i(2)), l(n(5), n(6)), true},
+		{l(n(1), n(2), n(3)), sl(nil, nil, i(2)), l(n(5)), false},
+		{l(n(1), n(2), n(3)), sl(nil, nil, i(0)), l(), false},
+		{l(n(1), n(2), n(3)), sl(nil, nil, nil), n(5), false},
+		{tu(n(1), n(2), n(3)), sl(nil, nil, nil), tu(), false},
+		{&String{Value: "héllo"}, i(-4), &String{Value: "e"}, true},
+		{l(n(1), n(2), n(3)), i(2), n(2), true},
+		{l(n(1), n(2), n(3)), bi("100000000000000000000"), n(2), false},
+		{Freeze(l(n(1), n(2), n(3))), n(1), n(2), false},
+		{Freeze(tu(n(1), n(2), n(3))), n(1), n(2), false},
+		{Freeze(&String{Value: "abc"}), n(1), &String{Value: "x"}, false},
+		{Freeze(m(s("a"), n(1))), s("a"), n(2), false},
 		// None for map -- already tested in the m() function
 	}
 
@@ -317,3 +492,268 @@ func TestSetSubscript(t *testing.T) {
 		}
 	}
 }
+
+func TestHash(t *testing.T) {
+	// Each group of objects should all have the same hash
+	same := [][]Object{
+		{n(1), i(1), d("1"), d("1.00")},
+		{n(-0.25), d("-0.250")},
+		{n(0.1), d("0.1"), d("0.10")},
+		{n(1.0 / 3), d("0.3333333333333333")},
+		{n(1e20), bi("100000000000000000000"), d("100000000000000000000.0")},
+		{s("foo"), s("foo")},
+		{tu(n(1), s("a")), tu(i(1), s("a"))},
+		{Freeze(l(n(1), n(2))), Freeze(l(d("1.0"), i(2)))},
+		{Freeze(m(s("a"), n(1), s("b"), n(2))), Freeze(m(s("b"), i(2), s("a"), i(1)))},
+		{set(n(1), s("a")), set(s("a"), i(1))},
+		{re(`\d+`), re(`\d+`)},
+	}
+
+	for _, group := range same {
+		first, ok := Hash(group[0])
+		if !ok {
+			t.Errorf("%v should be hashable", group[0])
+			continue
+		}
+
+		for _, o := range group[1:] {
+			if h, ok := Hash(o); !ok || h != first {
+				t.Errorf("%v and %v should have the same hash", group[0], o)
+			}
+		}
+	}
+
+	unhashable := []Object{
+		l(n(1)),
+		m(s("a"), n(1)),
+		tu(n(1), l(n(2))),
+		Freeze(l(n(1), f(nil))),
+		f(nil),
+	}
+
+	for _, o := range unhashable {
+		if IsHashable(o) {
+			t.Errorf("%v shouldn't be hashable", o)
+		}
+	}
+}
+
+func TestMapKeys(t *testing.T) {
+	var (
+		key = s("ab")
+		mp  = m(key, n(1))
+	)
+
+	key.SetSubscript(i(0), s("x"))
+
+	if val, ok := mp.Subscript(s("ab")); !ok || !val.Equals(n(1)) {
+		t.Errorf("modifying a string shouldn't change a key it was used as")
+	}
+
+	mp.SetSubscript(s("c"), n(2))
+	mp.SetSubscript(s("ab"), n(3))
+
+	if keys := tu(mp.Keys()...); keys.String() != `("ab", "c")` {
+		t.Errorf("expected the keys to stay in the order they were added, got %s", keys)
+	}
+}
+
+func TestContains(t *testing.T) {
+	cases := []struct {
+		container, item Object
+		contains, ok    bool
+	}{
+		{l(n(1), n(2)), i(2), true, true},
+		{l(n(1), n(2)), n(3), false, true},
+		{tu(s("a")), s("a"), true, true},
+		{m(s("a"), n(1)), s("a"), true, true},
+		{m(s("a"), n(1)), n(1), false, true},
+		{s("hello"), s("ll"), true, true},
+		{s("hello"), s("lo!"), false, true},
+		{s("1"), n(1), false, true},
+		{set(n(1)), d("1.0"), true, true},
+		{&ListIterable{List: l(n(5))}, n(5), true, true},
+		{n(5), n(5), false, false},
+		{&Nil{}, n(5), false, false},
+	}
+
+	for _, c := range cases {
+		contains, ok := Contains(c.container, c.item)
+		if contains != c.contains || ok != c.ok {
+			t.Errorf("Contains(%s, %s): expected (%v, %v), got (%v, %v)", c.container, c.item, c.contains, c.ok, contains, ok)
+		}
+	}
+}
+
+func TestModelMethods(t *testing.T) {
+	var (
+		animal = NewModel(f(nil, "name"), nil)
+		dog    = NewModel(f(nil, "name"), animal)
+		speak  = f(nil)
+		fetch  = f(nil, "thing")
+	)
+
+	animal.SetSubscript(s("speak"), speak)
+	dog.SetSubscript(s("fetch"), fetch)
+
+	if method, ok := dog.Subscript(s("speak")); !ok || method != speak {
+		t.Errorf("a model should inherit its parent's methods")
+	}
+
+	if _, ok := animal.Subscript(s("fetch")); ok {
+		t.Errorf("a model shouldn't have its children's methods")
+	}
+
+	if parent, ok := dog.Subscript(s("__parent")); !ok || parent != animal {
+		t.Errorf("model.__parent should be the model's parent")
+	}
+
+	instance := m(s("__model"), dog, s("name"), s("rex"))
+
+	for _, name := range []string{"speak", "fetch"} {
+		method, ok := instance.Subscript(s(name))
+		if !ok {
+			t.Errorf("%s should be found through the instance's model", name)
+			continue
+		}
+
+		if fn, ok := method.(*Function); !ok || fn.Self != instance || fn.Type() != MethodType {
+			t.Errorf("%s should be a method bound to the instance, got %s", name, method)
+		}
+	}
+
+	if speak.IsMethod() {
+		t.Errorf("binding a method shouldn't modify the original function")
+	}
+
+	if _, ok := instance.Subscript(s("bark")); ok {
+		t.Errorf("bark shouldn't be found on the instance")
+	}
+
+	if _, ok := m(s("name"), s("rex")).Subscript(s("speak")); ok {
+		t.Errorf("a map which isn't an instance shouldn't have methods")
+	}
+}
+
+func TestProtocolImplements(t *testing.T) {
+	var (
+		shape = &Protocol{Methods: []ProtocolMethod{
+			{Name: "area", Arity: 0},
+			{Name: "scale", Arity: 1},
+			{Name: "name", Arity: -1},
+		}}
+
+		square = NewModel(f(nil, "side"), nil)
+	)
+
+	square.SetSubscript(s("area"), f(nil))
+	square.SetSubscript(s("scale"), &Function{Parameters: []string{"x", "y"}, Defaults: []Object{n(1)}})
+
+	cases := []struct {
+		in      Object
+		ok      bool
+		missing string
+	}{
+		{m(s("__model"), square, s("name"), f(nil)), true, ""},
+		{m(s("__model"), square, s("name"), s("square")), false, "name"},
+		{m(s("__model"), square), false, "name"},
+		{m(s("area"), f(nil, "x"), s("scale"), f(nil, "x"), s("name"), f(nil)), false, "area"},
+		{m(s("area"), f(nil), s("scale"), &Function{Rest: "xs"}, s("name"), f(nil)), true, ""},
+		{l(n(1)), false, "area"},
+	}
+
+	for _, c := range cases {
+		ok, missing := shape.Implements(c.in)
+
+		if ok != c.ok || missing.Name != c.missing {
+			t.Errorf("%s: expected (%t, %s), got (%t, %s)", c.in, c.ok, c.missing, ok, missing.Name)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		left  *Decimal
+		op    string
+		right Object
+		out   string
+	}{
+		{d("12.30"), "+", d("0.7"), "13.00"},
+		{d("10.00"), "/", i(2), "5.00"},
+		{d("10"), "/", i(4), "2.5"},
+		{d("10"), "/", i(3), "3.333333333333333333333333333"},
+		{d("2"), "/", i(3), "0.6666666666666666666666666667"},
+		{d("-2"), "/", i(3), "-0.6666666666666666666666666667"},
+		{d("19.99"), "*", i(3), "59.97"},
+	}
+
+	for _, c := range cases {
+		got, ok := c.left.Infix(c.op, c.right)
+		if !ok || got.String() != c.out {
+			t.Errorf("%v %s %v should be %s, got %v\n", c.left, c.op, c.right, c.out, got)
+		}
+	}
+
+	if _, ok := d("1").Infix("/", d("0")); ok {
+		t.Errorf("division by zero should fail")
+	}
+
+	rounding := []struct {
+		in   string
+		mode RoundingMode
+		out  string
+	}{
+		{"2.5", RoundHalfEven, "2"},
+		{"3.5", RoundHalfEven, "4"},
+		{"2.51", RoundHalfEven, "3"},
+		{"-2.5", RoundHalfEven, "-2"},
+		{"2.5", RoundHalfUp, "3"},
+		{"-2.5", RoundHalfUp, "-3"},
+		{"2.5", RoundHalfDown, "2"},
+		{"2.6", RoundHalfDown, "3"},
+		{"2.1", RoundUp, "3"},
+		{"-2.1", RoundUp, "-3"},
+		{"2.9", RoundDown, "2"},
+		{"-2.9", RoundDown, "-2"},
+		{"-2.1", RoundCeiling, "-2"},
+		{"2.1", RoundCeiling, "3"},
+		{"2.9", RoundFloor, "2"},
+		{"-2.1", RoundFloor, "-3"},
+		{"2", RoundFloor, "2"},
+	}
+
+	for _, c := range rounding {
+		if got := d(c.in).Round(0, c.mode).String(); got != c.out {
+			t.Errorf("%s rounded %s should be %s, got %s\n", c.in, c.mode, c.out, got)
+		}
+	}
+
+	if got := d("1.005").Round(2, RoundHalfUp).String(); got != "1.01" {
+		t.Errorf("1.005 rounded to 2 places should be 1.01, got %s\n", got)
+	}
+
+	if got := d("1.5").Round(3, RoundHalfEven).String(); got != "1.500" {
+		t.Errorf("1.5 rounded to 3 places should be 1.500, got %s\n", got)
+	}
+
+	ctx := DecimalContext{Precision: 3, Rounding: RoundDown}
+	if got, ok := d("2").Quo(d("3"), ctx); !ok || got.String() != "0.666" {
+		t.Errorf("2 / 3 to 3 digits, rounding down, should be 0.666, got %v\n", got)
+	}
+
+	if got, ok := ParseDecimal("1e100000"); !ok || len(got.String()) != 100001 {
+		t.Errorf("1e100000 should parse as a decimal\n")
+	}
+
+	for _, bad := range []string{"", "-", "1.2.3", "1e", "abc", "1-2", "1e1000000000", "1e-100001", "1.5e-100000"} {
+		if _, ok := ParseDecimal(bad); ok {
+			t.Errorf("%q shouldn't parse as a decimal\n", bad)
+		}
+	}
+}
+
+func TestRegexCache(t *testing.T) {
+	if re(`x+`) != re(`x+`) {
+		t.Errorf("compiling the same pattern twice should reuse the cached regex")
+	}
+}
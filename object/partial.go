@@ -0,0 +1,58 @@
+package object
+
+import (
+	"fmt"
+)
+
+// A Partial is a function, method, or builtin which has been called with fewer
+// arguments than it takes. It remembers the arguments it has been given, and
+// calls the underlying function once the rest are supplied.
+type Partial struct {
+	defaults
+	Fn    Object
+	Args  []Object
+	Arity int
+}
+
+func (p *Partial) String() string {
+	return fmt.Sprintf("<partial (%d)>", p.Remaining())
+}
+
+// Type returns the type of an Object. A Partial has the type of the function
+// it wraps.
+func (p *Partial) Type() Type {
+	return p.Fn.Type()
+}
+
+// Equals checks whether or not two objects are equal to each other.
+func (p *Partial) Equals(other Object) bool {
+	return false
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (p *Partial) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{p}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (p *Partial) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{
+			Value: []Object{p, right},
+		}, true
+	}
+
+	return nil, false
+}
+
+// Remaining returns the amount of arguments which still need to be supplied
+// before the underlying function is called.
+func (p *Partial) Remaining() int {
+	return p.Arity - len(p.Args)
+}
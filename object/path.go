@@ -0,0 +1,92 @@
+package object
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// The path module manipulates file paths, using the host's separator. None of its
+// builtins access the file system.
+func init() {
+	defineModule("path", map[string]Object{
+		"join": &Builtin{
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				parts, errType, errMsg := pathArgs("join", -1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return &String{Value: filepath.Join(parts...)}, "", ""
+			},
+		},
+
+		// path.split splits a path into a tuple of its directory and its file name.
+		"split": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				parts, errType, errMsg := pathArgs("split", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				dir, file := filepath.Split(parts[0])
+
+				return &Tuple{Value: []Object{&String{Value: dir}, &String{Value: file}}}, "", ""
+			},
+		},
+
+		"base":  pathFunction("base", filepath.Base),
+		"dir":   pathFunction("dir", filepath.Dir),
+		"ext":   pathFunction("ext", filepath.Ext),
+		"clean": pathFunction("clean", filepath.Clean),
+
+		"absolute?": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				parts, errType, errMsg := pathArgs("absolute?", 1, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return &Boolean{Value: filepath.IsAbs(parts[0])}, "", ""
+			},
+		},
+	})
+}
+
+// pathFunction makes a path builtin, which takes a single path and returns fn
+// applied to it.
+func pathFunction(name string, fn func(string) string) *Builtin {
+	return &Builtin{
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			parts, errType, errMsg := pathArgs(name, 1, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			return &String{Value: fn(parts[0])}, "", ""
+		},
+	}
+}
+
+// pathArgs checks that n arguments, or any number if n is negative, were passed to
+// the path builtin called name, and that they're all strings.
+func pathArgs(name string, n int, args []Object) ([]string, string, string) {
+	if n >= 0 && len(args) != n {
+		return nil, "Argument", fmt.Sprintf("expected exactly %d argument(s) to path.%s(...)", n, name)
+	}
+
+	parts := make([]string, len(args))
+
+	for i, arg := range args {
+		str, ok := arg.(*String)
+		if !ok {
+			return nil, "Type", fmt.Sprintf("the arguments to path.%s(...) should be strings, not %s", name, arg.Type())
+		}
+
+		parts[i] = str.Value
+	}
+
+	return parts, "", ""
+}
@@ -0,0 +1,95 @@
+package object
+
+import (
+	"fmt"
+)
+
+// The process builtins let a program interact with the process running it, through
+// the Context it's run in.
+//
+//   - input prompt writes prompt, then reads a line of input, or returns nil at the
+//     end of the input
+//   - env name returns the environment variable called name, or nil if it's unset
+//   - exit code stops the program, with the exit code, which defaults to zero
+func init() {
+	Builtins["input"] = &Builtin{
+		Name:  "input",
+		Arity: 1,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) > 1 {
+				return nil, "Argument", "expected at most one argument to input(...)"
+			}
+
+			if len(args) == 1 {
+				prompt, ok := args[0].(*String)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("the prompt passed to input(...) should be a string, not %s", args[0].Type())
+				}
+
+				if _, err := fmt.Fprint(ctx.Out, prompt.Value); err != nil {
+					return nil, "IO", fmt.Sprintf("cannot write the prompt: %s", err)
+				}
+			}
+
+			line, ok := ctx.In.ReadLine()
+			if !ok {
+				return &Nil{}, "", ""
+			}
+
+			return &String{Value: line}, "", ""
+		},
+	}
+
+	Builtins["env"] = &Builtin{
+		Name:  "env",
+		Arity: 1,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to env(...)"
+			}
+
+			name, ok := args[0].(*String)
+			if !ok {
+				return nil, "Type", fmt.Sprintf("the argument to env(...) should be a string, not %s", args[0].Type())
+			}
+
+			if ctx.Env == nil {
+				return &Nil{}, "", ""
+			}
+
+			val, ok := ctx.Env(name.Value)
+			if !ok {
+				return &Nil{}, "", ""
+			}
+
+			return &String{Value: val}, "", ""
+		},
+	}
+
+	Builtins["exit"] = &Builtin{
+		Name:  "exit",
+		Arity: 1,
+		Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) > 1 {
+				return nil, "Argument", "expected at most one argument to exit(...)"
+			}
+
+			code := 0
+
+			if len(args) == 1 {
+				n, ok := args[0].(*Integer)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("the exit code should be an integer, not %s", args[0])
+				}
+
+				if code, ok = n.Int(); !ok {
+					return nil, "Argument", fmt.Sprintf("the exit code %s is too big", n)
+				}
+			}
+
+			ctx.Exit(code)
+
+			return nil, "Exit", fmt.Sprintf("exited with code %d", code)
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Protocol is a list of methods. An object implements a protocol if it has
+// each of them, e.g. an instance of a model which defines them.
+type Protocol struct {
+	defaults
+	Methods []ProtocolMethod
+}
+
+// A ProtocolMethod is a method required by a protocol. Arity is the amount of
+// arguments the method must accept, or -1 if it can take any amount.
+type ProtocolMethod struct {
+	Name  string
+	Arity int
+}
+
+func (m ProtocolMethod) String() string {
+	if m.Arity < 0 {
+		return m.Name
+	}
+
+	return fmt.Sprintf("%s (%d)", m.Name, m.Arity)
+}
+
+func (p *Protocol) String() string {
+	methods := make([]string, len(p.Methods))
+
+	for i, method := range p.Methods {
+		methods[i] = method.String()
+	}
+
+	return fmt.Sprintf("<protocol %s>", strings.Join(methods, ", "))
+}
+
+// Type returns the type of an Object.
+func (p *Protocol) Type() Type {
+	return ProtocolType
+}
+
+// Equals checks whether or not two objects are equal to each other. A protocol
+// is only equal to itself.
+func (p *Protocol) Equals(other Object) bool {
+	o, ok := other.(*Protocol)
+	return ok && p == o
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (p *Protocol) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{p}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (p *Protocol) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{
+			Value: []Object{p, right},
+		}, true
+	}
+
+	return nil, false
+}
+
+// Implements checks whether or not o implements the protocol. If it doesn't, the
+// first method which it's missing is returned too.
+func (p *Protocol) Implements(o Object) (bool, ProtocolMethod) {
+	for _, method := range p.Methods {
+		val, ok := o.Subscript(&String{Value: method.Name})
+		if !ok || !accepts(val, method.Arity) {
+			return false, method
+		}
+	}
+
+	return true, ProtocolMethod{}
+}
+
+// accepts checks whether fn can be called with n arguments, or can be called at
+// all if n is negative.
+func accepts(fn Object, n int) bool {
+	switch f := fn.(type) {
+	case *Function:
+		return n < 0 || (n >= f.Arity() && (n <= len(f.Parameters) || f.Rest != ""))
+
+	case *Model:
+		return accepts(f.Init, n)
+
+	case *Builtin:
+		return n < 0 || f.Arity == 0 || f.Arity == n
+
+	case *Partial:
+		return n < 0 || n == f.Remaining()
+	}
+
+	return false
+}
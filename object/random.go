@@ -0,0 +1,121 @@
+package object
+
+import (
+	"fmt"
+)
+
+// randomItems returns the items of o, which is the argument to the random builtin
+// called name. If o doesn't have items, the error type and message are returned.
+func randomItems(name string, o Object) ([]Object, string, string) {
+	items, ok := o.Items()
+	if !ok {
+		return nil, "Type", fmt.Sprintf("random.%s(...) expects a collection, not %s", name, o.Type())
+	}
+
+	return items, "", ""
+}
+
+func init() {
+	defineModule("random", map[string]Object{
+		"seed": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to random.seed(...)"
+				}
+
+				seed, ok := args[0].(*Integer)
+				if !ok || seed.Big != nil {
+					return nil, "Type", fmt.Sprintf("the seed should be an integer, not %s", args[0])
+				}
+
+				ctx.Random.Seed(seed.Value)
+
+				return &Nil{}, "", ""
+			},
+		},
+
+		"rand": &Builtin{
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 0 {
+					return nil, "Argument", "random.rand(...) doesn't take any arguments"
+				}
+
+				return &Number{Value: ctx.Random.Float64()}, "", ""
+			},
+		},
+
+		"choice": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to random.choice(...)"
+				}
+
+				items, errType, errMsg := randomItems("choice", args[0])
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				if len(items) == 0 {
+					return nil, "Argument", "cannot choose an item from an empty collection"
+				}
+
+				return items[ctx.Random.Intn(len(items))], "", ""
+			},
+		},
+
+		"shuffle": &Builtin{
+			Arity: 1,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to random.shuffle(...)"
+				}
+
+				items, errType, errMsg := randomItems("shuffle", args[0])
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				shuffled := append([]Object(nil), items...)
+
+				ctx.Random.Shuffle(len(shuffled), func(i, j int) {
+					shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+				})
+
+				return &List{Value: shuffled}, "", ""
+			},
+		},
+
+		"sample": &Builtin{
+			Arity: 2,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 2 {
+					return nil, "Argument", "expected exactly two arguments to random.sample(...)"
+				}
+
+				k, ok := ToInt(args[0])
+				if !ok || k < 0 {
+					return nil, "Type", "the first argument to random.sample(...) should be a non-negative integer"
+				}
+
+				items, errType, errMsg := randomItems("sample", args[1])
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				if k > len(items) {
+					return nil, "Argument", fmt.Sprintf("cannot take a sample of %d items from a collection of %d", k, len(items))
+				}
+
+				sample := make([]Object, k)
+
+				for i, index := range ctx.Random.Perm(len(items))[:k] {
+					sample[i] = items[index]
+				}
+
+				return &List{Value: sample}, "", ""
+			},
+		},
+	})
+}
@@ -0,0 +1,313 @@
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// A Regex is a compiled regular expression, using Go's regexp syntax. Regexes
+// are compiled by re.compile, and any of the functions in the re module which take
+// a pattern can be given either a Regex or a string. Two regexes with the same
+// pattern are equal, so they can be used as map keys.
+type Regex struct {
+	defaults
+	Pattern *regexp.Regexp
+}
+
+func (r *Regex) String() string {
+	return fmt.Sprintf("<regex `%s`>", r.Pattern)
+}
+
+// Type returns the type of an Object.
+func (r *Regex) Type() Type {
+	return RegexType
+}
+
+// Equals checks whether or not two objects are equal to each other.
+func (r *Regex) Equals(o Object) bool {
+	other, ok := o.(*Regex)
+	return ok && other.Pattern.String() == r.Pattern.String()
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (r *Regex) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{r}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (r *Regex) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{r, right}}, true
+	}
+
+	return nil, false
+}
+
+// Hash hashes the regex's pattern.
+func (r *Regex) Hash() (uint64, bool) {
+	return hashString(hashType(RegexType), r.Pattern.String()), true
+}
+
+// maxCachedRegexes is the number of compiled regexes kept by CompileRegex. When
+// the cache is full, it's emptied.
+const maxCachedRegexes = 256
+
+var (
+	regexCache = make(map[string]*Regex)
+	regexMutex sync.Mutex
+)
+
+// CompileRegex compiles pattern, reusing the Regex from an earlier compilation of
+// the same pattern if it's still cached.
+func CompileRegex(pattern string) (*Regex, error) {
+	regexMutex.Lock()
+	defer regexMutex.Unlock()
+
+	if r, ok := regexCache[pattern]; ok {
+		return r, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(regexCache) >= maxCachedRegexes {
+		regexCache = make(map[string]*Regex)
+	}
+
+	r := &Regex{Pattern: compiled}
+	regexCache[pattern] = r
+
+	return r, nil
+}
+
+// toRegex converts o, which should be a Regex or a pattern, to a Regex. If it
+// can't, the error type and message are returned.
+func toRegex(o Object) (*Regex, string, string) {
+	switch p := o.(type) {
+	case *Regex:
+		return p, "", ""
+
+	case *String:
+		r, err := CompileRegex(p.Value)
+		if err != nil {
+			return nil, "Argument", fmt.Sprintf("invalid regular expression: %s", err)
+		}
+
+		return r, "", ""
+	}
+
+	return nil, "Type", fmt.Sprintf("expected a regex or a pattern string, not %s", o.Type())
+}
+
+// regexArgs checks the arguments to the re builtin called name, which should be a
+// regex or pattern followed by n-1 other arguments, the last of which is the string
+// to search.
+func regexArgs(name string, n int, args []Object) (*Regex, string, string, string) {
+	if len(args) != n {
+		return nil, "", "Argument", fmt.Sprintf("expected exactly %d argument(s) to re.%s(...)", n, name)
+	}
+
+	r, errType, errMsg := toRegex(args[0])
+	if errType != "" {
+		return nil, "", errType, errMsg
+	}
+
+	str, ok := args[n-1].(*String)
+	if !ok {
+		return nil, "", "Type", fmt.Sprintf("the last argument to re.%s(...) should be a string, not %s", name, args[n-1].Type())
+	}
+
+	return r, str.Value, "", ""
+}
+
+// match makes the object representing a match of r in s, whose submatch indices
+// are loc. If r has no groups, it's the matched string, otherwise it's a tuple of
+// the matched string followed by each group, which is nil if it didn't match.
+func (r *Regex) match(s string, loc []int) Object {
+	if r.Pattern.NumSubexp() == 0 {
+		return &String{Value: s[loc[0]:loc[1]]}
+	}
+
+	items := make([]Object, len(loc)/2)
+
+	for i := range items {
+		if loc[i*2] < 0 {
+			items[i] = &Nil{}
+		} else {
+			items[i] = &String{Value: s[loc[i*2]:loc[i*2+1]]}
+		}
+	}
+
+	return &Tuple{Value: items}
+}
+
+func init() {
+	defineModule("re", map[string]Object{
+		"compile": &Builtin{
+			Arity: 1,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				if len(args) != 1 {
+					return nil, "Argument", "expected exactly one argument to re.compile(...)"
+				}
+
+				if _, ok := args[0].(*String); !ok {
+					return nil, "Type", fmt.Sprintf("the argument to re.compile(...) should be a string, not %s", args[0].Type())
+				}
+
+				r, errType, errMsg := toRegex(args[0])
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return r, "", ""
+			},
+		},
+
+		"matches?": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("matches?", 2, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				return &Boolean{Value: r.Pattern.MatchString(s)}, "", ""
+			},
+		},
+
+		"find": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("find", 2, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				loc := r.Pattern.FindStringSubmatchIndex(s)
+				if loc == nil {
+					return &Nil{}, "", ""
+				}
+
+				return r.match(s, loc), "", ""
+			},
+		},
+
+		"captures": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("captures", 2, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				loc := r.Pattern.FindStringSubmatchIndex(s)
+				if loc == nil {
+					return &Nil{}, "", ""
+				}
+
+				captures := NewMap(0)
+
+				for i, name := range r.Pattern.SubexpNames() {
+					if name == "" {
+						continue
+					}
+
+					var val Object = &Nil{}
+					if loc[i*2] >= 0 {
+						val = &String{Value: s[loc[i*2]:loc[i*2+1]]}
+					}
+
+					captures.Set(&String{Value: name}, val)
+				}
+
+				return captures, "", ""
+			},
+		},
+
+		"find-all": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("find-all", 2, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				var matches []Object
+
+				for _, loc := range r.Pattern.FindAllStringSubmatchIndex(s, -1) {
+					matches = append(matches, r.match(s, loc))
+				}
+
+				return &List{Value: matches}, "", ""
+			},
+		},
+
+		"replace": &Builtin{
+			Arity: 3,
+			Contextual: func(ctx *Context, args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("replace", 3, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				if repl, ok := args[1].(*String); ok {
+					return &String{Value: r.Pattern.ReplaceAllString(s, repl.Value)}, "", ""
+				}
+
+				var (
+					replaced []byte
+					last     int
+				)
+
+				for _, loc := range r.Pattern.FindAllStringSubmatchIndex(s, -1) {
+					val, errType, errMsg := ctx.Call(args[1], r.match(s, loc))
+					if errType != "" {
+						return nil, errType, errMsg
+					}
+
+					replaced = append(replaced, s[last:loc[0]]...)
+
+					if str, ok := val.(*String); ok {
+						replaced = append(replaced, str.Value...)
+					} else {
+						replaced = append(replaced, val.String()...)
+					}
+
+					last = loc[1]
+				}
+
+				replaced = append(replaced, s[last:]...)
+
+				return &String{Value: string(replaced)}, "", ""
+			},
+		},
+
+		"split": &Builtin{
+			Arity: 2,
+			Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+				r, s, errType, errMsg := regexArgs("split", 2, args)
+				if errType != "" {
+					return nil, errType, errMsg
+				}
+
+				parts := r.Pattern.Split(s, -1)
+				items := make([]Object, len(parts))
+
+				for i, part := range parts {
+					items[i] = &String{Value: part}
+				}
+
+				return &List{Value: items}, "", ""
+			},
+		},
+	})
+}
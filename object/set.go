@@ -0,0 +1,171 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Set is a collection of unique hashable items. Like the keys of a Map, which it
+// stores its items as, the items are kept in the order they were added, but the
+// order doesn't affect equality. Sets can't be modified, so the set operators
+// always make a new set.
+type Set struct {
+	defaults
+	items *Map
+}
+
+// NewSet makes a new empty Set, with space for size items.
+func NewSet(size int) *Set {
+	return &Set{
+		items: NewMap(size),
+	}
+}
+
+// Add adds an item to the set, returning false if it isn't hashable. Since sets are
+// immutable, Add should only be used to build new sets.
+func (s *Set) Add(item Object) bool {
+	return s.items.Set(item, item)
+}
+
+// Contains checks whether item is in the set.
+func (s *Set) Contains(item Object) bool {
+	_, ok := s.items.Get(item)
+	return ok
+}
+
+// Len returns the number of items in the set.
+func (s *Set) Len() int {
+	return s.items.Len()
+}
+
+func (s *Set) String() string {
+	items := s.items.Keys()
+	strs := make([]string, len(items))
+
+	for i, item := range items {
+		strs[i] = item.String()
+	}
+
+	return fmt.Sprintf("set [%s]", strings.Join(strs, ", "))
+}
+
+// Type returns the type of an Object.
+func (s *Set) Type() Type {
+	return SetType
+}
+
+// Equals checks whether or not two objects are equal to each other. Two sets are
+// equal if they contain the same items, in any order.
+func (s *Set) Equals(o Object) bool {
+	other, ok := o.(*Set)
+	if !ok || other.Len() != s.Len() {
+		return false
+	}
+
+	return s.subsetOf(other)
+}
+
+// subsetOf checks whether every item in s is also in other.
+func (s *Set) subsetOf(other *Set) bool {
+	for _, item := range s.items.Keys() {
+		if !other.Contains(item) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (s *Set) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned. The set operators are | (union),
+// & (intersection), - (difference) and ^ (symmetric difference).
+func (s *Set) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s, right}}, true
+	}
+
+	other, ok := right.(*Set)
+	if !ok {
+		return nil, false
+	}
+
+	var (
+		result = NewSet(s.Len())
+		left   = s.items.Keys()
+		rights = other.items.Keys()
+	)
+
+	switch op {
+	case "|":
+		for _, item := range append(left, rights...) {
+			result.Add(item)
+		}
+
+	case "&":
+		for _, item := range left {
+			if other.Contains(item) {
+				result.Add(item)
+			}
+		}
+
+	case "-":
+		for _, item := range left {
+			if !other.Contains(item) {
+				result.Add(item)
+			}
+		}
+
+	case "^":
+		for _, item := range left {
+			if !other.Contains(item) {
+				result.Add(item)
+			}
+		}
+
+		for _, item := range rights {
+			if !s.Contains(item) {
+				result.Add(item)
+			}
+		}
+
+	default:
+		return nil, false
+	}
+
+	return result, true
+}
+
+// Items returns a slice containing all objects in an Object, or false otherwise.
+func (s *Set) Items() ([]Object, bool) {
+	return s.items.Keys(), true
+}
+
+// Iter creates an iterable from an Object.
+func (s *Set) Iter() (Iterable, bool) {
+	return &ListIterable{
+		List:  &List{Value: s.items.Keys()},
+		Index: 0,
+	}, true
+}
+
+// Hash hashes the set. Its items are all hashable, and their order doesn't affect
+// the hash, since it doesn't affect equality.
+func (s *Set) Hash() (uint64, bool) {
+	sum := hashType(SetType)
+
+	for _, e := range s.items.entries {
+		sum += e.hash
+	}
+
+	return sum, true
+}
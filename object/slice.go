@@ -0,0 +1,346 @@
+package object
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+// A Slice selects part of a sequence, as in xs[1:3], xs[:-1] or xs[::2]. Start, Stop
+// and Step are each an integral object, or Nil if they were left out. Like in Python,
+// negative bounds count from the end of the sequence, and the step can be negative
+// to select items in reverse.
+type Slice struct {
+	defaults
+	Start, Stop, Step Object
+}
+
+// NewSlice makes a new Slice, returning false if any of start, stop or step isn't
+// an integer or nil.
+func NewSlice(start, stop, step Object) (*Slice, bool) {
+	for _, part := range []Object{start, stop, step} {
+		if !IsSliceBound(part) {
+			return nil, false
+		}
+	}
+
+	return &Slice{Start: start, Stop: stop, Step: step}, true
+}
+
+// IsSliceBound checks whether o can be the start, stop or step of a slice, which
+// it can if it's nil or integral. Unlike an index, a bound can be any size.
+func IsSliceBound(o Object) bool {
+	if _, isNil := o.(*Nil); isNil {
+		return true
+	}
+
+	_, ok := boundInt(o)
+	return ok
+}
+
+// boundInt converts a bound of a slice to an int. Integers too big for an int are
+// clamped, since any bound past the end of a sequence selects up to the end, but
+// non-integral numbers aren't allowed.
+func boundInt(o Object) (int, bool) {
+	switch n := o.(type) {
+	case *Integer:
+		if i, ok := n.Int(); ok {
+			return i, true
+		}
+
+		if n.big().Sign() < 0 {
+			return minInt, true
+		}
+
+		return maxInt, true
+
+	case *Number:
+		if n.Value != math.Trunc(n.Value) {
+			return 0, false
+		}
+
+		if n.Value <= math.MinInt64 {
+			return minInt, true
+		}
+
+		if n.Value >= math.MaxInt64 {
+			return maxInt, true
+		}
+
+		return int(n.Value), true
+	}
+
+	return 0, false
+}
+
+func (s *Slice) String() string {
+	parts := []string{sliceBound(s.Start), sliceBound(s.Stop)}
+
+	if _, isNil := s.Step.(*Nil); !isNil {
+		parts = append(parts, sliceBound(s.Step))
+	}
+
+	return strings.Join(parts, ":")
+}
+
+// sliceBound formats a part of a slice, which is left empty if it's nil.
+func sliceBound(o Object) string {
+	if _, isNil := o.(*Nil); isNil {
+		return ""
+	}
+
+	return o.String()
+}
+
+// Type returns the type of an Object.
+func (s *Slice) Type() Type {
+	return SliceType
+}
+
+// Equals checks whether or not two objects are equal to each other.
+func (s *Slice) Equals(o Object) bool {
+	other, ok := o.(*Slice)
+
+	return ok &&
+		s.Start.Equals(other.Start) &&
+		s.Stop.Equals(other.Stop) &&
+		s.Step.Equals(other.Step)
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (s *Slice) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (s *Slice) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s, right}}, true
+	}
+
+	return nil, false
+}
+
+// Indices works out which indices the slice selects in a sequence of the given
+// length. The selected indices go from start towards stop, not including stop, in
+// increments of step. It returns false if the step is zero.
+func (s *Slice) Indices(length int) (start, stop, step int, ok bool) {
+	step = 1
+	if n, ok := boundInt(s.Step); ok {
+		step = n
+	}
+
+	if step == 0 {
+		return 0, 0, 0, false
+	}
+
+	// A step longer than the sequence selects at most one item, so it's shortened
+	// to stop the indices overflowing.
+	if limit := length + 2; step > limit {
+		step = limit
+	} else if step < -limit {
+		step = -limit
+	}
+
+	// When stepping backwards, the stop can be -1, which is before the first item
+	// rather than counting from the end.
+	lower, upper := 0, length
+	if step < 0 {
+		lower, upper = -1, length-1
+	}
+
+	clamp := func(bound Object, def int) int {
+		n, ok := boundInt(bound)
+		if !ok {
+			return def
+		}
+
+		if n < 0 {
+			n += length
+		}
+
+		if n < lower {
+			return lower
+		}
+
+		if n > upper {
+			return upper
+		}
+
+		return n
+	}
+
+	if step > 0 {
+		return clamp(s.Start, lower), clamp(s.Stop, upper), step, true
+	}
+
+	return clamp(s.Start, upper), clamp(s.Stop, lower), step, true
+}
+
+// Select returns the items the slice selects from items, or false if the step is
+// zero.
+func (s *Slice) Select(items []Object) ([]Object, bool) {
+	indices, ok := s.indexList(len(items))
+	if !ok {
+		return nil, false
+	}
+
+	selected := make([]Object, len(indices))
+
+	for i, index := range indices {
+		selected[i] = items[index]
+	}
+
+	return selected, true
+}
+
+// Count returns how many items the slice selects in a sequence of the given length,
+// or false if the step is zero.
+func (s *Slice) Count(length int) (int, bool) {
+	indices, ok := s.indexList(length)
+	return len(indices), ok
+}
+
+// indexList returns each index the slice selects in a sequence of the given
+// length, in order.
+func (s *Slice) indexList(length int) ([]int, bool) {
+	start, stop, step, ok := s.Indices(length)
+	if !ok {
+		return nil, false
+	}
+
+	var indices []int
+
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		indices = append(indices, i)
+	}
+
+	return indices, true
+}
+
+// normaliseIndex converts index to an index into a sequence of the given length,
+// counting from the end if it's negative. It returns false if index isn't an
+// integer, or if it's out of bounds.
+func normaliseIndex(index Object, length int) (int, bool) {
+	i, ok := ToInt(index)
+	if !ok {
+		return 0, false
+	}
+
+	if i < 0 {
+		i += length
+	}
+
+	if i < 0 || i >= length {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// subscriptSequence subscripts a sequence of items, with either an index or a slice.
+// A slice gives a new sequence, which is made by wrap.
+func subscriptSequence(items []Object, index Object, wrap func([]Object) Object) (Object, bool) {
+	if slice, ok := index.(*Slice); ok {
+		selected, ok := slice.Select(items)
+		if !ok {
+			return nil, false
+		}
+
+		return wrap(selected), true
+	}
+
+	i, ok := normaliseIndex(index, len(items))
+	if !ok {
+		return nil, false
+	}
+
+	return items[i], true
+}
+
+// Subscript subscrips an Object, e.g. foo[bar], or returns false if it can't be
+// done. Lists can be subscripted with an index, which is negative to count from the
+// end, or a slice, which gives a new list.
+func (l *List) Subscript(index Object) (Object, bool) {
+	return subscriptSequence(l.Value, index, func(items []Object) Object {
+		return &List{Value: items}
+	})
+}
+
+// Subscript subscrips an Object, e.g. foo[bar], or returns false if it can't be
+// done. Tuples can be subscripted with an index or a slice, like lists.
+func (t *Tuple) Subscript(index Object) (Object, bool) {
+	return subscriptSequence(t.Value, index, func(items []Object) Object {
+		return &Tuple{Value: items}
+	})
+}
+
+// Subscript subscrips an Object, e.g. foo[bar], or returns false if it can't be
+// done. Strings can be subscripted with an index, giving a single character, or a
+// slice, which gives a new string.
+func (s *String) Subscript(index Object) (Object, bool) {
+	items, _ := s.Items()
+
+	return subscriptSequence(items, index, func(chars []Object) Object {
+		var b strings.Builder
+
+		for _, c := range chars {
+			b.WriteString(c.(*String).Value)
+		}
+
+		return &String{Value: b.String()}
+	})
+}
+
+// setSlice replaces the items selected by slice with the items of to. If the slice
+// has a step of one, the number of items can change, otherwise to must have the
+// same number of items as the slice selects.
+func (l *List) setSlice(slice *Slice, to Object) bool {
+	items, ok := to.Items()
+	if !ok {
+		return false
+	}
+
+	items = append([]Object(nil), items...)
+
+	start, stop, step, ok := slice.Indices(len(l.Value))
+	if !ok {
+		return false
+	}
+
+	if step == 1 {
+		if stop < start {
+			stop = start
+		}
+
+		value := make([]Object, 0, len(l.Value)-(stop-start)+len(items))
+		value = append(value, l.Value[:start]...)
+		value = append(value, items...)
+		value = append(value, l.Value[stop:]...)
+
+		l.Value = value
+
+		return true
+	}
+
+	indices, _ := slice.indexList(len(l.Value))
+	if len(indices) != len(items) {
+		return false
+	}
+
+	for i, index := range indices {
+		l.Value[index] = items[i]
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package object
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Stream is a source of text which is read incrementally, such as stdin.
+// Iterating over a stream gives each of its lines, which are read as they're
+// needed rather than all at once.
+type Stream struct {
+	defaults
+	Name   string
+	Reader *bufio.Reader
+}
+
+// NewStream makes a Stream called name, which reads from r.
+func NewStream(name string, r io.Reader) *Stream {
+	return &Stream{
+		Name:   name,
+		Reader: bufio.NewReader(r),
+	}
+}
+
+func (s *Stream) String() string {
+	return fmt.Sprintf("<stream %s>", s.Name)
+}
+
+// Type returns the type of an Object.
+func (s *Stream) Type() Type {
+	return StreamType
+}
+
+// Equals checks whether or not two objects are equal to each other. A stream is
+// only equal to itself.
+func (s *Stream) Equals(o Object) bool {
+	return s == o
+}
+
+// Prefix applies a prefix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (s *Stream) Prefix(op string) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s}}, true
+	}
+
+	return nil, false
+}
+
+// Infix applies a infix operator to an object, returning the result. If the operation
+// cannot be performed, (nil, false) is returned.
+func (s *Stream) Infix(op string, right Object) (Object, bool) {
+	if op == "," {
+		return &Tuple{Value: []Object{s, right}}, true
+	}
+
+	return nil, false
+}
+
+// Iter turns an object into an iterable.
+func (s *Stream) Iter() (Iterable, bool) {
+	return &LineIterable{Reader: s.Reader}, true
+}
+
+// ReadLine reads the next line from the stream, without its line ending. It
+// returns false if the stream has ended, or can't be read.
+func (s *Stream) ReadLine() (string, bool) {
+	return readLine(s.Reader)
+}
+
+// readLine reads a line from r, without its line ending.
+func readLine(r *bufio.Reader) (string, bool) {
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", false
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), true
+}
+
+// A LineIterable is an iterable which reads each line from a reader, as it's
+// needed.
+type LineIterable struct {
+	defaults
+	Reader *bufio.Reader
+}
+
+func (i *LineIterable) String() string {
+	return "<iterable>"
+}
+
+// Type returns the type of an Object.
+func (i *LineIterable) Type() Type {
+	return IterType
+}
+
+// Equals checks whether or not two objects are equal to each other.
+func (i *LineIterable) Equals(o Object) bool {
+	return i == o
+}
+
+// Next returns the next object from the iterable. If false is returned
+// as the second return value, the iterable has finished.
+func (i *LineIterable) Next() (Object, bool) {
+	line, ok := readLine(i.Reader)
+	if !ok {
+		return nil, false
+	}
+
+	return &String{Value: line}, true
+}
+
+// Iter turns an object into an iterable.
+func (i *LineIterable) Iter() (Iterable, bool) {
+	return i, true
+}
+
+func init() {
+	Builtins["lines"] = &Builtin{
+		Name:  "lines",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to lines(...)"
+			}
+
+			switch s := args[0].(type) {
+			case *Stream:
+				iter, _ := s.Iter()
+				return iter, "", ""
+
+			case *String:
+				if s.Value == "" {
+					return &List{Value: []Object{}}, "", ""
+				}
+
+				parts := strings.Split(strings.TrimSuffix(s.Value, "\n"), "\n")
+				items := make([]Object, len(parts))
+
+				for i, part := range parts {
+					items[i] = &String{Value: strings.TrimSuffix(part, "\r")}
+				}
+
+				return &List{Value: items}, "", ""
+			}
+
+			return nil, "Type", fmt.Sprintf("cannot split a value of type %s into lines", args[0].Type())
+		},
+	}
+}
@@ -0,0 +1,440 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxStringLength is the length, in bytes, of the longest string which repeat and pad
+// will make, or json.stringify will indent by, so that a huge count or width is an
+// error rather than exhausting memory.
+const maxStringLength = 1 << 30
+
+// The string builtins all take the string they operate on as their last argument,
+// so they can be partially applied and used in pipelines, e.g.
+// `line |> split ","`. Every index and length is measured in runes, not bytes.
+func init() {
+	Builtins["split"] = &Builtin{
+		Name:  "split",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("split", 2, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			parts := strings.Split(strs[1], strs[0])
+			items := make([]Object, len(parts))
+
+			for i, part := range parts {
+				items[i] = &String{Value: part}
+			}
+
+			return &List{Value: items}, "", ""
+		},
+	}
+
+	Builtins["join"] = &Builtin{
+		Name:  "join",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 2 {
+				return nil, "Argument", "expected exactly two arguments to join(...)"
+			}
+
+			sep, ok := args[0].(*String)
+			if !ok {
+				return nil, "Type", "the first argument to join(...) should be a string"
+			}
+
+			items, ok := args[1].Items()
+			if !ok {
+				return nil, "Type", fmt.Sprintf("cannot join the items of a %s", args[1].Type())
+			}
+
+			strs := make([]string, len(items))
+
+			for i, item := range items {
+				str, ok := item.(*String)
+				if !ok {
+					return nil, "Type", fmt.Sprintf("join(...) can only join strings, not values of type %s", item.Type())
+				}
+
+				strs[i] = str.Value
+			}
+
+			return &String{Value: strings.Join(strs, sep.Value)}, "", ""
+		},
+	}
+
+	Builtins["replace"] = &Builtin{
+		Name:  "replace",
+		Arity: 3,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("replace", 3, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			return &String{Value: strings.Replace(strs[2], strs[0], strs[1], -1)}, "", ""
+		},
+	}
+
+	stringFunction("trim", strings.TrimSpace)
+	stringFunction("trim-left", func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) })
+	stringFunction("trim-right", func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) })
+	stringFunction("upper", strings.ToUpper)
+	stringFunction("lower", strings.ToLower)
+
+	Builtins["starts?"] = &Builtin{
+		Name:  "starts?",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("starts?", 2, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			return &Boolean{Value: strings.HasPrefix(strs[1], strs[0])}, "", ""
+		},
+	}
+
+	Builtins["ends?"] = &Builtin{
+		Name:  "ends?",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("ends?", 2, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			return &Boolean{Value: strings.HasSuffix(strs[1], strs[0])}, "", ""
+		},
+	}
+
+	Builtins["find"] = &Builtin{
+		Name:  "find",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("find", 2, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			index := strings.Index(strs[1], strs[0])
+			if index >= 0 {
+				index = utf8.RuneCountInString(strs[1][:index])
+			}
+
+			return &Integer{Value: int64(index)}, "", ""
+		},
+	}
+
+	Builtins["repeat"] = &Builtin{
+		Name:  "repeat",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 2 {
+				return nil, "Argument", "expected exactly two arguments to repeat(...)"
+			}
+
+			count, ok := ToInt(args[0])
+			if !ok || count < 0 {
+				return nil, "Type", "the first argument to repeat(...) should be a non-negative integer"
+			}
+
+			str, ok := args[1].(*String)
+			if !ok {
+				return nil, "Type", "the second argument to repeat(...) should be a string"
+			}
+
+			if len(str.Value) > 0 && count > maxStringLength/len(str.Value) {
+				return nil, "Argument", fmt.Sprintf("repeating a string %d times would make it too long", count)
+			}
+
+			return &String{Value: strings.Repeat(str.Value, count)}, "", ""
+		},
+	}
+
+	Builtins["pad"] = &Builtin{
+		Name:  "pad",
+		Arity: 2,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 2 {
+				return nil, "Argument", "expected exactly two arguments to pad(...)"
+			}
+
+			width, ok := ToInt(args[0])
+			if !ok {
+				return nil, "Type", "the first argument to pad(...) should be an integer"
+			}
+
+			str, ok := args[1].(*String)
+			if !ok {
+				return nil, "Type", "the second argument to pad(...) should be a string"
+			}
+
+			if width > maxStringLength || width < -maxStringLength {
+				return nil, "Argument", fmt.Sprintf("cannot pad a string to a width of %d", width)
+			}
+
+			return &String{Value: pad(str.Value, width)}, "", ""
+		},
+	}
+
+	Builtins["chars"] = &Builtin{
+		Name:  "chars",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("chars", 1, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			chars, _ := (&String{Value: strs[0]}).Items()
+
+			return &List{Value: chars}, "", ""
+		},
+	}
+
+	Builtins["bytes"] = &Builtin{
+		Name:  "bytes",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("bytes", 1, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			items := make([]Object, len(strs[0]))
+
+			for i := 0; i < len(strs[0]); i++ {
+				items[i] = &Integer{Value: int64(strs[0][i])}
+			}
+
+			return &List{Value: items}, "", ""
+		},
+	}
+
+	Builtins["ord"] = &Builtin{
+		Name:  "ord",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs("ord", 1, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			if utf8.RuneCountInString(strs[0]) != 1 {
+				return nil, "Argument", fmt.Sprintf("ord(...) expects a single character, not a string of length %d", utf8.RuneCountInString(strs[0]))
+			}
+
+			r, _ := utf8.DecodeRuneInString(strs[0])
+
+			return &Integer{Value: int64(r)}, "", ""
+		},
+	}
+
+	Builtins["chr"] = &Builtin{
+		Name:  "chr",
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) != 1 {
+				return nil, "Argument", "expected exactly one argument to chr(...)"
+			}
+
+			code, ok := ToInt(args[0])
+			if !ok || code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+				return nil, "Type", fmt.Sprintf("%s isn't a valid character code", args[0])
+			}
+
+			return &String{Value: string(rune(code))}, "", ""
+		},
+	}
+
+	Builtins["format"] = &Builtin{
+		Name: "format",
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			if len(args) < 1 {
+				return nil, "Argument", "expected at least one argument to format(...)"
+			}
+
+			template, ok := args[0].(*String)
+			if !ok {
+				return nil, "Type", "the first argument to format(...) should be a string"
+			}
+
+			formatted, err := format(template.Value, args[1:])
+			if err != "" {
+				return nil, "Argument", err
+			}
+
+			return &String{Value: formatted}, "", ""
+		},
+	}
+}
+
+// stringFunction defines a builtin called name, which takes a single string and
+// returns fn applied to it.
+func stringFunction(name string, fn func(string) string) {
+	Builtins[name] = &Builtin{
+		Name:  name,
+		Arity: 1,
+		Fn: func(args ...Object) (result Object, errorType string, errorMessage string) {
+			strs, errType, errMsg := stringArgs(name, 1, args)
+			if errType != "" {
+				return nil, errType, errMsg
+			}
+
+			return &String{Value: fn(strs[0])}, "", ""
+		},
+	}
+}
+
+// stringArgs checks that exactly n arguments, which are all strings, were passed
+// to the builtin called name, and returns their values. If they weren't, the error
+// type and message are returned instead.
+func stringArgs(name string, n int, args []Object) ([]string, string, string) {
+	if len(args) != n {
+		return nil, "Argument", fmt.Sprintf("expected exactly %d argument(s) to %s(...)", n, name)
+	}
+
+	strs := make([]string, n)
+
+	for i, arg := range args {
+		str, ok := arg.(*String)
+		if !ok {
+			return nil, "Type", fmt.Sprintf("the arguments to %s(...) should be strings, not %s", name, arg.Type())
+		}
+
+		strs[i] = str.Value
+	}
+
+	return strs, "", ""
+}
+
+// pad pads s with spaces to width characters. A positive width adds the spaces on
+// the left, and a negative width adds them on the right, like %5s and %-5s in
+// printf. Strings which are already wide enough are returned unchanged.
+func pad(s string, width int) string {
+	left := width > 0
+	if !left {
+		width = -width
+	}
+
+	padding := width - utf8.RuneCountInString(s)
+	if padding <= 0 {
+		return s
+	}
+
+	if left {
+		return strings.Repeat(" ", padding) + s
+	}
+
+	return s + strings.Repeat(" ", padding)
+}
+
+// format replaces the placeholders in template with args. A placeholder is either
+// $(), which is the next positional argument, $(n), which is the nth argument
+// (counting from zero), or $(name), which is looked up in the map passed as the
+// last argument. $$ is a literal $. Placeholders don't use braces, so that a format
+// string can be written with double quotes without being interpolated. If the
+// template is invalid, an error message is returned.
+func format(template string, args []Object) (string, string) {
+	var (
+		b    strings.Builder
+		next = 0
+	)
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+
+		if c != '$' || i+1 == len(template) || template[i+1] != '$' && template[i+1] != '(' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if template[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		end := strings.IndexByte(template[i:], ')')
+		if end < 0 {
+			return "", "unterminated placeholder in the format string"
+		}
+
+		name := template[i+2 : i+end]
+		i += end
+
+		val, err := placeholder(name, args, &next)
+		if err != "" {
+			return "", err
+		}
+
+		if str, ok := val.(*String); ok {
+			b.WriteString(str.Value)
+		} else {
+			b.WriteString(val.String())
+		}
+	}
+
+	return b.String(), ""
+}
+
+// placeholder finds the value of the placeholder $(name). next is the index of the
+// next positional argument, which is advanced if name is empty.
+func placeholder(name string, args []Object, next *int) (Object, string) {
+	if name == "" {
+		if *next >= len(args) {
+			return nil, fmt.Sprintf("the format string has more placeholders than the %d argument(s) given", len(args))
+		}
+
+		*next++
+
+		return args[*next-1], ""
+	}
+
+	if index, ok := parseIndex(name); ok {
+		if index >= len(args) {
+			return nil, fmt.Sprintf("the format string refers to argument %d, but only %d were given", index, len(args))
+		}
+
+		return args[index], ""
+	}
+
+	if len(args) > 0 {
+		if m, ok := args[len(args)-1].(*Map); ok {
+			if val, ok := m.Get(&String{Value: name}); ok {
+				return val, ""
+			}
+		}
+	}
+
+	return nil, fmt.Sprintf("no value was given for the placeholder $(%s)", name)
+}
+
+// parseIndex parses a non-negative decimal integer, returning false if s isn't one.
+// Indices too big to fit in an int are clamped to the largest int, since they're
+// out of range of any argument list anyway.
+func parseIndex(s string) (int, bool) {
+	n := 0
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+
+		if n > (maxInt-int(c-'0'))/10 {
+			n = maxInt
+			continue
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	return n, true
+}
@@ -7,7 +7,8 @@ import (
 // A String is a UTF-8 encoded Unicode string.
 type String struct {
 	defaults
-	Value string
+	Value  string
+	Frozen bool
 }
 
 func (s *String) String() string {
@@ -99,13 +100,14 @@ func (s *String) Iter() (Iterable, bool) {
 // SetSubscript sets the value of a subscript of an Object, e.g. foo[bar] = baz.
 // Returns false if it can't be done.
 func (s *String) SetSubscript(index Object, to Object) bool {
-	num, ok := index.(*Number)
-	if !ok {
+	if s.Frozen {
 		return false
 	}
 
-	i := int(num.Value)
-	if i < 0 || i >= len(s.Value) {
+	runes := []rune(s.Value)
+
+	i, ok := normaliseIndex(index, len(runes))
+	if !ok {
 		return false
 	}
 
@@ -119,14 +121,7 @@ func (s *String) SetSubscript(index Object, to Object) bool {
 		return false
 	}
 
-	toRune := toRunes[0]
-
-	runes := []rune(s.Value)
-	if i < 0 || i >= len(runes) {
-		return false
-	}
-
-	runes[i] = toRune
+	runes[i] = toRunes[0]
 	s.Value = string(runes)
 
 	return true
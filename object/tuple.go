@@ -8,7 +8,8 @@ import (
 // A Tuple is an statically-sized collection of items.
 type Tuple struct {
 	defaults
-	Value []Object
+	Value  []Object
+	Frozen bool
 }
 
 func (t *Tuple) String() string {
@@ -75,16 +76,19 @@ func (t *Tuple) Items() ([]Object, bool) {
 	return t.Value, true
 }
 
+// Iter creates an iterable from an Object.
+func (t *Tuple) Iter() (Iterable, bool) {
+	return &ListIterable{
+		List:  &List{Value: t.Value},
+		Index: 0,
+	}, true
+}
+
 // SetSubscript sets the value of a subscript of an Object, e.g. foo[bar] = baz.
 // Returns false if it can't be done.
 func (t *Tuple) SetSubscript(index Object, to Object) bool {
-	num, ok := index.(*Number)
-	if !ok {
-		return false
-	}
-
-	i := int(num.Value)
-	if i < 0 || i >= len(t.Value) {
+	i, ok := normaliseIndex(index, len(t.Value))
+	if !ok || t.Frozen {
 		return false
 	}
 
@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/Zac-Garby/radon/ast"
 	"github.com/Zac-Garby/radon/token"
@@ -18,10 +20,16 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	left := nud()
 
-	if p.peekIs(argTokens...) {
+	if p.peekIs(argTokens...) && !(p.inPattern && p.peekIs(token.If)) {
 		left = p.parseFunctionCall(left)
 	}
 
+	return p.parseInfixes(left, precedence)
+}
+
+// parseInfixes parses any infix operators following left which bind tighter
+// than precedence.
+func (p *Parser) parseInfixes(left ast.Expression, precedence int) ast.Expression {
 	for !p.peekIs(token.Semi) && precedence < p.peekPrecedence() {
 		led, ok := p.leds[p.peek.Type]
 		if !ok {
@@ -42,7 +50,40 @@ func (p *Parser) parseIdentifier() ast.Expression {
 }
 
 func (p *Parser) parseNumber() ast.Expression {
-	val, _ := strconv.ParseFloat(p.cur.Literal, 64)
+	lit := p.cur.Literal
+
+	// Binary, octal and hexadecimal literals are integers. Otherwise, literals
+	// with a 'd' suffix are decimals, and ones without a fractional part or
+	// exponent are integers. The prefix is checked first, since a 'd' at the
+	// end of a hexadecimal literal is a digit. The lexer ensures the literal is
+	// well-formed, so parsing a float can only fail if it's out of range.
+	if len(lit) > 2 && strings.ContainsRune("xXbBoO", rune(lit[1])) {
+		val, _ := new(big.Int).SetString(lit, 0)
+
+		return &ast.Integer{
+			Value: val,
+		}
+	}
+
+	if strings.HasSuffix(lit, "d") {
+		return &ast.Decimal{
+			Value: strings.Replace(lit[:len(lit)-1], "_", "", -1),
+		}
+	}
+
+	if !strings.ContainsAny(lit, ".eE") {
+		val, _ := new(big.Int).SetString(strings.Replace(lit, "_", "", -1), 10)
+
+		return &ast.Integer{
+			Value: val,
+		}
+	}
+
+	val, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		p.defaultErr("number literal out of range: %s", lit)
+		return nil
+	}
 
 	return &ast.Number{
 		Value: val,
@@ -60,9 +101,36 @@ func (p *Parser) parseNil() ast.Expression {
 }
 
 func (p *Parser) parseString() ast.Expression {
-	return &ast.String{
+	str := &ast.String{
 		Value: p.cur.Literal,
 	}
+
+	if !p.peekIs(token.InterpStart) {
+		return str
+	}
+
+	node := &ast.Interpolation{
+		Parts: []ast.Expression{str},
+	}
+
+	// The lexer splits an interpolated string into a string token for each
+	// literal segment, and the tokens of each expression between them.
+	for p.peekIs(token.InterpStart) {
+		p.next()
+		p.next()
+
+		node.Parts = append(node.Parts, p.parseExpression(lowest))
+
+		if !p.expect(token.InterpEnd) || !p.expect(token.String) {
+			return nil
+		}
+
+		node.Parts = append(node.Parts, &ast.String{
+			Value: p.cur.Literal,
+		})
+	}
+
+	return node
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
@@ -76,8 +144,15 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 
 	p.next()
 
+	// A colon inside the parentheses can't end a map key or a slice bound, so
+	// keyword arguments can be passed there, as in [(f a: 1)]
+	inMapKey, inSlice := p.inMapKey, p.inSlice
+	p.inMapKey, p.inSlice = false, false
+
 	expr := p.parseExpression(lowest)
 
+	p.inMapKey, p.inSlice = inMapKey, inSlice
+
 	if !p.expect(token.RightParen) {
 		return nil
 	}
@@ -85,12 +160,75 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	return expr
 }
 
+// parseList parses a list, or a slice such as [1:3], [:-1] or [::2]. Slices are
+// parsed as lists containing just the slice, so xs[1:3] is a call to xs with the
+// argument [1:3], in the same way xs[1] is.
 func (p *Parser) parseList() ast.Expression {
+	if p.peekIs(token.RightSquare) {
+		p.next()
+		return &ast.List{}
+	}
+
+	if p.peekIs(token.Colon) {
+		p.next()
+		return p.parseSlice(nil)
+	}
+
+	p.next()
+
+	first := p.parseSliceBound()
+
+	if p.peekIs(token.Colon) {
+		p.next()
+		return p.parseSlice(first)
+	}
+
+	return &ast.List{
+		Value: p.parseExpressionListFrom(first, token.RightSquare, token.Comma),
+	}
+}
+
+// parseSlice parses the rest of a slice, from the colon after its start. start is
+// nil if it was left out.
+func (p *Parser) parseSlice(start ast.Expression) ast.Expression {
+	node := &ast.Slice{
+		Start: start,
+	}
+
+	if !p.peekIs(token.Colon) && !p.peekIs(token.RightSquare) {
+		p.next()
+		node.Stop = p.parseSliceBound()
+	}
+
+	if p.peekIs(token.Colon) {
+		p.next()
+
+		if !p.peekIs(token.RightSquare) {
+			p.next()
+			node.Step = p.parseSliceBound()
+		}
+	}
+
+	if !p.expect(token.RightSquare) {
+		return nil
+	}
+
 	return &ast.List{
-		Value: p.parseExpressionList(token.RightSquare, token.Comma),
+		Value: []ast.Expression{node},
 	}
 }
 
+// parseSliceBound parses an expression which could be followed by a colon in a
+// slice.
+func (p *Parser) parseSliceBound() ast.Expression {
+	outer := p.inSlice
+	p.inSlice = true
+	expr := p.parseExpression(join)
+	p.inSlice = outer
+
+	return expr
+}
+
 func (p *Parser) parseMap() ast.Expression {
 	return &ast.Map{
 		Value: p.parseExpressionPairs(token.RightBrace, token.Comma),
@@ -128,6 +266,30 @@ func (p *Parser) parsePrefix() ast.Expression {
 	return node
 }
 
+// parsePin parses a pinned value in a pattern, as in `^limit`, which matches an
+// object equal to the value of the expression instead of binding a name.
+func (p *Parser) parsePin() ast.Expression {
+	if !p.inPattern {
+		p.defaultErr("a pinned value (^) can only be used in a match pattern")
+		return nil
+	}
+
+	return p.parsePrefix()
+}
+
+// parseNot parses a logical not, as in `not done`. It's the same as `!done`, but
+// has a lower precedence, so `not a == b` is `not (a == b)`.
+func (p *Parser) parseNot() ast.Expression {
+	node := &ast.Prefix{
+		Operator: "!",
+	}
+
+	p.next()
+	node.Right = p.parseExpression(and)
+
+	return node
+}
+
 func (p *Parser) parseIf() ast.Expression {
 	p.next()
 
@@ -174,7 +336,18 @@ func (p *Parser) parseMatch() ast.Expression {
 		p.next()
 		p.next()
 
+		p.inPattern, p.inBranch = true, true
 		pair.Condition = p.parseExpression(lowest)
+		p.inPattern = false
+
+		if p.peekIs(token.If) {
+			p.next()
+			p.next()
+
+			pair.Guard = p.parseExpression(lowest)
+		}
+
+		p.inBranch = false
 
 		if !p.expect(token.RightArrow) {
 			return nil
@@ -195,7 +368,7 @@ func (p *Parser) parseMatch() ast.Expression {
 	hasWildcard := false
 
 	for _, branch := range node.Branches {
-		if id, ok := branch.Condition.(*ast.Identifier); ok && id.Value == "_" {
+		if id, ok := branch.Condition.(*ast.Identifier); ok && id.Value == "_" && branch.Guard == nil {
 			hasWildcard = true
 			break
 		}
@@ -228,6 +401,14 @@ func (p *Parser) parseModel() ast.Expression {
 	return node
 }
 
+func (p *Parser) parseProtocol() ast.Expression {
+	p.next()
+
+	return &ast.Protocol{
+		Methods: p.parseExpression(lowest),
+	}
+}
+
 func (p *Parser) parseInfix(left ast.Expression) ast.Expression {
 	node := &ast.Infix{
 		Operator: p.cur.Literal,
@@ -241,11 +422,221 @@ func (p *Parser) parseInfix(left ast.Expression) ast.Expression {
 	return node
 }
 
+// parseNotIn parses a `not in` operator. The not keyword can't be used anywhere
+// else, so it must be followed by in.
+func (p *Parser) parseNotIn(left ast.Expression) ast.Expression {
+	node := &ast.Infix{
+		Operator: "not in",
+		Left:     left,
+	}
+
+	if !p.expect(token.In) {
+		return nil
+	}
+
+	p.next()
+	node.Right = p.parseExpression(membership)
+
+	return node
+}
+
+// parseAssign parses an assignment or a declaration. If the left-hand side is a
+// function, as in `greet name, greeting = "hi" = body`, every `=` except the
+// last gives the parameter before it a default value, which is attached to the
+// function's parameters as `greeting = "hi"`.
+func (p *Parser) parseAssign(left ast.Expression) ast.Expression {
+	node := &ast.Infix{
+		Operator: p.cur.Literal,
+		Left:     left,
+	}
+
+	op := p.cur.Type
+	p.next()
+
+	call, ok := left.(*ast.Call)
+	if !ok {
+		node.Right = p.parseExpression(assign)
+		return node
+	}
+
+	if _, isIndex := call.Argument.(*ast.List); isIndex {
+		node.Right = p.parseExpression(assign)
+		return node
+	}
+
+	for {
+		first := p.parseExpression(join)
+		right := p.parseInfixes(first, assign)
+
+		if p.peek.Type != op && !p.peekIs(token.RightArrow) {
+			node.Right = right
+			return node
+		}
+
+		// first is a default value, and any expressions joined to it are
+		// more parameters
+		var params []ast.Expression
+
+		for right != first {
+			tup, ok := right.(*ast.Infix)
+			if !ok || tup.Operator != "," {
+				p.defaultErr("expected a comma or '%s' after a default value", node.Operator)
+				return nil
+			}
+
+			params = append([]ast.Expression{tup.Right}, params...)
+			right = tup.Left
+		}
+
+		if tup, ok := call.Argument.(*ast.Infix); ok && tup.Operator == "," {
+			tup.Right = &ast.Infix{Operator: "=", Left: tup.Right, Right: first}
+		} else {
+			call.Argument = &ast.Infix{Operator: "=", Left: call.Argument, Right: first}
+		}
+
+		for _, param := range params {
+			call.Argument = &ast.Infix{Operator: ",", Left: call.Argument, Right: param}
+		}
+
+		// The return value's annotation comes after the last default value
+		if p.peekIs(token.RightArrow) {
+			p.parseReturnAnnotation(call)
+
+			if !p.expect(op) {
+				return nil
+			}
+
+			p.next()
+			node.Right = p.parseExpression(assign)
+
+			return node
+		}
+
+		p.next()
+		p.next()
+	}
+}
+
+// parseDot parses a dot expression, such as `a.b`. If the name is followed by an
+// argument, the expression is called with it, so `a.b c` is `(a.b) c`.
+func (p *Parser) parseDot(left ast.Expression) ast.Expression {
+	node := &ast.Infix{
+		Operator: p.cur.Literal,
+		Left:     left,
+	}
+
+	if !p.expect(token.ID) {
+		return nil
+	}
+
+	node.Right = p.parseIdentifier()
+
+	if p.peekIs(argTokens...) && !(p.inPattern && p.peekIs(token.If)) {
+		return p.parseFunctionCall(node)
+	}
+
+	return node
+}
+
+// parseApply parses the `$` operator, which is right-associative, so
+// `f $ g $ x` is `f $ (g $ x)`.
+func (p *Parser) parseApply(left ast.Expression) ast.Expression {
+	node := &ast.Infix{
+		Operator: p.cur.Literal,
+		Left:     left,
+	}
+
+	p.next()
+	node.Right = p.parseExpression(apply - 1)
+
+	return node
+}
+
+// parseFunctionCall parses the argument to a function call. The argument
+// stops before a `|>` or `$`, so `xs |> map f |> g` is `(xs |> map f) |> g`.
 func (p *Parser) parseFunctionCall(left ast.Expression) ast.Expression {
 	p.next()
 
-	return &ast.Call{
+	node := &ast.Call{
 		Function: left,
-		Argument: p.parseExpression(assign),
+		Argument: p.parseExpression(pipe),
+	}
+
+	if p.peekIs(token.Colon) && !p.inMapKey && !p.inSlice {
+		p.parseKeywords(node)
+	}
+
+	if p.peekIs(token.RightArrow) && !p.inBranch {
+		p.parseReturnAnnotation(node)
+	}
+
+	return node
+}
+
+// parseReturnAnnotation parses the annotation after the `->` in a function
+// definition, as in `double x -> number = x * 2`.
+func (p *Parser) parseReturnAnnotation(node *ast.Call) {
+	p.next()
+	p.next()
+
+	node.Returns = p.parseExpression(join)
+}
+
+// parseKeywords parses the keyword arguments at the end of a function call, as
+// in `greet "bob", greeting: "hi"`. The first keyword's name has already been
+// parsed as the last element of the call's argument. In a function definition,
+// keywords are annotated parameters, which can be followed by more parameters,
+// as in `describe s: shape, verbose = body`.
+func (p *Parser) parseKeywords(node *ast.Call) {
+	var (
+		name = node.Argument
+		rest ast.Expression
+	)
+
+	if tup, ok := node.Argument.(*ast.Infix); ok && tup.Operator == "," {
+		name, rest = tup.Right, tup.Left
+	}
+
+	id, ok := name.(*ast.Identifier)
+	if !ok {
+		p.defaultErr("the name of a keyword argument must be an identifier")
+		return
+	}
+
+	p.next()
+	p.next()
+
+	node.Argument = &ast.Keyword{
+		Name:  id.Value,
+		Value: p.parseExpression(join),
+	}
+
+	if rest != nil {
+		node.Argument = &ast.Infix{Operator: ",", Left: rest, Right: node.Argument}
+	}
+
+	for p.peekIs(token.Comma) {
+		p.next()
+		p.next()
+
+		elem := p.parseExpression(join)
+
+		if p.peekIs(token.Colon) {
+			id, ok := elem.(*ast.Identifier)
+			if !ok {
+				p.defaultErr("the name of a keyword argument must be an identifier")
+				return
+			}
+
+			p.next()
+			p.next()
+
+			elem = &ast.Keyword{
+				Name:  id.Value,
+				Value: p.parseExpression(join),
+			}
+		}
+
+		node.Argument = &ast.Infix{Operator: ",", Left: node.Argument, Right: elem}
 	}
 }
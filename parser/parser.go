@@ -17,6 +17,27 @@ type Parser struct {
 	cur, peek token.Token
 	nuds      map[token.Type]nud
 	leds      map[token.Type]led
+
+	// inPattern is true while parsing the pattern of a match branch, where an
+	// `if` begins a guard instead of an argument to a function call.
+	inPattern bool
+
+	// inMapKey is true while parsing the key of a map pair, where a colon ends
+	// the key instead of beginning a keyword argument.
+	inMapKey bool
+
+	// inBranch is true while parsing the pattern or guard of a match branch,
+	// where `->` begins the branch's body instead of a return annotation.
+	inBranch bool
+
+	// inSlice is true while parsing the first element of a list, which could be
+	// the start of a slice, where a colon ends the element instead of beginning a
+	// keyword argument.
+	inSlice bool
+
+	// inLoopVar is true while parsing the variable of a for loop, where `in`
+	// ends the variable instead of being an operator.
+	inLoopVar bool
 }
 
 // New creates a new parser for the given token generator function.
@@ -40,11 +61,15 @@ func New(lex func() token.Token) *Parser {
 		token.Minus:       p.parsePrefix,
 		token.Plus:        p.parsePrefix,
 		token.Bang:        p.parsePrefix,
+		token.Not:         p.parseNot,
 		token.LambdaArrow: p.parsePrefix,
 		token.Comma:       p.parsePrefix,
+		token.Ellipsis:    p.parsePrefix,
+		token.Exp:         p.parsePin,
 		token.If:          p.parseIf,
 		token.Match:       p.parseMatch,
 		token.Model:       p.parseModel,
+		token.Protocol:    p.parseProtocol,
 	}
 
 	p.leds = map[token.Type]led{
@@ -65,6 +90,9 @@ func New(lex func() token.Token) *Parser {
 		token.Mod:            p.parseInfix,
 		token.LessThanEq:     p.parseInfix,
 		token.GreaterThanEq:  p.parseInfix,
+		token.Implements:     p.parseInfix,
+		token.In:             p.parseInfix,
+		token.Not:            p.parseNotIn,
 		token.AndEquals:      p.parseInfix,
 		token.BitAndEquals:   p.parseInfix,
 		token.BitOrEquals:    p.parseInfix,
@@ -76,11 +104,13 @@ func New(lex func() token.Token) *Parser {
 		token.PlusEquals:     p.parseInfix,
 		token.SlashEquals:    p.parseInfix,
 		token.StarEquals:     p.parseInfix,
-		token.Assign:         p.parseInfix,
-		token.Declare:        p.parseInfix,
-		token.Dot:            p.parseInfix,
+		token.Assign:         p.parseAssign,
+		token.Declare:        p.parseAssign,
+		token.Dot:            p.parseDot,
 		token.Comma:          p.parseInfix,
 		token.LambdaArrow:    p.parseInfix,
+		token.Pipe:           p.parseInfix,
+		token.Apply:          p.parseApply,
 	}
 
 	p.next()
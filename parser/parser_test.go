@@ -14,12 +14,18 @@ func TestNoErrors(t *testing.T) {
 		"hello",
 		"100",
 		"2.3",
+		"0xff",
+		"1_000e-3",
 		"true",
 		"false",
 		"nil",
 		`"hello"`,
 		"'hello'",
 		"`hello`;;",
+		`"a {b} c"`,
+		`"{a}{b}"`,
+		`"a {f x, "b {c}"} d"`,
+		`"a {{b: c}} d"`,
 
 		"(5)",
 
@@ -68,6 +74,15 @@ func TestNoErrors(t *testing.T) {
              | a -> b,
              | b -> c,
              | _ -> d`,
+		`match n where
+             | (x, y) -> x,
+             | [head, ...rest] -> rest,
+             | {name: n} -> n,
+             | number n -> n,
+             | x if x > 3 -> x`,
+		`match n where
+             | ^limit -> 0,
+             | (^x, y) -> y`,
 
 		"model a",
 		"model a, b",
@@ -96,8 +111,51 @@ func TestNoErrors(t *testing.T) {
 
 		"for a in b, c",
 		"for a in b do c; d; e end",
+		"for k, v in m, k",
+
+		"a, b = b, a",
+		"[x, (y, z)] := w",
+		"{name: n} = person",
 
 		"import 'foo'",
+
+		"set ()",
+		"set 1, 2, 3",
+		"(set a, (set b)) | s",
+		"x in (set 1, 2)",
+		"print f #{ note: identity }",
+		"x not in [1, 2]",
+
+		"xs[1:3]",
+		"xs[:-1]",
+		"xs[::2]",
+		"xs[1::]",
+		"xs[:]",
+		"xs[a + 1:f b:c]",
+		"xs[1:3] = [5]",
+		"[(f a: 1), 2]",
+		"{(f a: 1): 2}",
+		"{{\"a\": 1}[\"a\"]: 2}",
+		"{{a: {b: 1}}: {c: 2}}",
+		"a in b not in c",
+		"for x in xs do print (x in ys) end",
+
+		"const x = 5",
+		"const a, b = 1, 2",
+		"const n: integer = 5",
+		"const square x = x * x",
+
+		"greet name, greeting = \"hi\" = greeting",
+		"f a, b = 1, c = 2 = a",
+		"f first, ...rest = rest",
+		"f ...args = args",
+		"greet \"bob\", greeting: \"hi\"",
+		"greet greeting: \"hi\", name: \"bob\"",
+		"{f x: 1}",
+
+		"5 |> print",
+		"print $ 5 + 3",
+		"xs |> map double |> sum",
 	}
 
 	for i, test := range tests {
@@ -113,7 +171,7 @@ func TestNoErrors(t *testing.T) {
 func TestErrors(t *testing.T) {
 	tests := map[string]string{
 		")": "unexpected token: right-paren",
-		"$": "illegal token encountered. literal: `$`",
+		"~": "illegal token encountered. literal: `~`",
 
 		"(":     "unexpected end of line",
 		"[":     "unexpected end of line",
@@ -124,8 +182,11 @@ func TestErrors(t *testing.T) {
 
 		"if true": "unexpected end of line, wanted 'then'",
 
-		"match x":           "unexpected end of line, wanted 'where'",
-		"match x where | a": "unexpected end of line, wanted 'right-arrow'",
+		"match x":                 "unexpected end of line, wanted 'where'",
+		"match x where | a":       "unexpected end of line, wanted 'right-arrow'",
+		"match x where | a if":    "unexpected end of line",
+		"^x":                      "a pinned value (^) can only be used in a match pattern",
+		"match ^x where | a -> a": "a pinned value (^) can only be used in a match pattern",
 
 		"model": "unexpected end of line",
 
@@ -138,7 +199,33 @@ func TestErrors(t *testing.T) {
 		"import":   "unexpected end of line, wanted 'string'",
 		"import 5": "expected 'string' but got 'number'",
 
-		"a[b": "unexpected end of line, wanted 'right-square'",
+		"a[b":        "unexpected end of line, wanted 'right-square'",
+		"a[b:":       "unexpected end of line",
+		"a[b:c, d]":  "expected 'right-square' but got 'comma'",
+		"a[1:2:3:4]": "expected 'right-square' but got 'colon'",
+
+		"a not b": "expected 'in' but got 'identifier'",
+		"a not":   "unexpected end of line, wanted 'in'",
+		"not":     "unexpected end of line",
+
+		"f a = 1 |> g = 2":  "expected a comma or '=' after a default value",
+		"a.5":               "expected 'identifier' but got 'number'",
+		"f a, 1: 2":         "the name of a keyword argument must be an identifier",
+		"f a: 1, 2: 3":      "the name of a keyword argument must be an identifier",
+		"x: number":         "unexpected end of line, wanted 'assign'",
+		"x: number + 1":     "unexpected end of line, wanted 'assign'",
+		"f x = 1 -> number": "unexpected end of line, wanted 'assign'",
+		"const x":           "expected an assignment after const, such as const x = 5",
+		"const x := 5":      "expected an assignment after const, such as const x = 5",
+
+		"0b12":  "invalid digit '2' in binary literal",
+		"1e":    "exponent has no digits",
+		"1e400": "number literal out of range: 1e400",
+
+		`"\q"`:           `unknown escape sequence: \q`,
+		`"a {} b"`:       "unexpected token: interpolation-end",
+		`"a {b c"`:       "unterminated string literal",
+		`import "a {b}"`: "an import path cannot be interpolated",
 	}
 
 	for test, expectedMessage := range tests {
@@ -163,6 +250,46 @@ func TestPrecedence(t *testing.T) {
 		"1 * 2 + 3": "(1 * 2) + 3",
 		"1 + 2, 3":  "(1 + 2), 3",
 		"1, 2 + 3":  "1, (2 + 3)",
+
+		"x |> f |> g":         "(x |> f) |> g",
+		"f $ g $ x":           "f $ (g $ x)",
+		"f $ x |> g":          "f $ (x |> g)",
+		"xs |> map f |> g":    "(xs |> (map f)) |> g",
+		"1, 2 |> f":           "(1, 2) |> f",
+		"y = x |> f":          "y = (x |> f)",
+		"y = f $ x":           "y = (f $ x)",
+		"print $ 5 + 3":       "print $ (5 + 3)",
+		"print $ a, b |> f x": "print $ ((a, b) |> (f x))",
+
+		"a.b c":              "(a.b) c",
+		"a.b.c d, e":         "((a.b).c) (d, e)",
+		"x + a.b c":          "x + ((a.b) c)",
+		"dog.speak n = body": "((dog.speak) n) = body",
+
+		`greet name, greeting = "hi" = body`: `greet (name, (greeting = "hi")) = body`,
+		"f a = 1, b = 2 = a + b":             "f ((a = 1), (b = 2)) = (a + b)",
+		"f a, b = 1, c = 2 = a":              "f (a, (b = 1), (c = 2)) = a",
+		"f a = 1, b, c = 2":                  "f ((a = 1), b, c) = 2",
+
+		"a implements s && b":   "(a implements s) && b",
+		"print x implements s":  "print (x implements s)",
+		"a + b implements s, t": "((a + b) implements s), t",
+
+		"a + b in s":       "(a + b) in s",
+		"a in s && b in t": "(a in s) && (b in t)",
+		"a in s == b":      "(a in s) == b",
+		"print x in s":     "print (x in s)",
+		"a not in s || b":  "(a not in s) || b",
+		"a < b in s":       "(a < b) in s",
+
+		"not a == b":     "!(a == b)",
+		"not a && b":     "(!a) && b",
+		"not a in s":     "!(a in s)",
+		"not a not in s": "!(a not in s)",
+		"not not a":      "!(!a)",
+
+		"xs[a:b c]": "xs [a:(b c)]",
+		"xs[f a:b]": "xs [(f a):b]",
 	}
 
 	for test, expected := range tests {
@@ -183,6 +310,83 @@ func TestPrecedence(t *testing.T) {
 	}
 }
 
+func TestNumbers(t *testing.T) {
+	floats := map[string]float64{
+		"1.5":      1.5,
+		"1e9":      1e9,
+		"2.5E-3":   0.0025,
+		"1_000.25": 1000.25,
+	}
+
+	for test, expected := range floats {
+		expr, err := parseExpression(test)
+		if err != nil {
+			t.Errorf("%s: %s\n", test, err)
+			continue
+		}
+
+		if num, ok := expr.(*ast.Number); !ok || num.Value != expected {
+			t.Errorf("%s: expected the number %v\n", test, expected)
+		}
+	}
+
+	ints := map[string]string{
+		"123":                            "123",
+		"0755":                           "755",
+		"1_000_000":                      "1000000",
+		"0xff":                           "255",
+		"0b1010":                         "10",
+		"0o17":                           "15",
+		"0x_dead_beef":                   "3735928559",
+		"0xd":                            "13",
+		"0xdead":                         "57005",
+		"0x1d":                           "29",
+		"0XAD":                           "173",
+		"0x1_0000_0000_0000_0000":        "18446744073709551616",
+		"123456789012345678901234567890": "123456789012345678901234567890",
+	}
+
+	for test, expected := range ints {
+		expr, err := parseExpression(test)
+		if err != nil {
+			t.Errorf("%s: %s\n", test, err)
+			continue
+		}
+
+		if i, ok := expr.(*ast.Integer); !ok || i.Value.String() != expected {
+			t.Errorf("%s: expected the integer %s\n", test, expected)
+		}
+	}
+
+	decimals := map[string]string{
+		"1d":        "1",
+		"12.34d":    "12.34",
+		"1_000.50d": "1000.50",
+		"1.5e-3d":   "1.5e-3",
+	}
+
+	for test, expected := range decimals {
+		expr, err := parseExpression(test)
+		if err != nil {
+			t.Errorf("%s: %s\n", test, err)
+			continue
+		}
+
+		if d, ok := expr.(*ast.Decimal); !ok || d.Value != expected {
+			t.Errorf("%s: expected the decimal %s\n", test, expected)
+		}
+	}
+}
+
+func parseExpression(str string) (ast.Expression, error) {
+	prog, err := parse(str, "test")
+	if err != nil {
+		return nil, err
+	}
+
+	return prog.Statements[0].(*ast.ExpressionStatement).Expr, nil
+}
+
 func parse(str, file string) (*ast.Program, error) {
 	var (
 		l = lexer.Lexer(str, file)
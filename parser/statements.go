@@ -5,8 +5,14 @@ import (
 	"github.com/Zac-Garby/radon/token"
 )
 
-func (p *Parser) parseStatement() ast.Statement {
-	var node ast.Statement
+func (p *Parser) parseStatement() (node ast.Statement) {
+	start := p.cur.Start
+
+	defer func() {
+		if node != nil {
+			node.SetPos(start)
+		}
+	}()
 
 	switch p.cur.Type {
 	case token.Semi:
@@ -33,6 +39,9 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.Export:
 		return p.parseExport()
 
+	case token.Const:
+		return p.parseConst()
+
 	default:
 		node = p.parseExpressionStatement()
 	}
@@ -45,11 +54,45 @@ func (p *Parser) parseStatement() ast.Statement {
 }
 
 func (p *Parser) parseExpressionStatement() ast.Statement {
+	if p.curIs(token.ID) && p.peekIs(token.Colon) {
+		return &ast.ExpressionStatement{
+			Expr: p.parseAnnotatedAssign(),
+		}
+	}
+
 	return &ast.ExpressionStatement{
 		Expr: p.parseExpression(lowest),
 	}
 }
 
+// parseAnnotatedAssign parses an assignment or declaration to an annotated
+// variable, such as `x: number = 5`, whose left-hand side is a Keyword.
+func (p *Parser) parseAnnotatedAssign() ast.Expression {
+	target := &ast.Keyword{Name: p.cur.Literal}
+
+	p.next()
+	p.next()
+
+	target.Value = p.parseExpression(join)
+
+	if !p.peekIs(token.Assign, token.Declare) {
+		p.peekErr(token.Assign)
+		return nil
+	}
+
+	p.next()
+
+	node := &ast.Infix{
+		Operator: p.cur.Literal,
+		Left:     target,
+	}
+
+	p.next()
+	node.Right = p.parseExpression(assign)
+
+	return node
+}
+
 func (p *Parser) parseReturn() ast.Statement {
 	if p.peekIs(token.Semi) {
 		return &ast.Return{
@@ -89,9 +132,11 @@ func (p *Parser) parseWhile() ast.Statement {
 func (p *Parser) parseFor() ast.Statement {
 	p.next()
 
+	p.inLoopVar = true
 	node := &ast.For{
 		Var: p.parseExpression(lowest),
 	}
+	p.inLoopVar = false
 
 	if !p.expect(token.In) {
 		return nil
@@ -121,13 +166,39 @@ func (p *Parser) parseImport() ast.Statement {
 		return nil
 	}
 
-	str := p.parseExpression(lowest).(*ast.String)
+	str, ok := p.parseExpression(lowest).(*ast.String)
+	if !ok {
+		p.defaultErr("an import path cannot be interpolated")
+		return nil
+	}
 
 	return &ast.Import{
 		Path: str.Value,
 	}
 }
 
+func (p *Parser) parseConst() ast.Statement {
+	p.next()
+
+	var expr ast.Expression
+	if p.curIs(token.ID) && p.peekIs(token.Colon) {
+		expr = p.parseAnnotatedAssign()
+	} else {
+		expr = p.parseExpression(lowest)
+	}
+
+	assign, ok := expr.(*ast.Infix)
+	if !ok || assign.Operator != "=" {
+		p.defaultErr("expected an assignment after const, such as const x = 5")
+		return nil
+	}
+
+	return &ast.Const{
+		Target: assign.Left,
+		Value:  assign.Right,
+	}
+}
+
 func (p *Parser) parseExport() ast.Statement {
 	p.next()
 
@@ -10,6 +10,8 @@ import (
 const (
 	lowest = iota
 	assign
+	apply
+	pipe
 	lambda
 	join
 	or
@@ -17,6 +19,7 @@ const (
 	bitOr
 	bitAnd
 	equals
+	membership
 	compare
 	sum
 	product
@@ -49,6 +52,9 @@ var precedences = map[token.Type]int{
 	token.GreaterThan:    compare,
 	token.LessThanEq:     compare,
 	token.GreaterThanEq:  compare,
+	token.Implements:     compare,
+	token.In:             membership,
+	token.Not:            membership,
 	token.Plus:           sum,
 	token.Minus:          sum,
 	token.Star:           product,
@@ -60,6 +66,8 @@ var precedences = map[token.Type]int{
 	token.Dot:            index,
 	token.LambdaArrow:    lambda,
 	token.Comma:          join,
+	token.Pipe:           pipe,
+	token.Apply:          apply,
 }
 
 // argTokens is the set of tokens which can appear as the first token to a function call
@@ -79,9 +87,15 @@ var argTokens = []token.Type{
 	token.For,
 	token.Match,
 	token.Model,
+	token.Protocol,
+	token.Ellipsis,
 }
 
 func (p *Parser) peekPrecedence() int {
+	if p.inLoopVar && p.peekIs(token.In) {
+		return lowest
+	}
+
 	if precedence, ok := precedences[p.peek.Type]; ok {
 		return precedence
 	}
@@ -101,13 +115,17 @@ func (p *Parser) next() {
 	p.cur = p.peek
 	p.peek = p.lex()
 
-	if p.peek.Type == token.Illegal {
+	switch p.peek.Type {
+	case token.Illegal:
 		p.err(
 			"illegal token encountered. literal: `%s`",
 			p.peek.Start,
 			p.peek.End,
 			p.peek.Literal,
 		)
+
+	case token.Malformed:
+		p.err("%s", p.peek.Start, p.peek.End, p.peek.Literal)
 	}
 }
 
@@ -142,15 +160,20 @@ func (p *Parser) expect(t token.Type) bool {
 }
 
 func (p *Parser) parseExpressionList(end, sep token.Type) []ast.Expression {
-	var exprs []ast.Expression
-
 	if p.peekIs(end) {
 		p.next()
-		return exprs
+		return nil
 	}
 
 	p.next()
-	exprs = append(exprs, p.parseExpression(join))
+
+	return p.parseExpressionListFrom(p.parseExpression(join), end, sep)
+}
+
+// parseExpressionListFrom parses the rest of an expression list, whose first
+// expression has already been parsed.
+func (p *Parser) parseExpressionListFrom(first ast.Expression, end, sep token.Type) []ast.Expression {
+	exprs := []ast.Expression{first}
 
 	for p.peekIs(sep) {
 		p.next()
@@ -171,8 +194,8 @@ func (p *Parser) parseExpressionList(end, sep token.Type) []ast.Expression {
 	return exprs
 }
 
-func (p *Parser) parseExpressionPairs(end, sep token.Type) map[ast.Expression]ast.Expression {
-	pairs := make(map[ast.Expression]ast.Expression)
+func (p *Parser) parseExpressionPairs(end, sep token.Type) []ast.Pair {
+	var pairs []ast.Pair
 
 	p.next()
 
@@ -181,7 +204,7 @@ func (p *Parser) parseExpressionPairs(end, sep token.Type) map[ast.Expression]as
 	}
 
 	key, val := p.parsePair()
-	pairs[key] = val
+	pairs = append(pairs, ast.Pair{Key: key, Value: val})
 
 	for p.peekIs(sep) {
 		p.next()
@@ -193,7 +216,7 @@ func (p *Parser) parseExpressionPairs(end, sep token.Type) map[ast.Expression]as
 
 		p.next()
 		key, val = p.parsePair()
-		pairs[key] = val
+		pairs = append(pairs, ast.Pair{Key: key, Value: val})
 	}
 
 	if !p.expect(end) {
@@ -204,15 +227,21 @@ func (p *Parser) parseExpressionPairs(end, sep token.Type) map[ast.Expression]as
 }
 
 func (p *Parser) parsePair() (ast.Expression, ast.Expression) {
+	outer := p.inMapKey
+
+	p.inMapKey = true
 	key := p.parseExpression(index)
+	p.inMapKey = false
 
 	if !p.expect(token.Colon) {
+		p.inMapKey = outer
 		return nil, nil
 	}
 
 	p.next()
 
 	value := p.parseExpression(join)
+	p.inMapKey = outer
 
 	return key, value
 }
@@ -3,13 +3,16 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/signal"
 	"strings"
 
 	"github.com/Zac-Garby/radon/bytecode"
+	"github.com/Zac-Garby/radon/checker"
 	"github.com/Zac-Garby/radon/compiler"
 	"github.com/Zac-Garby/radon/lexer"
 	"github.com/Zac-Garby/radon/object"
@@ -17,7 +20,15 @@ import (
 	"github.com/Zac-Garby/radon/runtime"
 )
 
+var (
+	enforce = flag.Bool("enforce", false, "check type and model annotations at runtime")
+	checks  = flag.Bool("check", false, "check the files' annotations statically instead of running them")
+	root    = flag.String("root", "", "confine the fs module to this directory")
+)
+
 func main() {
+	flag.Parse()
+
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 
@@ -26,17 +37,24 @@ func main() {
 		quit()
 	}(c)
 
-	if len(os.Args) < 2 {
+	args := flag.Args()
+
+	if len(args) < 1 {
 		startRepl()
+	} else if *checks {
+		check(args)
 	} else {
-		filename := os.Args[1]
+		filename := args[0]
 		bytes, err := ioutil.ReadFile(filename)
 		if err != nil {
 			fmt.Println("couldn't open", filename)
 			os.Exit(2)
 		}
 
-		if _, err = run(string(bytes), runtime.NewStore(nil)); err != nil {
+		_, err = run(string(bytes), filename, newVM(os.Stdin, args[1:]), runtime.NewStore(nil))
+		if exit, ok := err.(*runtime.Exit); ok {
+			os.Exit(exit.Code)
+		} else if err != nil {
 			fmt.Print("\x1b[91m")
 			fmt.Println(err)
 			fmt.Print("\x1b[0m")
@@ -46,9 +64,48 @@ func main() {
 	}
 }
 
+// check statically checks each file against its type annotations, printing any
+// problems which are found.
+func check(filenames []string) {
+	failed := false
+
+	for _, filename := range filenames {
+		bytes, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Println("couldn't open", filename)
+			os.Exit(2)
+		}
+
+		prog, err := parser.New(lexer.Lexer(string(bytes), filename)).Parse()
+		if err != nil {
+			fmt.Print("\x1b[91m")
+			fmt.Println(err)
+			fmt.Print("\x1b[0m")
+
+			failed = true
+			continue
+		}
+
+		for _, problem := range checker.Check(prog) {
+			fmt.Print("\x1b[91m")
+			fmt.Println(problem)
+			fmt.Print("\x1b[0m")
+
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
 func startRepl() {
-	reader := bufio.NewReader(os.Stdin)
-	store := runtime.NewStore(nil)
+	var (
+		reader = bufio.NewReader(os.Stdin)
+		store  = runtime.NewStore(nil)
+		v      = newVM(reader, nil)
+	)
 
 	for {
 		fmt.Print("> ")
@@ -61,8 +118,10 @@ func startRepl() {
 
 		line = strings.TrimSpace(line)
 
-		res, err := run(line, store)
-		if err != nil {
+		res, err := run(line, "repl", v, store)
+		if exit, ok := err.(*runtime.Exit); ok {
+			os.Exit(exit.Code)
+		} else if err != nil {
 			fmt.Print("\x1b[91m") // red
 			fmt.Println(" ", err)
 			fmt.Print("\x1b[0m")
@@ -74,9 +133,24 @@ func startRepl() {
 	}
 }
 
-func run(code string, store *runtime.Store) (object.Object, error) {
+// newVM makes a virtual machine configured by the command-line flags. The programs it
+// runs read their input from in, and their command-line arguments are args.
+func newVM(in io.Reader, args []string) *runtime.VM {
+	v := runtime.New()
+	v.EnforceAnnotations = *enforce
+	v.FileRoot = *root
+	v.In = in
+	v.Args = args
+
+	return v
+}
+
+// run runs code, read from the file filename, in store, using the virtual machine v.
+// v is reset first, so it can be reused, such as by each line of the REPL, keeping
+// its settings.
+func run(code, filename string, v *runtime.VM, store *runtime.Store) (object.Object, error) {
 	var (
-		l         = lexer.Lexer(code, "repl")
+		l         = lexer.Lexer(code, filename)
 		p         = parser.New(l)
 		prog, err = p.Parse()
 	)
@@ -95,7 +169,8 @@ func run(code string, store *runtime.Store) (object.Object, error) {
 		return nil, err
 	}
 
-	v := runtime.New()
+	v.Reset()
+
 	frame := v.MakeFrame(
 		parsedCode,
 		nil,
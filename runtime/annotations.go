@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/Zac-Garby/radon/object"
+)
+
+// isAnnotation checks whether o can be used as an annotation. An annotation is
+// either the name of a type, a model, or a protocol.
+func isAnnotation(o object.Object) bool {
+	switch o.(type) {
+	case *object.String, *object.Model, *object.Protocol:
+		return true
+	}
+
+	return false
+}
+
+// matchesAnnotation checks whether o matches annotation. If it doesn't, a reason
+// is returned too. Protocols are always checked, but types and models are only
+// checked if the virtual machine enforces annotations.
+func (v *VM) matchesAnnotation(o, annotation object.Object) (bool, string) {
+	switch a := annotation.(type) {
+	case *object.Protocol:
+		if ok, missing := a.Implements(o); !ok {
+			return false, fmt.Sprintf("it has no method %s", missing)
+		}
+
+	case *object.String:
+		if v.EnforceAnnotations && !typeMatches(o, object.Type(a.Value)) {
+			return false, fmt.Sprintf("its type is %s, not %s", o.Type(), a.Value)
+		}
+
+	case *object.Model:
+		if v.EnforceAnnotations && !isInstance(o, a) {
+			return false, fmt.Sprintf("it isn't an instance of the model %s", a)
+		}
+	}
+
+	return true, ""
+}
+
+// isInstance checks whether o is an instance of model, or of any model with it as
+// an ancestor.
+func isInstance(o object.Object, model *object.Model) bool {
+	m, ok := o.(*object.Map)
+	if !ok {
+		return false
+	}
+
+	val, ok := m.Subscript(&object.String{Value: "__model"})
+	if !ok {
+		return false
+	}
+
+	for parent, ok := val.(*object.Model); ok && parent != nil; parent = parent.Parent {
+		if parent == model {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkArguments checks that each argument bound to an annotated parameter of fn
+// matches its annotation. The parameters are checked in order, so the first one
+// which doesn't match is reported.
+func checkArguments(v *VM, store *Store, fn *object.Function) error {
+	names := fn.Parameters
+	if fn.Rest != "" {
+		names = append(names[:len(names):len(names)], fn.Rest)
+	}
+
+	for _, name := range names {
+		annotation, ok := fn.Annotations[name]
+		if !ok {
+			continue
+		}
+
+		variable, ok := store.Data[name]
+		if !ok {
+			continue
+		}
+
+		if ok, reason := v.matchesAnnotation(variable.Value, annotation); !ok {
+			return makeError(TypeError, "the argument for the parameter %s doesn't match its annotation: %s", name, reason)
+		}
+	}
+
+	return nil
+}
+
+// checkReturn checks that the value returned from fn matches the annotation of its
+// return value, if it has one.
+func checkReturn(v *VM, fn *object.Function, ret object.Object) error {
+	annotation, ok := fn.Annotations["return"]
+	if !ok {
+		return nil
+	}
+
+	if ret == nil {
+		ret = &object.Nil{}
+	}
+
+	if ok, reason := v.matchesAnnotation(ret, annotation); !ok {
+		return makeError(TypeError, "the value returned from a function doesn't match its annotation: %s", reason)
+	}
+
+	return nil
+}
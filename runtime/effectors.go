@@ -5,7 +5,6 @@ import (
 
 	"github.com/Zac-Garby/radon/bytecode"
 	"github.com/Zac-Garby/radon/object"
-	"github.com/cnf/structhash"
 )
 
 // An Effector is the function which is called for a particular instruction.
@@ -20,7 +19,15 @@ var Effectors [256]Effector
 func init() {
 	Effectors[bytecode.Nop] = func(v *VM, f *Frame, arg rune) error { return nil }
 	Effectors[bytecode.NopArg] = func(v *VM, f *Frame, arg rune) error { return nil }
-	Effectors[bytecode.LoadConst] = func(v *VM, f *Frame, arg rune) error { return f.stack.Push(f.constants[arg]) }
+	Effectors[bytecode.LoadConst] = func(v *VM, f *Frame, arg rune) error {
+		// Constants are frozen, but strings are mutable, so a string constant is
+		// loaded as a new string each time
+		if str, ok := f.constants[arg].(*object.String); ok {
+			return f.stack.Push(&object.String{Value: str.Value})
+		}
+
+		return f.stack.Push(f.constants[arg])
+	}
 
 	Effectors[bytecode.LoadName] = func(v *VM, f *Frame, arg rune) error {
 		val, ok := f.store().Get(f.names[arg])
@@ -37,8 +44,7 @@ func init() {
 			return err
 		}
 
-		f.store().Set(f.names[arg], val, false)
-		return nil
+		return storeName(f, f.names[arg], val, false)
 	}
 
 	Effectors[bytecode.DeclareName] = func(v *VM, f *Frame, arg rune) error {
@@ -47,7 +53,23 @@ func init() {
 			return err
 		}
 
-		f.store().Set(f.names[arg], val, false)
+		return storeName(f, f.names[arg], val, true)
+	}
+
+	Effectors[bytecode.DeclareConst] = func(v *VM, f *Frame, arg rune) error {
+		val, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		// The value is frozen as well as the variable, so a constant can't be
+		// mutated any more than it can be reassigned
+		name := f.names[arg]
+		if err := storeName(f, name, object.FrozenCopy(val), true); err != nil {
+			return err
+		}
+
+		f.store().Data[name].Const = true
 		return nil
 	}
 
@@ -63,11 +85,29 @@ func init() {
 		}
 
 		result, ok := obj.Subscript(index)
-		if !ok {
-			return makeError(TypeError, "could not subscript a %s object with index %s", obj.Type(), index.String())
+		if ok {
+			return f.stack.Push(result)
 		}
 
-		return f.stack.Push(result)
+		if method, ok := overload(obj, "__index"); ok {
+			result, err := v.invoke(f, method, index)
+			if err != nil {
+				return err
+			}
+
+			return f.stack.Push(result)
+		}
+
+		if err := checkIndex(obj, index); err != nil {
+			return err
+		}
+
+		return makeError(TypeError, "could not subscript a %s object with index %s", obj.Type(), index.String())
+	}
+
+	Effectors[bytecode.Pop] = func(v *VM, f *Frame, arg rune) error {
+		_, err := f.stack.Pop()
+		return err
 	}
 
 	Effectors[bytecode.StoreSubscript] = func(v *VM, f *Frame, arg rune) error {
@@ -86,7 +126,31 @@ func init() {
 			return err
 		}
 
+		if fr, ok := obj.(object.Freezable); ok && fr.IsFrozen() {
+			return makeError(TypeError, "cannot set subscript %s on a frozen %s object", index.String(), obj.Type())
+		}
+
+		if _, ok := obj.(*object.Map); ok {
+			if err := checkKey(index); err != nil {
+				return err
+			}
+		}
+
 		if !obj.SetSubscript(index, val) {
+			if err := checkIndex(obj, index); err != nil {
+				return err
+			}
+
+			if list, ok := obj.(*object.List); ok {
+				if err := checkBounds(index, len(list.Value)); err != nil {
+					return err
+				}
+			}
+
+			if err := checkSliceLength(obj, index, val); err != nil {
+				return err
+			}
+
 			return makeError(TypeError, "could not set subscript %s on a %s object", index.String(), obj.Type())
 		}
 
@@ -116,44 +180,192 @@ func init() {
 	Effectors[bytecode.BinaryMoreEq] = binaryEffector(">=")
 	Effectors[bytecode.BinaryTuple] = binaryEffector(",")
 
+	Effectors[bytecode.BinaryIn] = membershipEffector(true)
+	Effectors[bytecode.BinaryNotIn] = membershipEffector(false)
+
 	Effectors[bytecode.CallFunction] = func(v *VM, f *Frame, argCount rune) error {
 		top, err := f.stack.Pop()
 		if err != nil {
 			return err
 		}
 
-		builtin, ok := top.(*object.Builtin)
-		if ok {
-			args := make([]object.Object, 0, argCount)
+		args, err := popArgs(f, argCount)
+		if err != nil {
+			return err
+		}
 
-			for i := 0; i < int(argCount); i++ {
-				arg, err := f.stack.Pop()
-				if err != nil {
-					return err
-				}
+		return call(v, f, top, args, nil)
+	}
+
+	Effectors[bytecode.CallKeywords] = func(v *VM, f *Frame, argCount rune) error {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		kwargs, err := popKeywords(f)
+		if err != nil {
+			return err
+		}
 
-				args = append(args, arg)
+		args, err := popArgs(f, argCount)
+		if err != nil {
+			return err
+		}
+
+		return call(v, f, top, args, kwargs)
+	}
+
+	Effectors[bytecode.MakeModel] = func(v *VM, f *Frame, arg rune) error {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		initObj, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		init, ok := initObj.(*object.Function)
+		if !ok {
+			return makeError(InternalError, "expected a function to initialise a model, got a %s", initObj.Type())
+		}
+
+		switch parent := top.(type) {
+		case *object.Nil:
+			return f.stack.Push(object.NewModel(init, nil))
+
+		case *object.Model:
+			return f.stack.Push(object.NewModel(init, parent))
+		}
+
+		return makeError(TypeError, "the parent of a model must be a model, not a %s", top.Type())
+	}
+
+	Effectors[bytecode.MakeInstance] = func(v *VM, f *Frame, arg rune) error {
+		model, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		pairs := make([]object.Object, arg*2)
+
+		for n := int(arg)*2 - 1; n >= 0; n-- {
+			if pairs[n], err = f.stack.Pop(); err != nil {
+				return err
 			}
+		}
 
-			result, errorType, errorMessage := builtin.Fn(args...)
-			if err != nil {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		instance, ok := top.(*object.Map)
+		if !ok {
+			return makeError(TypeError, "a model's parent must make a map, not a %s", top.Type())
+		}
+
+		instance.Set(&object.String{Value: "__model"}, model)
+
+		for n := 0; n < len(pairs); n += 2 {
+			if !instance.Set(pairs[n], pairs[n+1]) {
+				return makeError(TypeError, "a value of type %s can't be used as a field name, since it isn't hashable", pairs[n].Type())
+			}
+		}
+
+		return f.stack.Push(instance)
+	}
+
+	Effectors[bytecode.BindDefaults] = func(v *VM, f *Frame, arg rune) error {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		fn, ok := top.(*object.Function)
+		if !ok {
+			return makeError(InternalError, "cannot bind default values to a %s", top.Type())
+		}
+
+		bound := *fn
+		bound.Defaults = make([]object.Object, arg)
+
+		for i := int(arg) - 1; i >= 0; i-- {
+			if bound.Defaults[i], err = f.stack.Pop(); err != nil {
+				return err
+			}
+		}
+
+		return f.stack.Push(&bound)
+	}
+
+	Effectors[bytecode.BindAnnotations] = func(v *VM, f *Frame, arg rune) error {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		names, ok := top.(*object.Tuple)
+		if !ok {
+			return makeError(InternalError, "expected a tuple of parameter names, got a %s", top.Type())
+		}
+
+		annotations := make([]object.Object, len(names.Value))
+
+		for i := len(annotations) - 1; i >= 0; i-- {
+			if annotations[i], err = f.stack.Pop(); err != nil {
 				return err
 			}
+		}
+
+		top, err = f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		fn, ok := top.(*object.Function)
+		if !ok {
+			return makeError(InternalError, "cannot bind annotations to a %s", top.Type())
+		}
+
+		bound := *fn
+		bound.Annotations = make(map[string]object.Object, len(annotations))
 
-			if errorType != "" {
-				return makeError(ErrorType(errorType), errorMessage)
+		for i, name := range names.Value {
+			param := name.(*object.String).Value
+
+			if !isAnnotation(annotations[i]) {
+				return makeError(TypeError, "the annotation of %s must be a type, a model or a protocol, not a %s", param, annotations[i].Type())
 			}
 
-			return f.stack.Push(result)
+			bound.Annotations[param] = annotations[i]
 		}
 
-		if fn, ok := top.(*object.Function); ok {
-			return callFunction(v, f, fn, argCount)
-		} else if items, ok := top.Items(); ok {
-			return indexCollection(v, f, items, argCount)
-		} else {
-			return makeError(TypeError, "cannot call an object of type %s", top.Type())
+		return f.stack.Push(&bound)
+	}
+
+	Effectors[bytecode.CheckAnnotation] = func(v *VM, f *Frame, arg rune) error {
+		annotation, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		val, err := f.stack.Top()
+		if err != nil {
+			return err
 		}
+
+		if !isAnnotation(annotation) {
+			return makeError(TypeError, "the annotation of %s must be a type, a model or a protocol, not a %s", f.names[arg], annotation.Type())
+		}
+
+		if ok, reason := v.matchesAnnotation(val, annotation); !ok {
+			return makeError(TypeError, "the value assigned to %s doesn't match its annotation: %s", f.names[arg], reason)
+		}
+
+		return nil
 	}
 
 	Effectors[bytecode.Return] = func(v *VM, f *Frame, arg rune) error {
@@ -188,7 +400,13 @@ func init() {
 			return makeError(StructureError, "can't export variable %s from a top-level scope", name)
 		}
 
+		if enclosing.IsConst(name, true) {
+			return makeError(NameError, "can't export %s, since the enclosing scope has a constant of the same name", name)
+		}
+
 		enclosing.Set(name, val, true)
+		enclosing.Data[name].Const = variable.Const
+
 		return nil
 	}
 
@@ -230,79 +448,142 @@ func init() {
 			return err
 		}
 
-		f.matchInputs = append(f.matchInputs, top)
+		f.matches = append(f.matches, matchState{
+			input:  top,
+			height: f.stack.Len(),
+		})
 
-		hasEnd := false
+		f.breaks = append(f.breaks, f.offsetToInstructionIndex(f.jumps[int(arg)]))
 
-		for i := f.offset; i < len(f.code); i++ {
-			if f.code[i].Code == bytecode.EndMatch {
-				f.breaks = append(f.breaks, i)
-				hasEnd = true
-				break
-			}
+		return nil
+	}
+
+	Effectors[bytecode.EndMatch] = func(v *VM, f *Frame, arg rune) error {
+		if len(f.matches) == 0 {
+			return makeError(InternalError, "malformed bytecode -- END_MATCH found (likely) before START_MATCH")
+		}
+
+		f.matches = f.matches[:len(f.matches)-1]
+		f.breaks = f.breaks[:len(f.breaks)-1]
+
+		return nil
+	}
+
+	Effectors[bytecode.LoadMatchInput] = func(v *VM, f *Frame, arg rune) error {
+		if len(f.matches) == 0 {
+			return makeError(InternalError, "unexpected empty match input stack")
+		}
+
+		return f.stack.Push(f.matches[len(f.matches)-1].input)
+	}
+
+	Effectors[bytecode.MatchValue] = func(v *VM, f *Frame, arg rune) error {
+		value, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		subject, err := f.stack.Pop()
+		if err != nil {
+			return err
 		}
 
-		if !hasEnd {
-			return makeError(InternalError, "malformed bytecode -- match expression has no END_MATCH")
+		if !subject.Equals(value) {
+			return failMatch(v, f, arg)
 		}
 
 		return nil
 	}
 
-	Effectors[bytecode.EndMatch] = func(v *VM, f *Frame, arg rune) error {
-		if len(f.matchInputs) == 0 {
-			return makeError(InternalError, "malformed bytecode -- END_MATCH found (likely) before START_MATCH")
+	Effectors[bytecode.MatchType] = func(v *VM, f *Frame, arg rune) error {
+		name, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		subject, err := f.stack.Top()
+		if err != nil {
+			return err
 		}
 
-		f.matchInputs = f.matchInputs[:len(f.matchInputs)-1]
+		if !typeMatches(subject, object.Type(name.(*object.String).Value)) {
+			return failMatch(v, f, arg)
+		}
 
 		return nil
 	}
 
-	Effectors[bytecode.StartBranch] = func(v *VM, f *Frame, arg rune) error {
-		cond, err := f.stack.Pop()
+	Effectors[bytecode.MatchSequence] = func(v *VM, f *Frame, arg rune) error {
+		hasRest, err := f.stack.Pop()
 		if err != nil {
 			return err
 		}
 
-		if len(f.matchInputs) == 0 {
-			return makeError(InternalError, "unexpected empty match input stack")
+		count, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		subject, err := f.stack.Pop()
+		if err != nil {
+			return err
 		}
 
-		input := f.matchInputs[len(f.matchInputs)-1]
+		var (
+			n, _      = object.ToInt(count)
+			rest      = object.IsTruthy(hasRest)
+			items, ok = subject.Items()
+		)
+
+		if !ok || len(items) < n || !rest && len(items) != n {
+			return failMatch(v, f, arg)
+		}
 
-		if !cond.Equals(input) {
-			hasEnd := false
+		if rest {
+			remaining := make([]object.Object, len(items)-n)
+			copy(remaining, items[n:])
 
-			for i := f.offset; i < len(f.code); i++ {
-				if f.code[i].Code == bytecode.EndMatch {
-					return makeError(InternalError, "malformed bytecode -- END_BRANCH appears after END_MATCH")
-				}
+			var restObj object.Object = &object.List{Value: remaining}
+			if _, ok := subject.(*object.Tuple); ok {
+				restObj = &object.Tuple{Value: remaining}
+			}
 
-				if f.code[i].Code == bytecode.EndBranch {
-					f.offset = i + 1
-					hasEnd = true
-					break
-				}
+			if err := f.stack.Push(restObj); err != nil {
+				return err
 			}
+		}
 
-			if !hasEnd {
-				return makeError(InternalError, "malformed bytecode -- match branch has no END_BRANCH")
+		for i := n - 1; i >= 0; i-- {
+			if err := f.stack.Push(items[i]); err != nil {
+				return err
 			}
 		}
 
 		return nil
 	}
 
-	Effectors[bytecode.EndBranch] = func(v *VM, f *Frame, arg rune) error {
-		if len(f.breaks) == 0 {
-			return makeError(InternalError, "malformed bytecode -- END_BRANCH found outside match")
+	Effectors[bytecode.MatchKey] = func(v *VM, f *Frame, arg rune) error {
+		key, err := f.stack.Pop()
+		if err != nil {
+			return err
 		}
 
-		top := f.breaks[len(f.breaks)-1]
-		f.offset = top
+		subject, err := f.stack.Top()
+		if err != nil {
+			return err
+		}
 
-		return nil
+		m, ok := subject.(*object.Map)
+		if !ok {
+			return failMatch(v, f, arg)
+		}
+
+		val, ok := m.Subscript(key)
+		if !ok {
+			return failMatch(v, f, arg)
+		}
+
+		return f.stack.Push(val)
 	}
 
 	Effectors[bytecode.Break] = func(v *VM, f *Frame, arg rune) error {
@@ -337,13 +618,7 @@ func init() {
 
 	Effectors[bytecode.StartLoop] = func(v *VM, f *Frame, arg rune) error {
 		f.nexts = append(f.nexts, f.offset)
-
-		var o int
-
-		for o = f.offset; f.code[o].Code != bytecode.EndLoop; o++ {
-		}
-
-		f.breaks = append(f.breaks, o)
+		f.breaks = append(f.breaks, f.offsetToInstructionIndex(f.jumps[int(arg)]))
 
 		return nil
 	}
@@ -361,6 +636,12 @@ func init() {
 			return err
 		}
 
+		if method, ok := overload(top, "__iter"); ok {
+			if top, err = v.invoke(f, method); err != nil {
+				return err
+			}
+		}
+
 		iter, ok := top.Iter()
 		if !ok {
 			return makeError(TypeError, "cannot make an iterable from a %s", top.Type())
@@ -390,17 +671,17 @@ func init() {
 
 		val, ok := iter.Next()
 		if !ok {
-			return Effectors[bytecode.Break](v, f, arg)
-		}
+			// Unlike a break statement, there's no scope to pop, since the
+			// body hasn't been entered
+			if len(f.breaks) == 0 {
+				return makeError(InternalError, "malformed bytecode -- ADV_ITER_FOR found outside a loop")
+			}
 
-		name, ok := f.getName(arg)
-		if !ok {
-			return makeError(InternalError, "cannot get name: %d", arg)
+			f.offset = f.breaks[len(f.breaks)-1]
+			return nil
 		}
 
-		f.store().Set(name, val, true)
-
-		return nil
+		return f.stack.Push(val)
 	}
 
 	Effectors[bytecode.MakeList] = func(v *VM, f *Frame, arg rune) error {
@@ -421,56 +702,301 @@ func init() {
 	}
 
 	Effectors[bytecode.MakeMap] = func(v *VM, f *Frame, arg rune) error {
-		keys := make(map[string]object.Object, arg)
-		vals := make(map[string]object.Object, arg)
+		pairs := make([]object.Object, arg*2)
 
-		for n := 0; n < int(arg); n++ {
-			val, err := f.stack.Pop()
+		for n := int(arg)*2 - 1; n >= 0; n-- {
+			top, err := f.stack.Pop()
 			if err != nil {
 				return err
 			}
 
-			key, err := f.stack.Pop()
-			if err != nil {
-				return err
-			}
+			pairs[n] = top
+		}
 
-			hash, err := structhash.Hash(key, 1)
-			if err != nil {
+		m := object.NewMap(int(arg))
+
+		for n := 0; n < len(pairs); n += 2 {
+			if err := checkKey(pairs[n]); err != nil {
 				return err
 			}
 
-			keys[hash] = key
-			vals[hash] = val
+			m.Set(pairs[n], pairs[n+1])
 		}
 
-		return f.stack.Push(&object.Map{
-			Keys:   keys,
-			Values: vals,
-		})
+		return f.stack.Push(m)
 	}
-}
 
-func equalityEffector(shouldEqual bool) Effector {
-	return func(v *VM, f *Frame, arg rune) error {
-		right, err := f.stack.Pop()
+	Effectors[bytecode.MakeSlice] = func(v *VM, f *Frame, arg rune) error {
+		step, err := f.stack.Pop()
 		if err != nil {
 			return err
 		}
 
-		left, err := f.stack.Pop()
+		stop, err := f.stack.Pop()
 		if err != nil {
 			return err
 		}
 
-		if left.Equals(right) == shouldEqual {
-			return f.stack.Push(&object.Boolean{Value: true})
+		start, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		parts := []object.Object{start, stop, step}
+
+		for i, name := range []string{"start", "stop", "step"} {
+			if !object.IsSliceBound(parts[i]) {
+				return makeError(TypeError, "the %s of a slice must be an integer or nil, not %s", name, parts[i].String())
+			}
+		}
+
+		slice, _ := object.NewSlice(start, stop, step)
+
+		return f.stack.Push(slice)
+	}
+
+	Effectors[bytecode.UnpackSequence] = func(v *VM, f *Frame, arg rune) error {
+		top, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		items, ok := top.Items()
+		if !ok {
+			return makeError(TypeError, "cannot unpack a value of type %s", top.Type())
+		}
+
+		if len(items) != int(arg) {
+			return makeError(ArgumentError, "expected %d values to unpack, got %d", arg, len(items))
+		}
+
+		for i := len(items) - 1; i >= 0; i-- {
+			if err := f.stack.Push(items[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	Effectors[bytecode.UnpackKey] = func(v *VM, f *Frame, arg rune) error {
+		key, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		top, err := f.stack.Top()
+		if err != nil {
+			return err
+		}
+
+		m, ok := top.(*object.Map)
+		if !ok {
+			return makeError(TypeError, "cannot unpack keys from a value of type %s", top.Type())
+		}
+
+		val, ok := m.Subscript(key)
+		if !ok {
+			return makeError(IndexError, "key %s not found in the map", key)
+		}
+
+		return f.stack.Push(val)
+	}
+}
+
+// failMatch is called when a pattern in a match expression fails to match. The data
+// stack is restored to its height at the start of the match, and then the jump to the
+// next branch, [arg], is made.
+func failMatch(v *VM, f *Frame, arg rune) error {
+	if len(f.matches) == 0 {
+		return makeError(InternalError, "unexpected empty match input stack")
+	}
+
+	height := f.matches[len(f.matches)-1].height
+	f.stack.Objects = f.stack.Objects[:height]
+
+	return Effectors[bytecode.Jump](v, f, arg)
+}
+
+// typeMatches checks whether an object is of the named type, as used in a type pattern.
+// The name "number" matches any numeric type, i.e. integers and decimals as well.
+func typeMatches(o object.Object, name object.Type) bool {
+	if name == object.NumberType {
+		switch o.Type() {
+		case object.NumberType, object.IntegerType, object.DecimalType:
+			return true
+		}
+	}
+
+	return o.Type() == name
+}
+
+// membershipEffector makes an effector which checks whether $0 contains $1, or
+// doesn't if shouldContain is false. Instances of models can define __contains, or
+// __iter to be searched like any other iterable.
+func membershipEffector(shouldContain bool) Effector {
+	return func(v *VM, f *Frame, arg rune) error {
+		container, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		item, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		if method, ok := overload(container, "__contains"); ok {
+			result, err := v.invoke(f, method, item)
+			if err != nil {
+				return err
+			}
+
+			return f.stack.Push(&object.Boolean{Value: object.IsTruthy(result) == shouldContain})
+		}
+
+		if method, ok := overload(container, "__iter"); ok {
+			if container, err = v.invoke(f, method); err != nil {
+				return err
+			}
+		}
+
+		contains, ok := object.Contains(container, item)
+		if !ok {
+			return makeError(TypeError, "cannot check whether a value of type %s contains something", container.Type())
+		}
+
+		return f.stack.Push(&object.Boolean{Value: contains == shouldContain})
+	}
+}
+
+func equalityEffector(shouldEqual bool) Effector {
+	return func(v *VM, f *Frame, arg rune) error {
+		right, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		left, err := f.stack.Pop()
+		if err != nil {
+			return err
+		}
+
+		op := "=="
+		if !shouldEqual {
+			op = "!="
+		}
+
+		if result, ok, err := overloadInfix(v, f, op, left, right); ok {
+			if err != nil {
+				return err
+			}
+
+			return f.stack.Push(result)
+		}
+
+		if left.Equals(right) == shouldEqual {
+			return f.stack.Push(&object.Boolean{Value: true})
 		}
 
 		return f.stack.Push(&object.Boolean{Value: false})
 	}
 }
 
+// storeName sets the variable name to val, as Store.Set does, unless that would
+// reassign a constant.
+func storeName(f *Frame, name string, val object.Object, declare bool) error {
+	if f.store().IsConst(name, declare) {
+		return makeError(NameError, "cannot assign to the constant %s", name)
+	}
+
+	f.store().Set(name, val, declare)
+	return nil
+}
+
+// checkIndex checks that index isn't a fractional number being used to index a
+// sequence, which would otherwise be reported as a generic failure.
+func checkIndex(obj, index object.Object) error {
+	n, ok := index.(*object.Number)
+	if !ok || n.Value == math.Trunc(n.Value) {
+		return nil
+	}
+
+	switch obj.(type) {
+	case *object.List, *object.Tuple, *object.String:
+		return makeError(TypeError, "a %s can only be indexed by a whole number, not %s", obj.Type(), n.String())
+	}
+
+	return nil
+}
+
+// checkBounds checks that index, if it's a whole number, is within the bounds of a
+// sequence of the given length, which would otherwise be reported as a generic
+// failure. Whole numbers too big to be an index are always out of bounds.
+func checkBounds(index object.Object, length int) error {
+	switch n := index.(type) {
+	case *object.Integer:
+		if n.Big != nil {
+			return makeError(IndexError, "%s is out of bounds", n.String())
+		}
+
+	case *object.Number:
+		if _, ok := object.ToInt(n); !ok && n.Value == math.Trunc(n.Value) {
+			return makeError(IndexError, "%s is out of bounds", n.String())
+		}
+	}
+
+	i, ok := object.ToInt(index)
+	if !ok {
+		return nil
+	}
+
+	position := i
+	if position < 0 {
+		position += length
+	}
+
+	if position < 0 || position >= length {
+		return makeError(IndexError, "%d is out of bounds", i)
+	}
+
+	return nil
+}
+
+// checkSliceLength checks that, when assigning val to an extended slice of a list,
+// val has as many items as the slice selects.
+func checkSliceLength(obj, index, val object.Object) error {
+	list, ok := obj.(*object.List)
+	if !ok {
+		return nil
+	}
+
+	slice, ok := index.(*object.Slice)
+	if !ok {
+		return nil
+	}
+
+	items, ok := val.Items()
+	if !ok {
+		return nil
+	}
+
+	if count, ok := slice.Count(len(list.Value)); ok && count != len(items) {
+		return makeError(ArgumentError, "cannot assign %d items to a slice which selects %d", len(items), count)
+	}
+
+	return nil
+}
+
+// checkKey checks that key can be used as a key in a map.
+func checkKey(key object.Object) error {
+	if !object.IsHashable(key) {
+		return makeError(TypeError, "a value of type %s can't be used as a map key, since it isn't hashable", key.Type())
+	}
+
+	return nil
+}
+
 func unaryEffector(op string) Effector {
 	return func(v *VM, f *Frame, arg rune) error {
 		obj, err := f.stack.Pop()
@@ -478,6 +1004,14 @@ func unaryEffector(op string) Effector {
 			return err
 		}
 
+		if result, ok, err := overloadPrefix(v, f, op, obj); ok {
+			if err != nil {
+				return err
+			}
+
+			return f.stack.Push(result)
+		}
+
 		result, ok := obj.Prefix(op)
 		if !ok {
 			return makeError(TypeError, "could not apply prefix operator %s to %s", op, obj.String())
@@ -499,8 +1033,26 @@ func binaryEffector(op string) Effector {
 			return err
 		}
 
+		if result, ok, err := overloadInfix(v, f, op, left, right); ok {
+			if err != nil {
+				return err
+			}
+
+			return f.stack.Push(result)
+		}
+
+		if op == "/" {
+			if result, ok := divideDecimals(v, left, right); ok {
+				return f.stack.Push(result)
+			}
+		}
+
 		result, ok := left.Infix(op, right)
-		if !ok {
+		if !ok && dividesByZero(op, left, right) {
+			return makeError(RuntimeError, "cannot divide %s by zero", left.String())
+		} else if !ok && op == "^" && object.TooBigPower(left, right) {
+			return makeError(RuntimeError, "%s ^ %s is too big to compute", left.String(), right.String())
+		} else if !ok {
 			return makeError(TypeError, "could not apply infix operator %s between %s and %s", op, left.String(), right.String())
 		}
 
@@ -508,28 +1060,360 @@ func binaryEffector(op string) Effector {
 	}
 }
 
-func callFunction(v *VM, f *Frame, fn *object.Function, argCount rune) error {
-	if int(argCount) != len(fn.Parameters) {
-		return makeError(ArgumentError, "wrong amount of arguments passed to a function. expected %d, got %d", len(fn.Parameters), argCount)
+// dividesByZero checks whether op is a division operator, and left and right are
+// integers, or numeric values at least one of which is a decimal, where right is
+// zero. Dividing by a number follows floating point rules instead, so isn't an
+// error even if it's zero. Raising a decimal zero to a negative power also divides
+// by zero.
+func dividesByZero(op string, left, right object.Object) bool {
+	if op != "/" && op != "//" && op != "%" && op != "^" {
+		return false
 	}
 
-	store := NewStore(f.store())
+	_, leftDecimal := left.(*object.Decimal)
+	_, rightDecimal := right.(*object.Decimal)
+
+	if leftDecimal || rightDecimal {
+		l, ok := object.ToDecimal(left)
+		r, rok := object.ToDecimal(right)
+
+		if op == "^" {
+			return ok && rok && l.Unscaled.Sign() == 0 && r.Unscaled.Sign() < 0
+		}
+
+		return ok && rok && r.Unscaled.Sign() == 0
+	}
+
+	if _, ok := left.(*object.Integer); !ok || op == "^" {
+		return false
+	}
+
+	r, ok := right.(*object.Integer)
+	return ok && r.Big == nil && r.Value == 0
+}
+
+// divideDecimals divides left by right using the virtual machine's decimal
+// settings, if either of them is a Decimal. It returns false otherwise, or if they
+// can't be divided, so that their Infix methods can be used instead.
+func divideDecimals(v *VM, left, right object.Object) (object.Object, bool) {
+	_, leftDecimal := left.(*object.Decimal)
+	_, rightDecimal := right.(*object.Decimal)
 
-	for _, param := range fn.Parameters {
+	if !leftDecimal && !rightDecimal {
+		return nil, false
+	}
+
+	l, ok := object.ToDecimal(left)
+	if !ok {
+		return nil, false
+	}
+
+	r, ok := object.ToDecimal(right)
+	if !ok {
+		return nil, false
+	}
+
+	return l.Quo(r, v.Decimals)
+}
+
+// call calls an object with the given arguments. kwargs, which can be nil, maps
+// the names of any keyword arguments to their values.
+func call(v *VM, f *Frame, top object.Object, args []object.Object, kwargs map[string]object.Object) error {
+	switch fn := top.(type) {
+	case *object.Builtin:
+		if len(kwargs) == 0 && len(args) > 0 && len(args) < fn.Arity {
+			return f.stack.Push(&object.Partial{Fn: fn, Args: args, Arity: fn.Arity})
+		}
+
+		return callBuiltin(v, f, fn, args, kwargs)
+
+	case *object.Function:
+		if len(kwargs) == 0 && len(args) > 0 && len(args) < fn.Arity() {
+			return f.stack.Push(&object.Partial{Fn: fn, Args: args, Arity: fn.Arity()})
+		}
+
+		return callFunction(v, f, fn, args, kwargs)
+
+	case *object.Model:
+		if len(kwargs) == 0 && len(args) > 0 && len(args) < fn.Init.Arity() {
+			return f.stack.Push(&object.Partial{Fn: fn, Args: args, Arity: fn.Init.Arity()})
+		}
+
+		return callModel(v, f, fn, args, kwargs)
+
+	case *object.Partial:
+		return callPartial(v, f, fn, args, kwargs)
+	}
+
+	// Subscripting, as in a[b], calls the map with a one-element list, so a[b]
+	// isn't passed to __call
+	if method, ok := overload(top, "__call"); ok && !isSubscript(args) {
+		return call(v, f, method, args, kwargs)
+	}
+
+	if len(kwargs) > 0 {
+		return makeError(ArgumentError, "cannot pass keyword arguments to an object of type %s", top.Type())
+	}
+
+	if m, ok := top.(*object.Map); ok {
+		return indexMap(v, f, m, args)
+	}
+
+	if items, ok := top.Items(); ok {
+		return indexCollection(v, f, top, items, args)
+	}
+
+	return makeError(TypeError, "cannot call an object of type %s", top.Type())
+}
+
+func callBuiltin(v *VM, f *Frame, builtin *object.Builtin, args []object.Object, kwargs map[string]object.Object) error {
+	if len(kwargs) > 0 {
+		return makeError(ArgumentError, "cannot pass keyword arguments to the builtin %s", builtin.Name)
+	}
+
+	if stringifyingBuiltins[builtin.Name] {
+		for i, arg := range args {
+			str, err := stringify(v, f, arg)
+			if err != nil {
+				return err
+			}
+
+			args[i] = str
+		}
+	}
+
+	var (
+		result                  object.Object
+		errorType, errorMessage string
+	)
+
+	if builtin.Contextual != nil {
+		result, errorType, errorMessage = builtin.Contextual(v.context(f), args...)
+	} else {
+		result, errorType, errorMessage = builtin.Fn(args...)
+	}
+
+	if errorType != "" {
+		return makeError(ErrorType(errorType), errorMessage)
+	}
+
+	return f.stack.Push(result)
+}
+
+// caller makes a Caller, which builtins use to call the functions passed to them.
+func caller(v *VM, f *Frame) object.Caller {
+	return func(fn object.Object, args ...object.Object) (object.Object, string, string) {
+		result, err := v.invoke(f, fn, args...)
+		if err == nil {
+			return result, "", ""
+		}
+
+		if e, ok := err.(*Error); ok {
+			return nil, string(e.Type), e.Message
+		}
+
+		return nil, InternalError, err.Error()
+	}
+}
+
+// callPartial supplies more arguments to a Partial. If there are still too few,
+// another Partial is pushed, otherwise the underlying function is called with
+// the remembered arguments followed by the new ones.
+func callPartial(v *VM, f *Frame, p *object.Partial, args []object.Object, kwargs map[string]object.Object) error {
+	all := make([]object.Object, 0, len(p.Args)+len(args))
+	all = append(all, p.Args...)
+	all = append(all, args...)
+
+	if len(kwargs) == 0 && len(all) < p.Arity {
+		return f.stack.Push(&object.Partial{
+			Fn:    p.Fn,
+			Args:  all,
+			Arity: p.Arity,
+		})
+	}
+
+	switch fn := p.Fn.(type) {
+	case *object.Builtin:
+		return callBuiltin(v, f, fn, all, kwargs)
+
+	case *object.Function:
+		return callFunction(v, f, fn, all, kwargs)
+
+	case *object.Model:
+		return callModel(v, f, fn, all, kwargs)
+	}
+
+	return makeError(InternalError, "cannot partially apply an object of type %s", p.Fn.Type())
+}
+
+// isSubscript checks whether args are the arguments of a subscript, a[b], i.e. a
+// single list containing one item.
+func isSubscript(args []object.Object) bool {
+	if len(args) != 1 {
+		return false
+	}
+
+	list, ok := args[0].(*object.List)
+
+	return ok && len(list.Value) == 1
+}
+
+// popArgs pops argCount arguments from the stack, the first argument being at the top.
+func popArgs(f *Frame, argCount rune) ([]object.Object, error) {
+	args := make([]object.Object, 0, argCount)
+
+	for i := 0; i < int(argCount); i++ {
 		arg, err := f.stack.Pop()
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// popKeywords pops a tuple of keyword names, then a value for each name, returning
+// a map from each name to its value.
+func popKeywords(f *Frame) (map[string]object.Object, error) {
+	top, err := f.stack.Pop()
+	if err != nil {
+		return nil, err
+	}
+
+	names, ok := top.(*object.Tuple)
+	if !ok {
+		return nil, makeError(InternalError, "expected a tuple of keyword names, got a %s", top.Type())
+	}
+
+	kwargs := make(map[string]object.Object, len(names.Value))
+
+	for i := len(names.Value) - 1; i >= 0; i-- {
+		val, err := f.stack.Pop()
+		if err != nil {
+			return nil, err
 		}
 
-		store.Set(param, arg, true)
+		kwargs[names.Value[i].(*object.String).Value] = val
+	}
+
+	return kwargs, nil
+}
+
+// bindArguments binds the arguments of a call to the function's parameters in store.
+// Positional arguments are bound in order, then keyword arguments by name, then any
+// parameters still unbound take their default values. Extra positional arguments
+// are put in the rest parameter as a tuple.
+func bindArguments(store *Store, fn *object.Function, args []object.Object, kwargs map[string]object.Object) error {
+	var (
+		bound = make(map[string]bool, len(fn.Parameters))
+		extra []object.Object
+	)
+
+	if len(args) > len(fn.Parameters) {
+		if fn.Rest == "" {
+			return makeError(ArgumentError, "too many arguments passed to a function. expected at most %d, got %d", len(fn.Parameters), len(args))
+		}
+
+		args, extra = args[:len(fn.Parameters)], args[len(fn.Parameters):]
+	}
+
+	for i, arg := range args {
+		store.Set(fn.Parameters[i], arg, true)
+		bound[fn.Parameters[i]] = true
+	}
+
+	for name, val := range kwargs {
+		known := false
+
+		for _, param := range fn.Parameters {
+			if param == name {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return makeError(ArgumentError, "unexpected keyword argument %s", name)
+		}
+
+		if bound[name] {
+			return makeError(ArgumentError, "more than one value passed for the parameter %s", name)
+		}
+
+		store.Set(name, val, true)
+		bound[name] = true
+	}
+
+	firstDefault := len(fn.Parameters) - len(fn.Defaults)
+
+	for i, param := range fn.Parameters {
+		if bound[param] {
+			continue
+		}
+
+		if i < firstDefault {
+			return makeError(ArgumentError, "missing an argument for the parameter %s", param)
+		}
+
+		// Each call gets its own copy of a default value, so that mutating it
+		// doesn't change the default for later calls
+		store.Set(param, object.Copy(fn.Defaults[i-firstDefault]), true)
+	}
+
+	if fn.Rest != "" {
+		store.Set(fn.Rest, &object.Tuple{Value: extra}, true)
+	}
+
+	return nil
+}
+
+func callFunction(v *VM, f *Frame, fn *object.Function, args []object.Object, kwargs map[string]object.Object) error {
+	store := NewStore(f.store())
+
+	if err := bindArguments(store, fn, args, kwargs); err != nil {
+		return err
+	}
+
+	if err := checkArguments(v, store, fn); err != nil {
+		return err
 	}
 
 	if fn.Self != nil {
 		store.Set("self", fn.Self, true)
 	}
 
+	pushFunctionFrame(v, f, fn, store)
+
+	return nil
+}
+
+// callModel makes an instance of a model, by calling its Init function with __model
+// declared as the model.
+func callModel(v *VM, f *Frame, model *object.Model, args []object.Object, kwargs map[string]object.Object) error {
+	store := NewStore(f.store())
+
+	if err := bindArguments(store, model.Init, args, kwargs); err != nil {
+		return err
+	}
+
+	if err := checkArguments(v, store, model.Init); err != nil {
+		return err
+	}
+
+	store.Set("__model", model, true)
+
+	pushFunctionFrame(v, f, model.Init, store)
+
+	return nil
+}
+
+// pushFunctionFrame pushes a frame to execute fn's code, with store as its scope.
+func pushFunctionFrame(v *VM, f *Frame, fn *object.Function, store *Store) {
 	frame := &Frame{
 		prev:      f,
+		fn:        fn,
 		code:      fn.Code,
 		offset:    0,
 		vm:        v,
@@ -541,38 +1425,84 @@ func callFunction(v *VM, f *Frame, fn *object.Function, argCount rune) error {
 	}
 
 	f.vm.PushFrame(frame)
-
-	return nil
 }
 
-func indexCollection(v *VM, f *Frame, items []object.Object, argCount rune) error {
-	if argCount != 1 {
+// indexCollection indexes a collection, whose items are items. The index can be
+// negative to count from the end, or a slice.
+func indexCollection(v *VM, f *Frame, top object.Object, items []object.Object, args []object.Object) error {
+	if len(args) != 1 {
 		return makeError(ArgumentError, "a list can only be called with one argument")
 	}
 
-	arg, err := f.stack.Pop()
-	if err != nil {
-		return err
-	}
-
 	var indexObj object.Object
 
-	if argItems, ok := arg.Items(); ok && len(argItems) == 1 {
+	if argItems, ok := args[0].Items(); ok && len(argItems) == 1 {
 		indexObj = argItems[0]
 	} else {
-		indexObj = arg
+		indexObj = args[0]
 	}
 
-	idx, ok := indexObj.(*object.Number)
-	if !ok {
-		return makeError(ArgumentError, "a list can only be called with a number or a length-1 list/tuple containing a number")
+	if slice, ok := indexObj.(*object.Slice); ok {
+		if _, _, _, ok := slice.Indices(len(items)); !ok {
+			return makeError(ArgumentError, "the step of a slice can't be zero")
+		}
+
+		result, ok := top.Subscript(slice)
+		if !ok {
+			return makeError(TypeError, "cannot slice a value of type %s", top.Type())
+		}
+
+		return f.stack.Push(result)
 	}
 
-	index := int(math.Floor(idx.Value))
+	if err := checkIndex(top, indexObj); err != nil {
+		return err
+	}
 
-	if index < 0 || index >= len(items) {
-		return makeError(IndexError, "%d is out of bounds", index)
+	if err := checkBounds(indexObj, len(items)); err != nil {
+		return err
+	}
+
+	index, ok := object.ToInt(indexObj)
+	if !ok {
+		return makeError(ArgumentError, "a list can only be called with a number, a slice, or a length-1 list/tuple containing either")
+	}
+
+	if index < 0 {
+		index += len(items)
 	}
 
 	return f.stack.Push(items[index])
 }
+
+func indexMap(v *VM, f *Frame, m *object.Map, args []object.Object) error {
+	if len(args) != 1 {
+		return makeError(ArgumentError, "a map can only be called with one argument")
+	}
+
+	key := args[0]
+
+	if list, ok := key.(*object.List); ok && len(list.Value) == 1 {
+		key = list.Value[0]
+	}
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	val, ok := m.Subscript(key)
+	if ok {
+		return f.stack.Push(val)
+	}
+
+	if method, ok := overload(m, "__index"); ok {
+		val, err := v.invoke(f, method, key)
+		if err != nil {
+			return err
+		}
+
+		return f.stack.Push(val)
+	}
+
+	return makeError(IndexError, "key %s not found in the map", key.String())
+}
@@ -30,6 +30,14 @@ const (
 
 	// IndexError is used when an invalid index/key is used.
 	IndexError = "Index"
+
+	// IOError is used when reading or writing a file fails, or a path is outside of the
+	// file root.
+	IOError = "IO"
+
+	// ExitError is used when the program calls exit. It stops the program like any other
+	// error, but Run returns an *Exit instead of it.
+	ExitError = "Exit"
 )
 
 // An Error represents any type of runtime error (not just RuntimeError), and implements
@@ -9,6 +9,7 @@ import (
 // the bytecode to execute, along with the frame's constants and names, and other data.
 type Frame struct {
 	prev          *Frame
+	fn            *object.Function
 	code          bytecode.Code
 	offset        int
 	vm            *VM
@@ -18,10 +19,17 @@ type Frame struct {
 	constants     []object.Object
 	names         []string
 	jumps         []int
-	matchInputs   []object.Object
+	matches       []matchState
 	iterStack     []object.Iterable
 }
 
+// A matchState stores the input to a match expression, along with the height of the
+// data stack when the match started, to which it's restored if a pattern fails.
+type matchState struct {
+	input  object.Object
+	height int
+}
+
 func (f *Frame) offsetToInstructionIndex(offset int) int {
 	var index, counter int
 
@@ -0,0 +1,119 @@
+package runtime
+
+import (
+	"github.com/Zac-Garby/radon/object"
+)
+
+// infixMethods maps each infix operator which can be overloaded to the name of the
+// method which overloads it.
+var infixMethods = map[string]string{
+	"+":  "__add",
+	"-":  "__sub",
+	"*":  "__mul",
+	"/":  "__div",
+	"^":  "__pow",
+	"//": "__floor-div",
+	"%":  "__mod",
+	"|":  "__or",
+	"&":  "__and",
+	"==": "__eq",
+	"<":  "__lt",
+	">":  "__gt",
+	"<=": "__le",
+	">=": "__ge",
+}
+
+// invertedOperators maps operators to the operators they're the inverse of. If an
+// operator isn't overloaded itself, but its inverse is, the result of the inverse
+// is inverted, so a != b is !(a == b), a >= b is !(a < b) and a <= b is !(a > b).
+var invertedOperators = map[string]string{
+	"!=": "==",
+	">=": "<",
+	"<=": ">",
+}
+
+// prefixMethods maps each prefix operator which can be overloaded to the name of
+// the method which overloads it.
+var prefixMethods = map[string]string{
+	"-": "__neg",
+	"!": "__not",
+}
+
+// stringifyingBuiltins are the builtins which convert their arguments to strings.
+// Instances of models with a __str method are converted by calling it before being
+// passed to them.
+var stringifyingBuiltins = map[string]bool{
+	"print":  true,
+	"put":    true,
+	"str":    true,
+	"format": true,
+}
+
+// overload returns the method called name, bound to o, if o is an instance of a
+// model which defines it.
+func overload(o object.Object, name string) (object.Object, bool) {
+	m, ok := o.(*object.Map)
+	if !ok {
+		return nil, false
+	}
+
+	return m.Method(&object.String{Value: name})
+}
+
+// overloadInfix applies an infix operator to left and right if left is an instance
+// of a model which overloads it. The second return value is false if it doesn't.
+func overloadInfix(v *VM, f *Frame, op string, left, right object.Object) (object.Object, bool, error) {
+	if method, ok := overload(left, infixMethods[op]); ok {
+		result, err := v.invoke(f, method, right)
+		return result, true, err
+	}
+
+	inverse, ok := invertedOperators[op]
+	if !ok {
+		return nil, false, nil
+	}
+
+	method, ok := overload(left, infixMethods[inverse])
+	if !ok {
+		return nil, false, nil
+	}
+
+	result, err := v.invoke(f, method, right)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return &object.Boolean{Value: !object.IsTruthy(result)}, true, nil
+}
+
+// overloadPrefix applies a prefix operator to obj if it's an instance of a model
+// which overloads it. The second return value is false if it doesn't.
+func overloadPrefix(v *VM, f *Frame, op string, obj object.Object) (object.Object, bool, error) {
+	method, ok := overload(obj, prefixMethods[op])
+	if !ok {
+		return nil, false, nil
+	}
+
+	result, err := v.invoke(f, method)
+	return result, true, err
+}
+
+// stringify converts obj to a String using its __str method, if it's an instance of
+// a model which defines one. Otherwise, obj is returned unchanged.
+func stringify(v *VM, f *Frame, obj object.Object) (object.Object, error) {
+	method, ok := overload(obj, "__str")
+	if !ok {
+		return obj, nil
+	}
+
+	result, err := v.invoke(f, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := result.(*object.String); !ok {
+		return nil, makeError(TypeError, "__str should return a string, not a %s", result.Type())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/Zac-Garby/radon/object"
+)
+
+// An Exit is returned by Run when the program calls exit, instead of an Error, so
+// that whatever's running the program can exit with the same code.
+type Exit struct {
+	Code int
+}
+
+func (e *Exit) Error() string {
+	return fmt.Sprintf("exited with code %d", e.Code)
+}
+
+// stream returns the Stream which reads from the virtual machine's input. It's
+// made the first time it's needed, so In can be changed until then, and it's
+// shared by input and stdin so neither loses the other's buffered input.
+func (v *VM) stream() *object.Stream {
+	if v.stdin == nil {
+		v.stdin = object.NewStream("stdin", v.In)
+	}
+
+	return v.stdin
+}
+
+// context makes the Context given to contextual builtins called from f.
+func (v *VM) context(f *Frame) *object.Context {
+	return &object.Context{
+		Call: caller(v, f),
+		In:   v.stream(),
+		Out:  v.Out,
+		Env:  v.Env,
+
+		FileRoot: v.FileRoot,
+		Decimals: &v.Decimals,
+		Random:   v.Random,
+		Exit: func(code int) {
+			v.exitCode = code
+		},
+	}
+}
+
+// defineProcess defines the variables which hold the process running the program
+// in store, unless they've already been defined there. A store can be used for more
+// than one program, such as each line of the REPL, so they're only defined by the
+// first one, and the program can reassign them.
+//
+//   - stdin is a stream of the lines of input
+//   - args is a list of the program's command-line arguments
+func (v *VM) defineProcess(store *Store) {
+	if _, ok := store.Data["stdin"]; !ok {
+		store.Set("stdin", v.stream(), true)
+	}
+
+	if _, ok := store.Data["args"]; !ok {
+		args := make([]object.Object, len(v.Args))
+
+		for i, arg := range v.Args {
+			args[i] = &object.String{Value: arg}
+		}
+
+		store.Set("args", &object.List{Value: args}, true)
+	}
+}
@@ -3,10 +3,11 @@ package runtime
 import "github.com/Zac-Garby/radon/object"
 
 // A Variable represents a Radon variable. A Variable includes a Name
-// and Value.
+// and Value. A constant Variable, declared with const, can't be reassigned.
 type Variable struct {
 	Name  string
 	Value object.Object
+	Const bool
 }
 
 // A Store contains all the variables defined in a particular scope. A
@@ -16,27 +17,51 @@ type Store struct {
 	Enclosing *Store
 }
 
+// builtins contains a Variable for each builtin function and module. They're found
+// when a name isn't defined in any scope, so a variable with the same name hides
+// one without replacing it.
+var builtins = makeBuiltins()
+
+func makeBuiltins() map[string]*Variable {
+	vars := make(map[string]*Variable, len(object.Builtins)+len(object.Modules))
+
+	for k, v := range object.Builtins {
+		vars[k] = &Variable{Name: k, Value: v}
+	}
+
+	for k, v := range object.Modules {
+		vars[k] = &Variable{Name: k, Value: v}
+	}
+
+	return vars
+}
+
 // NewStore creates a new empty store with the given enclosing scope (can be nil).
 func NewStore(enclosing *Store) *Store {
-	s := &Store{
+	return &Store{
 		Data:      make(map[string]*Variable),
 		Enclosing: enclosing,
 	}
+}
 
-	for k, v := range object.Builtins {
-		s.Set(k, v, true)
+// Get gets a variable from the store. If it isn't found, it checks the enclosing scope,
+// and so on, and then the builtins.
+func (s *Store) Get(name string) (*Variable, bool) {
+	if v, ok := s.lookup(name); ok {
+		return v, true
 	}
 
-	return s
+	v, ok := builtins[name]
+	return v, ok
 }
 
-// Get gets a variable from the store. If it isn't found, it checks the enclosing scope,
-// and so on.
-func (s *Store) Get(name string) (*Variable, bool) {
+// lookup gets a variable from the store or its enclosing scopes, without checking
+// the builtins.
+func (s *Store) lookup(name string) (*Variable, bool) {
 	v, ok := s.Data[name]
 	if !ok {
 		if s.Enclosing != nil {
-			return s.Enclosing.Get(name)
+			return s.Enclosing.lookup(name)
 		}
 		return nil, false
 	}
@@ -45,10 +70,11 @@ func (s *Store) Get(name string) (*Variable, bool) {
 }
 
 // Set sets a variable in the store. If declare is false, enclosing scopes will be
-// assigned to instead of this one, if the variable is already defined there.
+// assigned to instead of this one, if the variable is already defined there and
+// not in this one.
 func (s *Store) Set(name string, val object.Object, declare bool) {
-	if !declare && s.Enclosing != nil {
-		if _, ok := s.Enclosing.Get(name); ok {
+	if _, here := s.Data[name]; !declare && !here && s.Enclosing != nil {
+		if _, ok := s.Enclosing.lookup(name); ok {
 			s.Enclosing.Set(name, val, false)
 			return
 		}
@@ -59,3 +85,14 @@ func (s *Store) Set(name string, val object.Object, declare bool) {
 		Value: val,
 	}
 }
+
+// IsConst checks whether the variable which Set would set, given the same name and
+// value of declare, is a constant.
+func (s *Store) IsConst(name string, declare bool) bool {
+	v, ok := s.Data[name]
+	if !ok && !declare {
+		v, ok = s.Get(name)
+	}
+
+	return ok && v.Const
+}
@@ -42,3 +42,50 @@ func TestStoreCanHandleVariables(t *testing.T) {
 		t.Error("variable 'foo' from scope E doesn't equal 'foo' from scope S")
 	}
 }
+
+func TestStoreScoping(t *testing.T) {
+	var (
+		outer = NewStore(nil)
+		inner = NewStore(outer)
+	)
+
+	outer.Set("x", &object.Number{Value: 1}, true)
+
+	// Declaring a name makes a new variable in this scope, shadowing the outer one
+	inner.Set("x", &object.Number{Value: 2}, true)
+
+	// Assigning to a shadowed name changes the variable in this scope
+	inner.Set("x", &object.Number{Value: 3}, false)
+
+	// Assigning to a name only defined in an enclosing scope changes that one
+	outer.Set("y", &object.Number{Value: 4}, true)
+	inner.Set("y", &object.Number{Value: 5}, false)
+
+	// Assigning to an undefined name makes a new variable in this scope
+	inner.Set("z", &object.Number{Value: 6}, false)
+
+	expected := []struct {
+		store *Store
+		name  string
+		value float64
+	}{
+		{outer, "x", 1},
+		{inner, "x", 3},
+		{outer, "y", 5},
+		{inner, "y", 5},
+		{inner, "z", 6},
+	}
+
+	for _, e := range expected {
+		v, ok := e.store.Get(e.name)
+		if !ok {
+			t.Errorf("variable '%s' couldn't be retrieved", e.name)
+		} else if !v.Value.Equals(&object.Number{Value: e.value}) {
+			t.Errorf("variable '%s' is %s, expected %v", e.name, v.Value, e.value)
+		}
+	}
+
+	if _, ok := outer.Get("z"); ok {
+		t.Error("variable 'z' was assigned in scope E but is defined in scope S")
+	}
+}
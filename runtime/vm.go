@@ -2,7 +2,9 @@ package runtime
 
 import (
 	"io"
+	"math/rand"
 	"os"
+	"time"
 
 	"github.com/Zac-Garby/radon/bytecode"
 	"github.com/Zac-Garby/radon/object"
@@ -29,6 +31,41 @@ type VM struct {
 	// Out is the io.Writer to which the virtual machine outputs to. This includes functions
 	// like print, but also errors and various messages.
 	Out io.Writer
+
+	// In is the io.Reader from which the virtual machine reads input, through the stdin
+	// stream and the input builtin.
+	In io.Reader
+
+	// Args are the command-line arguments passed to the program, not including the
+	// interpreter and the program's name. They're available to the program as args.
+	Args []string
+
+	// Env looks up an environment variable, returning false if it isn't set. It's used by
+	// the env builtin, and is os.LookupEnv by default. If it's nil, no variables are set.
+	Env func(name string) (string, bool)
+
+	// FileRoot is the directory which the fs module is confined to. If it's empty, which
+	// is the default, programs can access any file. Otherwise, every path is relative to
+	// FileRoot, even absolute ones, and paths which would leave it, including through
+	// symbolic links, are rejected. Paths returned by fs.glob are relative to FileRoot.
+	FileRoot string
+
+	// Decimals holds the settings for decimal arithmetic, which the program can change
+	// with decimal-precision and decimal-rounding. They start as the defaults, and only
+	// affect this virtual machine.
+	Decimals object.DecimalContext
+
+	// Random is the generator used by the random module. It's seeded with the current
+	// time by default, and the program can reseed it with random.seed without affecting
+	// any other virtual machine.
+	Random *rand.Rand
+
+	// EnforceAnnotations specifies whether the type and model annotations of parameters,
+	// return values and variables are checked at runtime. Protocols are always checked.
+	EnforceAnnotations bool
+
+	stdin    *object.Stream
+	exitCode int
 }
 
 // New creates a new virtual machine.
@@ -41,6 +78,10 @@ func New() *VM {
 		storePool:  NewStorePool(),
 		Interrupts: make(chan Interrupt, InterruptQueueSize),
 		Out:        os.Stdout,
+		In:         os.Stdin,
+		Env:        os.LookupEnv,
+		Decimals:   object.DefaultDecimalContext(),
+		Random:     rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -49,15 +90,15 @@ func New() *VM {
 // store (note: declared, not assigned).
 func (v *VM) MakeFrame(code bytecode.Code, args, store *Store, constants []object.Object, names []string, jumps []int) *Frame {
 	frame := &Frame{
-		code:        code,
-		stores:      []*Store{store},
-		offset:      0,
-		stack:       NewStack(),
-		constants:   constants,
-		names:       names,
-		jumps:       jumps,
-		vm:          v,
-		matchInputs: make([]object.Object, 0),
+		code:      code,
+		stores:    []*Store{store},
+		offset:    0,
+		stack:     NewStack(),
+		constants: constants,
+		names:     names,
+		jumps:     jumps,
+		vm:        v,
+		matches:   make([]matchState, 0),
 	}
 
 	if args != nil {
@@ -69,6 +110,17 @@ func (v *VM) MakeFrame(code bytecode.Code, args, store *Store, constants []objec
 	return frame
 }
 
+// Reset empties the call stack and clears the error, so that the virtual machine can
+// run another program, such as the next line of the REPL. Its settings, including
+// its decimal settings and random generator, are kept.
+func (v *VM) Reset() {
+	v.frames = v.frames[:0]
+	v.frame = nil
+	v.returnVal = nil
+	v.err = nil
+	v.exitCode = 0
+}
+
 // PushFrame pushes a frame to the top of the call stack.
 func (v *VM) PushFrame(frame *Frame) {
 	v.frames = append(v.frames, frame)
@@ -102,9 +154,44 @@ func (v *VM) Error() error {
 
 // Run executes a virtual machine, starting from the most recently pushed frame. If, after
 // execution, any values are left in the top frame, the top one will be returned. It will
-// also return, if any, a runtime error.
+// also return, if any, a runtime error, or an *Exit if the program called exit.
 func (v *VM) Run() (object.Object, error) {
-main:
+	if len(v.frames) > 0 {
+		v.defineProcess(v.frames[0].store())
+	}
+
+	_, v.err = v.run(0)
+
+	if e, ok := v.err.(*Error); ok && e.Type == ExitError {
+		v.err = &Exit{Code: v.exitCode}
+	}
+
+	return v.ExtractValue(), v.err
+}
+
+// invoke calls fn with args from Go code, such as an effector, and executes the virtual
+// machine until it returns, returning its result. It's used to call the methods which
+// models can define to overload operators.
+func (v *VM) invoke(f *Frame, fn object.Object, args ...object.Object) (object.Object, error) {
+	base := len(v.frames)
+
+	if err := call(v, f, fn, args, nil); err != nil {
+		return nil, err
+	}
+
+	// Builtins, and anything else which doesn't push a frame, push their result
+	// straight away
+	if len(v.frames) == base {
+		return f.stack.Pop()
+	}
+
+	return v.run(base)
+}
+
+// run executes instructions until the frame at index base in the call stack finishes,
+// returning the value it leaves on its data stack, or Nil if it doesn't leave one. The
+// bottom frame is never popped, so its values can still be extracted afterwards.
+func (v *VM) run(base int) (object.Object, error) {
 	for {
 		// Handle interrupts first
 		for len(v.Interrupts) > 0 {
@@ -112,7 +199,11 @@ main:
 
 			switch interrupt {
 			case Stop:
-				break main
+				if base > 0 {
+					return nil, makeError(RuntimeError, "the virtual machine was stopped")
+				}
+
+				return nil, nil
 
 			case Pause:
 				for {
@@ -124,7 +215,7 @@ main:
 		}
 
 		if len(v.frames) == 0 {
-			break
+			return nil, nil
 		}
 
 		// This may be able to be optimized slightly by putting it outside the loop
@@ -132,23 +223,39 @@ main:
 
 		if top.offset >= len(top.code) {
 			if len(v.frames) == 1 {
-				break
-			} else {
-				v.PopFrame()
+				return nil, nil
+			}
 
-				if top.stack.Len() > 0 {
-					next := v.frames[len(v.frames)-1]
+			v.PopFrame()
 
-					ret, err := top.stack.Pop()
-					if err != nil {
-						v.err = err
-						break
-					}
+			var ret object.Object
 
-					if err := next.stack.Push(ret); err != nil {
-						v.err = err
-						break
-					}
+			if top.stack.Len() > 0 {
+				var err error
+				if ret, err = top.stack.Pop(); err != nil {
+					return nil, err
+				}
+			}
+
+			if top.fn != nil {
+				if err := checkReturn(v, top.fn, ret); err != nil {
+					return nil, err
+				}
+			}
+
+			if len(v.frames) == base {
+				if ret == nil {
+					ret = &object.Nil{}
+				}
+
+				return ret, nil
+			}
+
+			if ret != nil {
+				next := v.frames[len(v.frames)-1]
+
+				if err := next.stack.Push(ret); err != nil {
+					return nil, err
 				}
 			}
 
@@ -162,16 +269,12 @@ main:
 		// Decode
 		eff := Effectors[instr.Code]
 		if eff == nil {
-			v.err = makeError(InternalError, "instruction %s not yet implemented", instr.Name)
-			break
+			return nil, makeError(InternalError, "instruction %s not yet implemented", instr.Name)
 		}
 
 		// Execute :)
 		if err := eff(v, top, instr.Arg); err != nil {
-			v.err = err
-			break
+			return nil, err
 		}
 	}
-
-	return v.ExtractValue(), v.err
 }
@@ -0,0 +1,1618 @@
+package runtime_test
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Zac-Garby/radon/bytecode"
+	"github.com/Zac-Garby/radon/compiler"
+	"github.com/Zac-Garby/radon/lexer"
+	"github.com/Zac-Garby/radon/object"
+	"github.com/Zac-Garby/radon/parser"
+	. "github.com/Zac-Garby/radon/runtime"
+)
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"match 2 where | 1 -> 10, | 2 -> 20":                       "20",
+		"match 3 where | 1 -> 10, | 2 -> 20":                       "nil",
+		"match 3 where | _ -> 0, | 1 -> 10":                        "0",
+		"match 3 where | 1 -> 10, | _ -> 0":                        "0",
+		"match (1, 2) where | (a, b) -> a + b":                     "3",
+		"match (1, 2) where | (a, b, c) -> 0, | _ -> 1":            "1",
+		"match (1, 2) where | [a, b] -> 0, | _ -> 1":               "1",
+		"match [1, 2, 3] where | [h, ...t] -> t":                   "[2, 3]",
+		"match [1] where | [h, ...t] -> t":                         "[]",
+		"match [] where | [h, ...t] -> 0, | [] -> 1":               "1",
+		"match (1, 2, 3) where | (_, ...t) -> t":                   "(2, 3)",
+		"match (1, [2, 3]) where | (1, [a, b]) -> a * b":           "6",
+		"match (2, [2, 3]) where | (1, [a, b]) -> 0, | _ -> 1":     "1",
+		`match {"name": "x"} where | {name: n} -> n`:               `"x"`,
+		`match {"age": 1} where | {name: n} -> n, | _ -> 0`:        "0",
+		`match {1: 2} where | {1: n} -> n`:                         "2",
+		"match 5 where | number n -> n":                            "5",
+		"match 5.5 where | number n -> n":                          "5.5",
+		"match 5 where | integer n -> n":                           "5",
+		"match 5.5 where | integer n -> 0, | _ -> 1":               "1",
+		`match "a" where | number n -> 0, | string s -> s`:         `"a"`,
+		"match 5 where | x if x > 3 -> 1, | _ -> 0":                "1",
+		"match 2 where | x if x > 3 -> 1, | _ -> 0":                "0",
+		"match 2 where | x if x > 3 -> 1, | x -> x * 10":           "20",
+		"match (1, 2) where | (a, b) if a > b -> a, | (a, b) -> b": "2",
+
+		// Names bound by a pattern are local to their branch
+		"x = 1; match 5 where | x -> x; x":            "1",
+		"x = 1; match 5 where | x -> do x = 7 end; x": "1",
+
+		// A pinned name matches the variable's value instead of binding the name
+		`plus = "+"; match "-" where | ^plus -> 1, | _ -> 0`: "0",
+		`plus = "+"; match "+" where | ^plus -> 1, | _ -> 0`: "1",
+		`plus = "+"; match "-" where | plus -> plus`:         `"-"`,
+		"x = 2; match (1, 2) where | (a, ^x) -> a, | _ -> 0": "1",
+		"x = 3; match (1, 2) where | (a, ^x) -> a, | _ -> 0": "0",
+		"x = 1; match [2] where | [^(x + 1)] -> 1, | _ -> 0": "1",
+		"x = 1; match 2 where | ^x -> 1, | x -> x; x":        "1",
+
+		// The data stack is restored after a partial match fails
+		"match (1, (2, 3)) where | (a, (b, 4)) -> 0, | (a, (b, c)) -> a + b + c": "6",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestMatchBindingScope(t *testing.T) {
+	tests := []string{
+		"match 5 where | y -> y; y",
+		"match 2 where | n if n > 5 -> 1, | _ -> n",
+		"match [1, 2] where | [a, 5] -> 1, | _ -> a",
+	}
+
+	expectErrorType(t, "", tests, NameError)
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := map[string]string{
+		"a = 1; b = 2; a, b = b, a; a, b":                                  "(2, 1)",
+		"x, y = (10, 20); x + y":                                           "30",
+		"x, y := 10, 20; x - y":                                            "-10",
+		"[p, q] = [3, 4]; p * q":                                           "12",
+		"(m, n), o = (1, 2), 3; m, n, o":                                   "(1, 2, 3)",
+		"[a, [b, c]] = (1, [2, 3]); a + b + c":                             "6",
+		"first, _ = 8, 9; first":                                           "8",
+		`{name: n, "age": a} = {"name": "x", "age": 5}; n`:                 `"x"`,
+		`{name: n, "age": a} = {"name": "x", "age": 5}; a`:                 "5",
+		"xs = [1, 2]; (xs[0]), (xs[1]) = (xs[1]), (xs[0]); xs":             "[2, 1]",
+		`m = {"a": 1, "b": 2}; (m["a"]), (m["b"]) = (m["b"]), (m["a"]); m`: `{"a": 2, "b": 1}`,
+
+		"total = 0; for k, v in {1: 2, 3: 4} do total = total + k * v end; total":               "14",
+		"total = 0; for (a, b) in [(1, 2), (3, 4)] do total = total + a * b end; total":         "14",
+		"total = 0; for x in [1, 2] do for y in (3, 4) do total = total + x * y end end; total": "21",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"a, b = 1, 2, 3":             ArgumentError,
+		"a, b, c = 1, 2":             ArgumentError,
+		"[a] = []":                   ArgumentError,
+		"a, b = 5":                   TypeError,
+		`{x: y} = {"z": 1}`:          IndexError,
+		"{x: y} = [1]":               TypeError,
+		"for a, b in [1, 2], a":      TypeError,
+		"for a, b in [(1, 2, 3)], a": ArgumentError,
+	}
+
+	expectErrors(t, "", tests)
+
+	// A subscript is a call with a list, so unparenthesised subscripts in a tuple
+	// of targets would define a function instead.
+	for _, test := range []string{"xs = [1, 2]; xs[0], xs[1] = 3, 4", "xs = [1, 2]; xs[0], xs[1] := 3, 4"} {
+		_, err := run(test)
+		if err == nil || !strings.Contains(err.Error(), "must be parenthesised") {
+			t.Errorf("%s: expected a compile error, got %v\n", test, err)
+		}
+	}
+}
+
+func TestComparison(t *testing.T) {
+	tests := map[string]string{
+		"3 > 2":                  "true",
+		"2 > 3":                  "false",
+		"2 > 2":                  "false",
+		"2.5 > 2":                "true",
+		"(decimal 3) > 2.5":      "true",
+		"\"b\" > \"a\"":          "true",
+		"[3 > 2, 3 >= 3, 2 < 3]": "[true, true, true]",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestDeclaration(t *testing.T) {
+	tests := map[string]string{
+		"x := 1; x": "1",
+		"x = 1; f y = do x := 2; x end; [(f 0), x]": "[2, 1]",
+		"x = 1; f y = do x := y end; f 5; f 6; x":   "1",
+		"x = 1; if true do x := 2 end; x":           "1",
+
+		// A parameter shadows a variable of the same name
+		"x = 1; f x = do x := x + 1; x end; [(f 5), x]": "[6, 1]",
+		"x = 1; f x = do x = x + 1; x end; [(f 5), x]":  "[6, 1]",
+
+		// A declaration in a nested function shadows the enclosing function's variable
+		"f () = do x := 1; g () = do x := 2 end; g (); x end; f ()": "1",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestAssignmentScope(t *testing.T) {
+	tests := map[string]string{
+		"x = 1; f y = do x = 2 end; f 0; x":                    "2",
+		"x = 1; f y = do x := 2; x = 3; x end; [(f 0), x]":     "[3, 1]",
+		"x = 1; f y = do x := 2; x = y; x end; f 5; x":         "1",
+		"x = 1; match 5 where | n if n > 0 -> do x = n end; x": "5",
+
+		// An assignment changes the innermost scope which has the name
+		"f () = do x := 1; g () = do x = 2 end; g (); x end; f ()":               "2",
+		"x = 1; f () = do x := 2; g () = do x = 3 end; g (); x end; [(f ()), x]": "[3, 1]",
+
+		// An assignment to an undefined name makes a variable in the current scope
+		"f () = do y = 1; y end; f (); f ()": "1",
+	}
+
+	expectOutputs(t, "", tests)
+	expectErrorType(t, "", []string{"f () = do y = 1 end; f (); y"}, NameError)
+}
+
+func TestBuiltinScope(t *testing.T) {
+	tests := map[string]string{
+		`path = "a"; f () = path + "b"; f ()`:             `"ab"`,
+		"lines = 1; f () = do lines = 5 end; f (); lines": "5",
+		"f () = do lines = 5 end; f (); type lines":       `"builtin"`,
+		"f x = len x; f [1, 2]":                           "2",
+		"math = 5; math":                                  "5",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestPartialApplication(t *testing.T) {
+	tests := map[string]string{
+		"add x, y, z = x + y + z; add 1":                        "<partial (2)>",
+		"add x, y, z = x + y + z; (add 1) 2":                    "<partial (1)>",
+		"add x, y, z = x + y + z; ((add 1) 2) 3":                "6",
+		"add x, y, z = x + y + z; (add 1, 2) 3":                 "6",
+		"add x, y, z = x + y + z; (add 1) 2, 3":                 "6",
+		"add x, y, z = x + y + z; inc = add 0, 1; inc 5; inc 9": "10",
+		"infix 1":                        "<partial (2)>",
+		`(infix 1, "+") 2`:               "3",
+		"add x, y = x + y; type (add 1)": `"function"`,
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestPipeAndApply(t *testing.T) {
+	prelude := `
+		add x, y = x + y
+		double x = x * 2
+		map f, xs = do
+			r = []
+			for x in xs do r = r + [f x] end
+			r
+		end
+	`
+
+	tests := map[string]string{
+		"5 |> double":                       "10",
+		"1, 2 |> add":                       "3",
+		"3 |> add 1 |> double":              "8",
+		"double $ add 1, 2":                 "6",
+		"double $ double $ 3":               "12",
+		"y = 4 |> double; y":                "8",
+		"[1, 2, 3] |> map double":           "[2, 4, 6]",
+		"[1, 2] |> map (add 10) |> len":     "2",
+		"map double $ [1, 2] |> map double": "[4, 8]",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestParameters(t *testing.T) {
+	prelude := `
+		greet name, greeting = "hi" = greeting + ", " + name
+		f a, b = 1, c = 2 = a, b, c
+		g first, ...rest = first, rest
+		add x, y = x + y
+	`
+
+	tests := map[string]string{
+		`greet "bob"`:                       `"hi, bob"`,
+		`greet "bob", "yo"`:                 `"yo, bob"`,
+		`greet "bob", greeting: "hey"`:      `"hey, bob"`,
+		`greet greeting: "hey", name: "al"`: `"hey, al"`,
+		"f 0":                               "(0, 1, 2)",
+		"f 0, 5":                            "(0, 5, 2)",
+		"f 0, c: 9":                         "(0, 1, 9)",
+		"f 0, 5, 6":                         "(0, 5, 6)",
+		"g 1":                               "(1, ())",
+		"g 1, 2, 3":                         "(1, (2, 3))",
+		"(add 1) y: 5":                      "6",
+		"add y: 1, x: 2":                    "3",
+		"d = 5; h x, y = d = y; d = 6; h 0": "5",
+		"h x, ...r = r; (h 1) |> len":       "0",
+		"h x, y = 10 = x + y; 1, 2 |> h":    "3",
+		"h x, y = 10 = x + y; h 1":          "11",
+
+		// Each call gets its own copy of a default value
+		"f a, xs = [0] = do xs[0] = (xs[0]) + a; xs end; f 1; f 1":               "[1]",
+		`f k, m = {} = do m[k] = 1; m end; f "a"; f "b"`:                         `{"b": 1}`,
+		"f a, xs = [[0]] = do ys = xs[0]; ys[0] = (ys[0]) + a; xs end; f 1; f 1": "[[1]]",
+		"xs = [0]; f a, ys = xs = do ys[0] = a; ys end; f 1; xs":                 "[0]",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestParameterErrors(t *testing.T) {
+	prelude := `
+		greet name, greeting = "hi" = greeting + ", " + name
+		add x, y = x + y
+	`
+
+	tests := map[string]string{
+		`greet greeting: "yo"`: "missing an argument for the parameter name",
+		`greet "a", "b", "c"`:  "too many arguments passed to a function. expected at most 2, got 3",
+		`greet "a", foo: 1`:    "unexpected keyword argument foo",
+		`greet "a", name: "b"`: "more than one value passed for the parameter name",
+		"add 1, y: 2, x: 3":    "more than one value passed for the parameter x",
+		"str 1, x: 2":          "cannot pass keyword arguments to the builtin str",
+		"[1, 2] 0, x: 1":       "cannot pass keyword arguments to an object of type list",
+	}
+
+	for test, expected := range tests {
+		_, err := run(prelude + test)
+		if e, ok := err.(*Error); !ok || e.Type != ArgumentError || e.Message != expected {
+			t.Errorf("%s: expected the argument error '%s', got %v\n", test, expected, err)
+		}
+	}
+}
+
+func TestModels(t *testing.T) {
+	prelude := `
+		animal = model name, species
+		dog = model name : animal name, "dog"
+
+		animal.describe () = self.name + " is a " + self.species
+		animal.rename new-name = do self.name = new-name end
+		dog.speak times = "woof " + str times
+
+		rex = dog "rex"
+	`
+
+	tests := map[string]string{
+		"rex.name":                                  `"rex"`,
+		"rex.species":                               `"dog"`,
+		"rex.__model == dog":                        "true",
+		"dog.__parent == animal":                    "true",
+		"animal.__parent":                           "nil",
+		"rex.describe ()":                           `"rex is a dog"`,
+		"rex.speak 2":                               `"woof 2"`,
+		"type rex.speak":                            `"method"`,
+		"type dog":                                  `"model"`,
+		"d = rex.describe; d ()":                    `"rex is a dog"`,
+		`rex.rename "max"; rex.describe ()`:         `"max is a dog"`,
+		`(animal "tom", "cat").describe ()`:         `"tom is a cat"`,
+		`((animal "tom") "cat").species`:            `"cat"`,
+		`(animal species: "cat", name: "tom").name`: `"tom"`,
+		"point = model x, y; point.sum () = self.x + self.y; (point 1, 2).sum ()": "3",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+// TestUnhashableField builds an instance by hand, since compiled models always
+// use strings as field names.
+func TestUnhashableField(t *testing.T) {
+	var (
+		v    = New()
+		code = bytecode.Code{
+			{Code: bytecode.MakeMap, Name: "MAKE_MAP", Arg: 0},
+			{Code: bytecode.LoadConst, Name: "LOAD_CONST", Arg: 0},
+			{Code: bytecode.LoadConst, Name: "LOAD_CONST", Arg: 1},
+			{Code: bytecode.LoadConst, Name: "LOAD_CONST", Arg: 2},
+			{Code: bytecode.MakeInstance, Name: "MAKE_INSTANCE", Arg: 1},
+		}
+		constants = []object.Object{
+			&object.List{Value: []object.Object{}},
+			&object.Integer{Value: 1},
+			&object.Nil{},
+		}
+	)
+
+	v.PushFrame(v.MakeFrame(code, nil, NewStore(nil), constants, nil, nil))
+
+	_, err := v.Run()
+	if e, ok := err.(*Error); !ok || e.Type != TypeError {
+		t.Errorf("expected a type error, got %v\n", err)
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	prelude := `
+		vec = model x, y
+		vec.__add o = vec self.x + o.x, self.y + o.y
+		vec.__sub o = vec self.x - o.x, self.y - o.y
+		vec.__eq o = self.x == o.x && self.y == o.y
+		vec.__lt o = self.x < o.x
+		vec.__neg () = vec (-self.x), (-self.y)
+		vec.__str () = "<{self.x}, {self.y}>"
+		vec.__call n = self.x * n
+		vec.__index i = if i == 0 then self.x else self.y
+		vec.__iter () = [self.x, self.y]
+
+		a = vec 1, 2
+		b = vec 3, 4
+	`
+
+	tests := map[string]string{
+		"str (a + b)":     `"<4, 6>"`,
+		"str (b - a)":     `"<2, 2>"`,
+		"str (a + b + a)": `"<5, 8>"`,
+		"str (-a)":        `"<-1, -2>"`,
+		"a == vec 1, 2":   "true",
+		"a == b":          "false",
+		"a != b":          "true",
+		"a != vec 1, 2":   "false",
+		"a < b":           "true",
+		"a >= b":          "false",
+		"b >= a":          "true",
+		"str a":           `"<1, 2>"`,
+		`"a is {a}"`:      `"a is <1, 2>"`,
+		"a 10":            "10",
+		"a[0]":            "1",
+		"a[1]":            "2",
+		"a.x":             "1",
+		"total = 0; for c in b do total = total + c end; total": "7",
+		"1 + 1": "2",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestOperatorOverloadingErrors(t *testing.T) {
+	prelude := `
+		money = model cents
+		money.__add o = money self.cents + o.cents
+		money.__str () = self.cents
+		money.__iter () = self.cents
+	`
+
+	tests := map[string]ErrorType{
+		"(money 1) + 2":         TypeError,
+		"str (money 1)":         TypeError,
+		"(money 1) * 2":         TypeError,
+		"-(money 1)":            TypeError,
+		"(money 1) 5":           IndexError,
+		"for c in (money 1), c": TypeError,
+	}
+
+	expectErrors(t, prelude, tests)
+}
+
+func TestMembership(t *testing.T) {
+	prelude := `
+		range = model lo, hi
+		range.__contains n = n >= self.lo && n < self.hi
+
+		pair = model a, b
+		pair.__iter () = [self.a, self.b]
+	`
+
+	tests := map[string]string{
+		"2 in [1, 2, 3]":           "true",
+		"2.0 in [1, 2, 3]":         "true",
+		"4 in [1, 2, 3]":           "false",
+		"4 not in [1, 2, 3]":       "true",
+		"[1] in [[1], [2]]":        "true",
+		"1 in (1, 2)":              "true",
+		"3 not in (1, 2)":          "true",
+		`"a" in {"a": 1}`:          "true",
+		`1 in {"a": 1}`:            "false",
+		`"ell" in "hello"`:         "true",
+		`"" in "hello"`:            "true",
+		`"hi" not in "hello"`:      "true",
+		`1 in "1"`:                 "false",
+		"5 in range 1, 10":         "true",
+		"10 in range 1, 10":        "false",
+		"10 not in range 1, 10":    "true",
+		`"b" in pair "a", "b"`:     "true",
+		`"c" not in pair "a", "b"`: "true",
+		"1 + 1 in [2]":             "true",
+		"2 in [2] == true":         "true",
+		"!(1 in [1])":              "false",
+		"not 1 in [1]":             "false",
+		"not 4 in [1, 2, 3]":       "true",
+		"not true":                 "false",
+		"not 1 == 2 && true":       "true",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestMembershipErrors(t *testing.T) {
+	tests := []string{
+		"1 in 2",
+		"1 not in nil",
+		"true in false",
+	}
+
+	expectErrorType(t, "", tests, TypeError)
+}
+
+func TestSlicing(t *testing.T) {
+	prelude := "xs = [0, 1, 2, 3, 4, 5]; "
+
+	tests := map[string]string{
+		"xs[1:3]":                       "[1, 2]",
+		"xs[:-1]":                       "[0, 1, 2, 3, 4]",
+		"xs[::2]":                       "[0, 2, 4]",
+		"xs[::-1]":                      "[5, 4, 3, 2, 1, 0]",
+		"xs[4:1:-1]":                    "[4, 3, 2]",
+		"xs[:]":                         "[0, 1, 2, 3, 4, 5]",
+		"xs[10:]":                       "[]",
+		"xs[-1]":                        "5",
+		"xs[-6]":                        "0",
+		"n = 2; xs[n - 1:(len xs) - 1]": "[1, 2, 3, 4]",
+		"xs[slice 2]":                   "[0, 1]",
+		"(1, 2, 3)[1:]":                 "(2, 3)",
+		"(1, 2, 3)[-2]":                 "2",
+		`"hello"[1:4]`:                  `"ell"`,
+		`"hello"[::-1]`:                 `"olleh"`,
+		`"hello"[-1]`:                   `"o"`,
+		"ys = xs[:]; ys[0] = 9; xs[0]":  "0",
+		"xs[-1] = 9; xs":                "[0, 1, 2, 3, 4, 9]",
+		`xs[1:3] = ["a"]; xs`:           `[0, "a", 3, 4, 5]`,
+		"xs[:0] = (8, 9); xs":           "[8, 9, 0, 1, 2, 3, 4, 5]",
+		"xs[::2] = [7, 8, 9]; xs":       "[7, 1, 8, 3, 9, 5]",
+		"xs[:] = []; xs":                "[]",
+		"xs[3:] = xs; xs":               "[0, 1, 2, 0, 1, 2, 3, 4, 5]",
+		"[:2]":                          "[:2]",
+		"type [1:2][0]":                 `"slice"`,
+		"f a = a; [(f a: 1), 2]":        "[1, 2]",
+		"xs[1:2 ^ 70]":                  "[1, 2, 3, 4, 5]",
+		"xs[-(2 ^ 70):2]":               "[0, 1]",
+		"xs[::2 ^ 70]":                  "[0]",
+		"xs[::-(2 ^ 70)]":               "[5]",
+		"xs[1.0]":                       "1",
+		"xs[1.0:3.0]":                   "[1, 2]",
+		"xs[::2 ^ 70] = [9]; xs":        "[9, 1, 2, 3, 4, 5]",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestSlicingErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"[1, 2][::0]":                        ArgumentError,
+		"[1, 2][2]":                          IndexError,
+		"[1, 2][-3]":                         IndexError,
+		"[1, 2][2 ^ 70]":                     IndexError,
+		"(1, 2)[2 ^ 70]":                     IndexError,
+		`"ab"[2 ^ 70]`:                       IndexError,
+		"[1, 2][1e30]":                       IndexError,
+		`[1, 2]["a":]`:                       TypeError,
+		"(set 1, 2)[0:1]":                    TypeError,
+		"xs = [1, 2, 3]; xs[::2] = [1]":      ArgumentError,
+		"xs = [1, 2, 3]; xs[1.5]":            TypeError,
+		"xs = [1, 2, 3]; xs[1.5] = 1":        TypeError,
+		"[1, 2][0.5]":                        TypeError,
+		"[1, 2][0.5:]":                       TypeError,
+		"xs = [1, 2, 3]; xs[-4] = 1":         IndexError,
+		"xs = [1, 2, 3]; xs[-100] = 1":       IndexError,
+		"xs = [1, 2, 3]; xs[3] = 1":          IndexError,
+		"xs = [1, 2, 3]; xs[2 ^ 70] = 1":     IndexError,
+		"xs = [1, 2, 3]; xs[\"a\"] = 1":      TypeError,
+		"xs = freeze [1, 2, 3]; xs[:1] = []": TypeError,
+		"(1, 2)[:1] = [3]":                   TypeError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestBoundsMessages(t *testing.T) {
+	tests := map[string]string{
+		"xs = [1, 2]; xs[2 ^ 70]":     "1180591620717411303424 is out of bounds",
+		"xs = [1, 2]; xs[2 ^ 70] = 1": "1180591620717411303424 is out of bounds",
+		"xs = [1, 2]; xs[-3]":         "-3 is out of bounds",
+		"xs = [1, 2]; xs[-3] = 1":     "-3 is out of bounds",
+	}
+
+	for test, expected := range tests {
+		_, err := run(test)
+		if e, ok := err.(*Error); !ok || e.Type != IndexError || e.Message != expected {
+			t.Errorf("%s: expected the index error '%s', got %v\n", test, expected, err)
+		}
+	}
+}
+
+func TestPartialApplicationErrors(t *testing.T) {
+	tests := []string{
+		"add x, y = x + y; add 1, 2, 3",
+		"add x, y = x + y; (add 1) 2, 3",
+		"str 1, 2",
+	}
+
+	expectErrorType(t, "", tests, ArgumentError)
+}
+
+func TestProtocols(t *testing.T) {
+	prelude := `
+		shape = protocol do
+			area ()
+			perimeter ()
+		end
+
+		scalable = protocol do
+			scale factor
+			area
+		end
+
+		named = protocol name
+
+		square = model side
+		square.area () = self.side ^ 2
+		square.perimeter () = self.side * 4
+		square.scale factor = square self.side * factor
+
+		tile = model side, colour : square side
+
+		circle = model r
+		circle.area () = 3 * self.r ^ 2
+		circle.scale a, b = circle self.r * a * b
+
+		describe s: shape = "area {s.area ()}, perimeter {s.perimeter ()}"
+		grow s: scalable, factor = 2 = s.scale factor
+	`
+
+	tests := map[string]string{
+		"(square 2) implements shape":           "true",
+		`(tile 2, "red") implements shape`:      "true",
+		"(circle 2) implements shape":           "false",
+		"(square 2) implements scalable":        "true",
+		"(circle 2) implements scalable":        "false",
+		"(circle 2) implements protocol area":   "true",
+		`{"name": "x"} implements named`:        "false",
+		`{"name": id} implements named`:         "true",
+		"5 implements shape":                    "false",
+		"square implements protocol scale":      "true",
+		"type shape":                            `"protocol"`,
+		"str shape":                             `"<protocol area (0), perimeter (0)>"`,
+		"str scalable":                          `"<protocol scale (1), area>"`,
+		"str (protocol a, b)":                   `"<protocol a, b>"`,
+		"shape == shape":                        "true",
+		"describe (square 3)":                   `"area 9, perimeter 12"`,
+		`describe (tile 1, "blue")`:             `"area 1, perimeter 4"`,
+		"(grow (square 3)).side":                "6",
+		"(grow (square 3), 3).side":             "9",
+		"(grow factor: 4, s: (square 1)).side":  "4",
+		"(grow (square 1)) implements scalable": "true",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestProtocolErrors(t *testing.T) {
+	prelude := `
+		shape = protocol area, perimeter
+		circle = model r
+		circle.area () = 3 * self.r ^ 2
+		describe s: shape = s.area ()
+	`
+
+	tests := map[string]ErrorType{
+		"describe (circle 1)":     TypeError,
+		"describe 5":              TypeError,
+		"(circle 1) implements 5": TypeError,
+		"f x: 5 = x; f 1":         TypeError,
+		"describe s: (circle 1)":  TypeError,
+	}
+
+	expectErrors(t, prelude, tests)
+}
+
+func TestAnnotations(t *testing.T) {
+	prelude := `
+		square = model side
+		add x: number, y: number -> number = x + y
+		area s: square -> integer = s.side ^ 2
+		name-of x: string = "the " + x
+		half x -> number = x / 2
+	`
+
+	tests := map[string]string{
+		"add 1, 2":                     "3",
+		"add 1.5, 2":                   "3.5",
+		"add 1, y: 2":                  "3",
+		"(add 1) 2":                    "3",
+		"area (square 3)":              "9",
+		`name-of "square"`:             `"the square"`,
+		"n: integer = 5; n":            "5",
+		"n: number := 5; n + 1":        "6",
+		"s: square = square 2; s.side": "2",
+		"half 3":                       "1.5",
+	}
+
+	for test, expected := range tests {
+		for _, enforce := range []bool{false, true} {
+			result, err := runWith(prelude+test, enforce)
+			if err != nil {
+				t.Errorf("%s: %s\n", test, err)
+				continue
+			}
+
+			if result == nil || result.String() != expected {
+				t.Errorf("%s: expected %s, got %v\n", test, expected, result)
+			}
+		}
+	}
+}
+
+func TestEnforcedAnnotations(t *testing.T) {
+	prelude := `
+		square = model side
+		tile = model side
+		add x: number, y: number -> number = x + y
+		area s: square -> integer = s.side ^ 2
+		wrong x -> string = x
+	`
+
+	tests := []string{
+		`add "a", "b"`,
+		`add "a", y: "b"`,
+		"area (tile 1)",
+		`area {"side": 1}`,
+		"area (square 1.5)",
+		"wrong 5",
+		`n: integer = "five"`,
+		"t: square = tile 1",
+	}
+
+	for _, test := range tests {
+		if _, err := runWith(prelude+test, false); err != nil {
+			t.Errorf("%s: annotations shouldn't be checked unless enforced, got %s\n", test, err)
+		}
+
+		_, err := runWith(prelude+test, true)
+		if e, ok := err.(*Error); !ok || e.Type != TypeError {
+			t.Errorf("%s: expected a type error, got %v\n", test, err)
+		}
+	}
+
+	// The first parameter which doesn't match is always the one reported.
+	for i := 0; i < 20; i++ {
+		_, err := runWith(prelude+`add "a", "b"`, true)
+		if err == nil || !strings.Contains(err.Error(), "the parameter x ") {
+			t.Fatalf("expected the parameter x to be reported, got %v\n", err)
+		}
+	}
+}
+
+func TestFreezing(t *testing.T) {
+	tests := map[string]string{
+		"l = freeze [1, 2]; l[0]":                  "1",
+		"frozen (freeze [1, [2]])[1]":              "true",
+		`frozen (freeze {"a": [1]})["a"]`:          "true",
+		"frozen [1]":                               "false",
+		"frozen 5":                                 "true",
+		"l = freeze [1]; k = list l; k[0] = 2; l":  "[1]",
+		"l = freeze [1]; k = l + [2]; k[0] = 3; l": "[1]",
+		"point = model x; p = freeze (point 1); point.f () = 2; p.f ()": "2",
+		`f () = "abc"; s = f (); s[0] = "x"; f ()`:                      `"abc"`,
+		`s = "abc"; s[0] = "x"; t = "abc"; s, t`:                        `("xbc", "abc")`,
+
+		// freeze copies its argument, which can still be mutated
+		"x = [1, [2]]; f = freeze x; x[0] = 3; y = x[1]; y[0] = 4; x, f":    "([3, [4]], [1, [2]])",
+		`m = {"a": [1]}; f = freeze m; a = m["a"]; a[0] = 2; m["b"] = 3; f`: `{"a": [1]}`,
+		"x = [1]; (frozen x), (frozen (freeze x)), (frozen x)":              "(false, true, false)",
+		"point = model x; p = point 1; f = freeze p; p.x = 2; f.x":          "1",
+		"point = model x; freeze point; frozen point":                       "true",
+		"x = [1]; x[0] = x; f = freeze x; frozen (f[0])":                    "true",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestFreezingErrors(t *testing.T) {
+	tests := []string{
+		"l = freeze [1, 2]; l[0] = 3",
+		"l = freeze [[1]]; k = l[0]; k[0] = 3",
+		`m = freeze {"a": 1}; m["b"] = 2`,
+		`s = freeze "abc"; s[0] = "x"`,
+		"point = model x; p = freeze (point 1); p.x = 2",
+		"point = freeze (model x); point.f () = 2",
+	}
+
+	expectErrorType(t, "", tests, TypeError)
+}
+
+func TestConstants(t *testing.T) {
+	tests := map[string]string{
+		"const x = 5; x":                                       "5",
+		"const a, b = 1, 2; a + b":                             "3",
+		"const n: integer = 5; n":                              "5",
+		"const square x = x * x; square 3":                     "9",
+		"const x = 5; f () = do x := 2; x end; y = f (); y, x": "(2, 5)",
+		"l = [1]; const k = l; l[0] = 2; k, l":                 "([1], [2])",
+		`const m = {"a": [1]}; frozen (m["a"])`:                "true",
+		"do const x = 1; export x end; x":                      "1",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestConstantErrors(t *testing.T) {
+	tests := []string{
+		"const x = 5; x = 6",
+		"const x = 5; x := 6",
+		"const x = 5; const x = 6",
+		"const x = 5; f () = do x = 6 end; f ()",
+		"const square x = x * x; square = 5",
+		"do const x = 1; export x end; x = 2",
+	}
+
+	expectErrorType(t, "", tests, NameError)
+
+	// The value of a constant is frozen, too
+	expectErrorType(t, "", []string{
+		"const l = [1]; l[0] = 2",
+		`const m = {"a": [1]}; l = m["a"]; l[0] = 2`,
+		`const s = "abc"; s[0] = "x"`,
+	}, TypeError)
+}
+
+func TestMapKeys(t *testing.T) {
+	tests := map[string]string{
+		`str {"b": 1, "a": 2, 3: 4}`:                    `"{"b": 1, "a": 2, 3: 4}"`,
+		`m = {1: "a"}; m[1.00d]`:                        `"a"`,
+		`m = {0.5: "a"}; m[0.50d]`:                      `"a"`,
+		`m = {(1, "a"): 2}; m[(1.0, "a")]`:              "2",
+		"m = {(freeze [1, 2]): 3}; m[freeze [1, 2]]":    "3",
+		`k = "ab"; m = {k: 1}; k[0] = "x"; m["ab"]`:     "1",
+		`m = {"a": 1}; m["b"] = 2; m["a"] = 3; list m`:  `[("a", 3), ("b", 2)]`,
+		`list {"e": 1, "d": 2, "c": 3, "b": 4, "a": 5}`: `[("e", 1), ("d", 2), ("c", 3), ("b", 4), ("a", 5)]`,
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestUnhashableKeys(t *testing.T) {
+	tests := []string{
+		"{[1]: 2}",
+		"m = {}; m[[1]] = 2",
+		"m = {}; m[[1, 2]]",
+		"m = {}; m[{}] = 1",
+		"{(1, [2]): 3}",
+	}
+
+	expectErrorType(t, "", tests, TypeError)
+}
+
+func TestSets(t *testing.T) {
+	tests := map[string]string{
+		"set 1, 2, 2, 3":                          "set [1, 2, 3]",
+		"set ()":                                  "set []",
+		"set 1, 1.0, 1.00d":                       "set [1]",
+		"set [3, 1, 3]":                           "set [3, 1]",
+		`set "abba"`:                              `set ["a", "b"]`,
+		"(set 1, 2) | (set 2, 3)":                 "set [1, 2, 3]",
+		"(set 1, 2) & (set 2, 3)":                 "set [2]",
+		"(set 1, 2) - (set 2, 3)":                 "set [1]",
+		"(set 1, 2) ^ (set 2, 3)":                 "set [1, 3]",
+		"(set 1, 2) == (set 2, 1)":                "true",
+		"(set 1, 2) == (set 1)":                   "false",
+		"2 in (set 1, 2)":                         "true",
+		"(1, 2) in (set [(1.0, 2)])":              "true",
+		"3 in (set 1, 2)":                         "false",
+		"type (set 1)":                            `"set"`,
+		"len (set 1, 2, 1)":                       "2",
+		"list (set 3, 1, 2)":                      "[3, 1, 2]",
+		`m = {(set 1, 2): "a"}; m[set 2, 1]`:      `"a"`,
+		`"#{1 + 1}"`:                              `"#2"`,
+		"s = set 1 #{ a comment, not a set }\n s": "set [1]",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestSetErrors(t *testing.T) {
+	tests := []string{
+		"set [[1]]",
+		"set 1, {}",
+		"1 in 2",
+	}
+
+	expectErrorType(t, "", tests, TypeError)
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := map[string]string{
+		`split ",", "a,b,,c"`:            `["a", "b", "", "c"]`,
+		`split "", "hé"`:                 `["h", "é"]`,
+		`"a b c" |> split " " |> len`:    "3",
+		`join ", ", ["a", "b"]`:          `"a, b"`,
+		`join "", ("a", "b")`:            `"ab"`,
+		`"a,b" |> split "," |> join ";"`: `"a;b"`,
+		`replace "l", "L", "hello"`:      `"heLLo"`,
+		`trim "  hi 
+"`: `"hi"`,
+		`trim-left "  hi "`:                   `"hi "`,
+		`trim-right "  hi "`:                  `"  hi"`,
+		`upper "héllo"`:                       `"HÉLLO"`,
+		`lower "ÀB"`:                          `"àb"`,
+		`starts? "he", "hello"`:               "true",
+		`"hello" |> ends? "he"`:               "false",
+		`find "llo", "héllo"`:                 "2",
+		`find "z", "hello"`:                   "-1",
+		`repeat 3, "ab"`:                      `"ababab"`,
+		`repeat 9223372036854775807, ""`:      `""`,
+		`pad 5, "ab"`:                         `"   ab"`,
+		`pad (-5), "ab"`:                      `"ab   "`,
+		`pad 2, "héllo"`:                      `"héllo"`,
+		`chars "héllo"`:                       `["h", "é", "l", "l", "o"]`,
+		`bytes "hé"`:                          "[104, 195, 169]",
+		`ord "é"`:                             "233",
+		"chr 233":                             `"é"`,
+		`len "héllo"`:                         "5",
+		`s = "abc"; t = str s; t[0] = "x"; s`: `"abc"`,
+		`s = "abc"; t = str s; t[0] = "x"; t`: `"xbc"`,
+		`"héllo"[1]`:                          `"é"`,
+		`format "$() + $() = $()", 1, 2, 3`:   `"1 + 2 = 3"`,
+		`format "$(1)$(0)", "a", "b"`:         `"ba"`,
+		`format "$$() $$5 $ $()", [1]`:        `"$() $5 $ [1]"`,
+		`format "$(name) is $(age)", {"name": "Ann", "age": 30}`:          `"Ann is 30"`,
+		`x = 2; format "{x}: $(x)", {"x": 1}`:                             `"2: 1"`,
+		"format `$()`, 1":                                                 `"1"`,
+		`vec = model x; vec.__str () = "<{self.x}>"; format "$()", vec 1`: `"<1>"`,
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		`split 1, "a"`:                           TypeError,
+		`join ",", [1]`:                          TypeError,
+		`join ",", 1`:                            TypeError,
+		`repeat (-1), "a"`:                       TypeError,
+		`repeat 9223372036854775807, "ab"`:       ArgumentError,
+		`repeat 1073741824, "ab"`:                ArgumentError,
+		`pad 9223372036854775807, "ab"`:          ArgumentError,
+		`pad (-9223372036854775807), "ab"`:       ArgumentError,
+		`ord "ab"`:                               ArgumentError,
+		"chr (-1)":                               TypeError,
+		`format "$()"`:                           ArgumentError,
+		`format "$(2)", 1`:                       ArgumentError,
+		`format "$(9223372036854775808)", 1`:     ArgumentError,
+		`format "$(99999999999999999999999)", 1`: ArgumentError,
+		`format "$(x)", 1`:                       ArgumentError,
+		`format "$(x)", {}`:                      ArgumentError,
+		`format "$(", 1`:                         ArgumentError,
+		"format 1":                               TypeError,
+		`upper "a", "b"`:                         ArgumentError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestRegex(t *testing.T) {
+	prelude := "r = re.compile `(\\d+)-(\\d+)`; "
+
+	tests := map[string]string{
+		"r":                             "<regex `(\\d+)-(\\d+)`>",
+		"type r":                        `"regex"`,
+		"re.matches? `^\\d+$`, \"123\"": "true",
+		"\"ab\" |> re.matches? r":       "false",
+		`re.find r, "x 10-20 y"`:        `("10-20", "10", "20")`,
+		"re.find `\\d+`, \"ab12cd\"":    `"12"`,
+		"re.find `\\d+`, \"abcd\"":      "nil",
+		"re.find `(a)|(b)`, \"b\"":      `("b", nil, "b")`,
+		"re.captures `(?P<k>\\w+)=(?P<v>\\w*)`, \"a=b\"":         `{"k": "a", "v": "b"}`,
+		"re.find-all `\\d+`, \"1 22 333\"":                       `["1", "22", "333"]`,
+		`re.find-all r, "1-2 3-4"`:                               `[("1-2", "1", "2"), ("3-4", "3", "4")]`,
+		"re.find-all `\\d`, \"abc\"":                             "[]",
+		"re.replace `\\d+`, \"#\", \"a1b22\"":                    `"a#b#"`,
+		"re.replace `(\\w+)@(\\w+)`, \"$2 at $1\", \"me@host\"":  `"host at me"`,
+		"double m = m + m; re.replace `\\d+`, double, \"a1b22\"": `"a11b2222"`,
+		"re.replace `[aeiou]`, upper, \"banana\"":                `"bAnAnA"`,
+		"f m = m[1]; re.replace r, f, \"1-2, 3-4\"":              `"1, 3"`,
+		"re.split `\\s*,\\s*`, \"a , b,c\"":                      `["a", "b", "c"]`,
+		"{r: 1}[re.compile `(\\d+)-(\\d+)`]":                     "1",
+		"r == re.compile `(\\d+)-(\\d+)`":                        "true",
+		"r == re.compile `\\d`":                                  "false",
+	}
+
+	expectOutputs(t, prelude, tests)
+}
+
+func TestRegexErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"re.compile `(`":                       ArgumentError,
+		"re.compile 5":                         TypeError,
+		"re.find 5, \"a\"":                     TypeError,
+		"re.find `a`, 5":                       TypeError,
+		"re.find `a`, \"a\", \"b\"":            ArgumentError,
+		"f m = m[5]; re.replace `a`, f, \"a\"": IndexError,
+		"re.replace `a`, 5, \"a\"":             TypeError,
+		"re.compile = 5":                       TypeError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestMath(t *testing.T) {
+	tests := map[string]string{
+		"math.sqrt 16":                    "4",
+		"math.sqrt 2.25":                  "1.5",
+		"math.floor 2.7":                  "2",
+		"math.floor (-2.5)":               "-3",
+		"math.ceil 2.1":                   "3",
+		"math.round 2.5":                  "3",
+		"math.round (-2.5)":               "-3",
+		"math.round 2.4":                  "2",
+		"math.trunc (-2.7)":               "-2",
+		"math.floor 7":                    "7",
+		"math.floor 1e20":                 "100000000000000000000",
+		"type (math.floor 2.5)":           `"integer"`,
+		"math.abs (-3)":                   "3",
+		"math.abs (-2.5)":                 "2.5",
+		"math.min 3, 1, 2":                "1",
+		"math.max [1, 5, 2]":              "5",
+		"math.max \"a\", \"c\", \"b\"":    `"c"`,
+		"math.min 2, 1.5":                 "1.5",
+		"math.gcd 12, 18":                 "6",
+		"math.gcd (-4), 6":                "2",
+		"math.gcd 0, 0":                   "0",
+		"math.nan? math.nan":              "true",
+		"math.nan? 1":                     "false",
+		"math.inf? math.inf":              "true",
+		"math.inf? (-math.inf)":           "true",
+		"math.pi > 3.14":                  "true",
+		"math.log 1":                      "0",
+		"(math.log 0) == -math.inf":       "true",
+		"math.log2 8":                     "3",
+		"math.log10 1000":                 "3",
+		"math.hypot 3, 4":                 "5",
+		"math.atan2 0, 1":                 "0",
+		"math.sin 0":                      "0",
+		"math.sqrt math.nan |> math.nan?": "true",
+		"16 |> math.sqrt |> math.sqrt":    "2",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestDecimalSettings(t *testing.T) {
+	tests := map[string]string{
+		`decimal-precision 5; (decimal 1) / 3`:                                  "0.33333",
+		`decimal-precision 5; 2 / (decimal 3)`:                                  "0.66667",
+		`decimal-precision 5; decimal-rounding "down"; (decimal 2) / 3`:         "0.66666",
+		`decimal-rounding "up"; decimal "2.21", 1`:                              "2.3",
+		`decimal-precision 3; [(decimal-precision 4), (decimal-rounding "up")]`: `[3, "half-even"]`,
+	}
+
+	expectOutputs(t, "", tests)
+
+	// Each virtual machine has its own settings, so none of the above affect this.
+	result, err := run(`(decimal 1) / 3`)
+	if err != nil || result.String() != "0.3333333333333333333333333333" {
+		t.Errorf("expected the default precision, got %v (%v)\n", result, err)
+	}
+
+	if _, err := run(`decimal "1e1000000000"`); err == nil {
+		t.Errorf("a decimal with a huge exponent should be rejected")
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"math.sqrt (-1)":      ArgumentError,
+		"math.asin 2":         ArgumentError,
+		"math.sqrt \"4\"":     TypeError,
+		"math.hypot 1, 2, 3":  ArgumentError,
+		"math.floor math.inf": ArgumentError,
+		"math.floor nil":      TypeError,
+		"math.abs \"a\"":      TypeError,
+		"math.min []":         ArgumentError,
+		"math.min 5":          TypeError,
+		"math.max 1, \"a\"":   TypeError,
+		"math.gcd 1.5, 3":     TypeError,
+		"math.pi = 3":         TypeError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := map[string]ErrorType{
+		`5 // 0`:                       RuntimeError,
+		`5 % 0`:                        RuntimeError,
+		`(-5) % 0`:                     RuntimeError,
+		`99999999999999999999999 // 0`: RuntimeError,
+		`99999999999999999999999 % 0`:  RuntimeError,
+		`"a" // 0`:                     TypeError,
+		`5 // "a"`:                     TypeError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestIntegerPowers(t *testing.T) {
+	tests := map[string]string{
+		"2 ^ 10":                      "1024",
+		"(-3) ^ 3":                    "-27",
+		"0 ^ 0":                       "1",
+		"2 ^ 62":                      "4611686018427387904",
+		"2 ^ 63":                      "9223372036854775808",
+		"(-2) ^ 63":                   "-9223372036854775808",
+		"3 ^ 41":                      "36472996377170786403",
+		"2 ^ (-1)":                    "0.5",
+		"1 ^ (2 ^ 70)":                "1",
+		"(-1) ^ 99999999999999999999": "-1",
+		"len (str (2 ^ 1000000))":     "301030",
+	}
+
+	expectOutputs(t, "", tests)
+
+	for _, test := range []string{"2 ^ (2 ^ 62)", "2 ^ 99999999999999999999", "3 ^ 100000000"} {
+		_, err := run(test)
+		if e, ok := err.(*Error); !ok || e.Type != RuntimeError {
+			t.Errorf("%s: expected a %s error, got %v\n", test, RuntimeError, err)
+		}
+	}
+}
+
+func TestDecimalPowers(t *testing.T) {
+	tests := map[string]string{
+		"1.5d ^ 2":                  "2.25",
+		"2d ^ 10":                   "1024",
+		"1d ^ 99999999999999999999": "1",
+		"2d ^ (-1)":                 "0.5",
+		"len (str (0.1d ^ 1000))":   "1002",
+		"2d ^ 2d":                   "4",
+		"1.5d ^ 2.0d":               "2.25",
+		"2 ^ 2d":                    "4",
+		"4d ^ 0.5d":                 "2",
+		"4d ^ 0.5":                  "2",
+		"2 ^ (-1d)":                 "0.5",
+		"str ((-8d) ^ (1d / 3d))":   `"NaN"`,
+	}
+
+	expectOutputs(t, "", tests)
+
+	errors := []string{
+		"2d ^ 1000000000", "1.0d ^ 1000000", "(decimal 2) ^ (2 ^ 70)",
+		"2d ^ 1000000000d", "2 ^ 1000000000d",
+		"0d ^ (-1)", "0d ^ (-0.5d)", "0 ^ (-1d)",
+	}
+
+	for _, test := range errors {
+		_, err := run(test)
+		if e, ok := err.(*Error); !ok || e.Type != RuntimeError {
+			t.Errorf("%s: expected a %s error, got %v\n", test, RuntimeError, err)
+		}
+	}
+}
+
+func TestRandom(t *testing.T) {
+	prelude := "xs = [1, 2, 3, 4, 5]; "
+
+	tests := map[string]string{
+		"x = random.rand (); x >= 0 && x < 1":   "true",
+		"(random.choice xs) in xs":              "true",
+		"random.choice \"a\"":                   `"a"`,
+		"(set (random.shuffle xs)) == (set xs)": "true",
+		"random.shuffle xs; xs":                 "[1, 2, 3, 4, 5]",
+		"len (random.sample 3, xs)":             "3",
+		"len (set (random.sample 5, xs))":       "5",
+		"random.sample 0, xs":                   "[]",
+	}
+
+	expectOutputs(t, prelude, tests)
+
+	// Seeding the generator should make it give the same values each time.
+	seeded := "random.seed 42; ((random.rand ()), (random.choice xs), (random.shuffle xs), (random.sample 2, xs))"
+
+	first, err := run(prelude + seeded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := run(prelude + seeded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !first.Equals(second) {
+		t.Errorf("expected seeded runs to be equal, got %s and %s\n", first, second)
+	}
+
+	// Each virtual machine has its own generator, so seeding one doesn't affect
+	// any other.
+	a, b := New(), New()
+	a.Random = rand.New(rand.NewSource(1))
+
+	if _, err := runIn(b, "random.seed 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := runIn(a, "random.rand ()")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &object.Number{Value: rand.New(rand.NewSource(1)).Float64()}
+	if !result.Equals(expected) {
+		t.Errorf("expected %s from the unseeded machine, got %s\n", expected, result)
+	}
+
+	result, err = runIn(New(), "random.seed 2; random.rand ()")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = &object.Number{Value: rand.New(rand.NewSource(2)).Float64()}
+	if !result.Equals(expected) {
+		t.Errorf("expected %s from the seeded machine, got %s\n", expected, result)
+	}
+}
+
+func TestRandomErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"random.seed 1.5":         TypeError,
+		"random.rand 1":           ArgumentError,
+		"random.choice []":        ArgumentError,
+		"random.choice 5":         TypeError,
+		"random.shuffle nil":      TypeError,
+		"random.sample 3, [1, 2]": ArgumentError,
+		"random.sample (-1), [1]": TypeError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestJSON(t *testing.T) {
+	tests := map[string]string{
+		"json.parse `{\"a\": [1, 2.5, null, true]}`":                                  `{"a": [1, 2.5, nil, true]}`,
+		"`[\"x\"]` |> json.parse":                                                     `["x"]`,
+		"json.stringify {\"b\": 1, \"a\": (2, \"c\")}":                                `"{"b":1,"a":[2,"c"]}"`,
+		"json.stringify {\"sort-keys\": true}, {\"b\": 1, \"a\": 2}":                  `"{"a":2,"b":1}"`,
+		"json.stringify {\"indent\": 1}, [1]":                                         "\"[\n 1\n]\"",
+		"json.stringify {\"indent\": \"\\t\"}, {\"a\": nil}":                          "\"{\n\t\"a\": null\n}\"",
+		"x = {\"k\": [1, \"two\", {\"n\": nil}]}; x == json.parse (json.stringify x)": "true",
+	}
+
+	expectOutputs(t, "", tests)
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := map[string]ErrorType{
+		"json.parse `{`":                                        ArgumentError,
+		"json.parse 5":                                          TypeError,
+		"f x = x; json.stringify f":                             TypeError,
+		"json.stringify {1: 2}":                                 TypeError,
+		"json.stringify math.nan":                               TypeError,
+		"json.stringify {\"colour\": 1}, nil":                   ArgumentError,
+		"json.stringify {\"indent\": nil}, 1":                   TypeError,
+		"json.stringify 5, 1":                                   TypeError,
+		"json.stringify 1, 2, 3":                                ArgumentError,
+		"json.stringify {\"indent\": 4611686018427387904}, [1]": ArgumentError,
+	}
+
+	expectErrors(t, "", tests)
+}
+
+func TestFS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "radon")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var (
+		root    = filepath.Join(dir, "root")
+		outside = filepath.Join(dir, "outside")
+	)
+
+	for _, d := range []string{root, outside} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("shh"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A link to a missing file outside the root would be followed when writing.
+	if err := os.Symlink(filepath.Join(outside, "pwned"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	confined := func(code string) (object.Object, error) {
+		v := New()
+		v.FileRoot = root
+
+		return runIn(v, code)
+	}
+
+	// Each step depends on the ones before it, so they're run in order.
+	steps := []struct {
+		code, expected string
+	}{
+		{`fs.write "a.txt", "hello"`, "nil"},
+		{`fs.read "a.txt"`, `"hello"`},
+		{`" world" |> fs.append "a.txt"; fs.read "/a.txt"`, `"hello world"`},
+		{`fs.write-bytes "b.bin", [0, 104, 255]`, "nil"},
+		{`fs.read-bytes "b.bin"`, "[0, 104, 255]"},
+		{`fs.exists? "a.txt"`, "true"},
+		{`fs.exists? "c.txt"`, "false"},
+		{`fs.mkdir "sub/deeper"; fs.write "sub/x.txt", "x"; fs.list "sub"`, `["deeper", "x.txt"]`},
+		{`fs.glob "*.txt"`, `["a.txt"]`},
+		{`fs.glob "sub/*"`, `["sub/deeper", "sub/x.txt"]`},
+		{`fs.glob "*/*"`, `["sub/deeper", "sub/x.txt"]`},
+		{`fs.glob "escape*"`, `[]`},
+		{`st = fs.stat "a.txt"; [st["name"]]`, `["a.txt"]`},
+		{`(fs.stat "a.txt")["size"]`, "11"},
+		{`(fs.stat "sub")["dir?"]`, "true"},
+		{`fs.read "../../a.txt"`, `"hello world"`},
+		{`fs.remove "a.txt"; fs.exists? "a.txt"`, "false"},
+	}
+
+	for _, step := range steps {
+		result, err := confined(step.code)
+		if err != nil {
+			t.Errorf("%s: %s\n", step.code, err)
+			continue
+		}
+
+		if result == nil || result.String() != step.expected {
+			t.Errorf("%s: expected %s, got %v\n", step.code, step.expected, result)
+		}
+	}
+
+	errors := map[string]ErrorType{
+		`fs.read "missing.txt"`:         IOError,
+		`fs.read "escape/secret"`:       IOError,
+		`fs.write "escape/new", "x"`:    IOError,
+		`fs.write "dangling", "x"`:      IOError,
+		`fs.append "dangling", "x"`:     IOError,
+		`fs.mkdir "dangling/sub"`:       IOError,
+		`fs.list "sub/x.txt"`:           IOError,
+		`fs.remove "sub"`:               IOError,
+		`fs.read 5`:                     TypeError,
+		`fs.write "a.txt", 5`:           TypeError,
+		`fs.write-bytes "a.txt", [256]`: TypeError,
+		`fs.glob "["`:                   ArgumentError,
+		`fs.read "a", "b"`:              ArgumentError,
+	}
+
+	for test, expected := range errors {
+		_, err := confined(test)
+		if e, ok := err.(*Error); !ok || e.Type != expected {
+			t.Errorf("%s: expected a %s error, got %v\n", test, expected, err)
+		}
+	}
+
+	for _, name := range []string{"new", "pwned"} {
+		if _, err := os.Stat(filepath.Join(outside, name)); err == nil {
+			t.Errorf("%s was written outside of the file root\n", name)
+		}
+	}
+
+	// An empty root could be removed, so removing the root is refused.
+	empty := filepath.Join(dir, "empty")
+	if err := os.Mkdir(empty, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{`fs.remove "."`, `fs.remove ""`, `fs.remove "/"`, `fs.remove "sub/.."`} {
+		v := New()
+		v.FileRoot = empty
+
+		_, err := runIn(v, code)
+		if e, ok := err.(*Error); !ok || e.Type != IOError {
+			t.Errorf("%s: expected an IO error, got %v\n", code, err)
+		}
+	}
+
+	if _, err := os.Stat(empty); err != nil {
+		t.Errorf("the file root was removed: %s\n", err)
+	}
+
+	// The root only confines the virtual machine it's set on.
+	code := fmt.Sprintf("fs.read %q", filepath.Join(outside, "secret"))
+	if result, err := run(code); err != nil || result.String() != `"shh"` {
+		t.Errorf("%s: expected \"shh\", got %v (%v)\n", code, result, err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	tests := map[string]string{
+		`path.join "a", "b", "../c.txt"`: `"a/c.txt"`,
+		`path.join ()`:                   `""`,
+		`path.split "a/b/c.txt"`:         `("a/b/", "c.txt")`,
+		`path.base "a/b/c.txt"`:          `"c.txt"`,
+		`path.dir "a/b/c.txt"`:           `"a/b"`,
+		`path.ext "a/b/c.tar.gz"`:        `".gz"`,
+		`path.clean "a//b/./../c"`:       `"a/c"`,
+		`path.absolute? "/a"`:            "true",
+		`path.absolute? "a"`:             "false",
+	}
+
+	expectOutputs(t, "", tests)
+
+	for _, test := range []string{`path.base 5`, `path.join "a", nil`, `path.dir "a", "b"`} {
+		if _, err := run(test); err == nil {
+			t.Errorf("%s: expected an error\n", test)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		code, input, expected, output string
+	}{
+		{`input "name? "`, "Ann\nBob\n", `"Ann"`, "name? "},
+		{`a = input (); b = input (); [a, b]`, "Ann\r\nBob", `["Ann", "Bob"]`, ""},
+		{`input ()`, "", "nil", ""},
+		{`input "x"; input "y"`, "1\n", "nil", "xy"},
+		{`xs = []; for l in lines stdin do xs = xs + [l] end; xs`, "a\nb\n\nc", `["a", "b", "", "c"]`, ""},
+		{`first = input (); rest = []; for l in stdin do rest = rest + [l] end; (first, rest)`, "a\nb\nc\n", `("a", ["b", "c"])`, ""},
+		{`type stdin`, "", `"stream"`, ""},
+		{`lines "a\nb\n"`, "", `["a", "b"]`, ""},
+		{`lines ""`, "", "[]", ""},
+		{`args`, "", `["-v", "file.txt"]`, ""},
+		{`len args`, "", "2", ""},
+		{`env "HOME"`, "", `"/home/test"`, ""},
+		{`env "MISSING"`, "", "nil", ""},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+
+		v := New()
+		v.In = strings.NewReader(test.input)
+		v.Out = &out
+		v.Args = []string{"-v", "file.txt"}
+		v.Env = func(name string) (string, bool) {
+			if name == "HOME" {
+				return "/home/test", true
+			}
+
+			return "", false
+		}
+
+		result, err := runIn(v, test.code)
+		if err != nil {
+			t.Errorf("%s: %s\n", test.code, err)
+			continue
+		}
+
+		if result == nil || result.String() != test.expected {
+			t.Errorf("%s: expected %s, got %v\n", test.code, test.expected, result)
+		}
+
+		if out.String() != test.output {
+			t.Errorf("%s: expected the output %q, got %q\n", test.code, test.output, out.String())
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	tests := map[string]string{
+		`print "a", 1, [2]`:            "a 1 [2]\n",
+		`put "a", "b"; put "c"`:        "a bc",
+		`print ()`:                     "\n",
+		`print "x"; input "> "; put 1`: "x\n> 1",
+	}
+
+	for test, expected := range tests {
+		var out bytes.Buffer
+
+		v := New()
+		v.In = strings.NewReader("")
+		v.Out = &out
+
+		if _, err := runIn(v, test); err != nil {
+			t.Errorf("%s: %s\n", test, err)
+			continue
+		}
+
+		if out.String() != expected {
+			t.Errorf("%s: expected the output %q, got %q\n", test, expected, out.String())
+		}
+	}
+}
+
+func TestProcessStore(t *testing.T) {
+	// Like the REPL, each line is run by the same virtual machine in the same store.
+	var (
+		store = NewStore(nil)
+		v     = New()
+	)
+
+	v.In = bufio.NewReader(strings.NewReader("a\nb\n"))
+	v.Args = []string{"x"}
+
+	lines := []struct {
+		code, expected string
+	}{
+		{`input = 5; args = 3; args`, "3"},
+		{`input`, "5"},
+		{`args`, "3"},
+		{`xs = []; for l in stdin do xs = xs + [l] end; xs`, `["a", "b"]`},
+		{`env = 1; stdin = 2; env + stdin`, "3"},
+		{`decimal-precision 5`, "28"},
+		{`(decimal 1) / 3`, "0.33333"},
+		{`random.seed 1; first = random.rand (); random.seed 1`, "nil"},
+		{`first == random.rand ()`, "true"},
+	}
+
+	for _, line := range lines {
+		v.Reset()
+
+		result, err := runInStore(v, store, line.code)
+		if err != nil {
+			t.Errorf("%s: %s\n", line.code, err)
+			continue
+		}
+
+		if result == nil || result.String() != line.expected {
+			t.Errorf("%s: expected %s, got %v\n", line.code, line.expected, result)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := map[string]int{
+		`exit 3`:                               3,
+		`exit ()`:                              0,
+		`f x = exit x; f 7; 5`:                 7,
+		`f m = exit 2; re.replace "a", f, "a"`: 2,
+		`for x in [1, 2, 3] do exit x end`:     1,
+	}
+
+	for test, expected := range tests {
+		_, err := run(test)
+
+		exit, ok := err.(*Exit)
+		if !ok {
+			t.Errorf("%s: expected an exit, got %v\n", test, err)
+			continue
+		}
+
+		if exit.Code != expected {
+			t.Errorf("%s: expected the exit code %d, got %d\n", test, expected, exit.Code)
+		}
+	}
+
+	errors := map[string]ErrorType{
+		`exit "a"`:                  TypeError,
+		`exit 1, 2`:                 ArgumentError,
+		`input 5`:                   TypeError,
+		`env 5`:                     TypeError,
+		`lines 5`:                   TypeError,
+		`exit 99999999999999999999`: ArgumentError,
+	}
+
+	for test, expected := range errors {
+		_, err := run(test)
+		if e, ok := err.(*Error); !ok || e.Type != expected {
+			t.Errorf("%s: expected a %s error, got %v\n", test, expected, err)
+		}
+	}
+}
+
+// expectOutputs runs each program in tests after prelude, and checks that it
+// evaluates to the expected output.
+func expectOutputs(t *testing.T, prelude string, tests map[string]string) {
+	t.Helper()
+
+	for test, expected := range tests {
+		result, err := run(prelude + test)
+		if err != nil {
+			t.Errorf("%s: %s\n", test, err)
+			continue
+		}
+
+		if result == nil || result.String() != expected {
+			t.Errorf("%s: expected %s, got %v\n", test, expected, result)
+		}
+	}
+}
+
+// expectErrors runs each program in tests after prelude, and checks that it
+// fails with the expected type of error.
+func expectErrors(t *testing.T, prelude string, tests map[string]ErrorType) {
+	t.Helper()
+
+	for test, expected := range tests {
+		_, err := run(prelude + test)
+		if e, ok := err.(*Error); !ok || e.Type != expected {
+			t.Errorf("%s: expected a %s error, got %v\n", test, expected, err)
+		}
+	}
+}
+
+// expectErrorType runs each program in tests after prelude, and checks that it
+// fails with an error of type expected.
+func expectErrorType(t *testing.T, prelude string, tests []string, expected ErrorType) {
+	t.Helper()
+
+	for _, test := range tests {
+		_, err := run(prelude + test)
+		if e, ok := err.(*Error); !ok || e.Type != expected {
+			t.Errorf("%s: expected a %s error, got %v\n", test, expected, err)
+		}
+	}
+}
+
+func run(code string) (object.Object, error) {
+	return runWith(code, false)
+}
+
+// runWith runs code, enforcing annotations if enforce is true.
+func runWith(code string, enforce bool) (object.Object, error) {
+	v := New()
+	v.EnforceAnnotations = enforce
+
+	return runIn(v, code)
+}
+
+// runIn runs code in the virtual machine v.
+func runIn(v *VM, code string) (object.Object, error) {
+	return runInStore(v, NewStore(nil), code)
+}
+
+// runInStore runs code in the virtual machine v, in store.
+func runInStore(v *VM, store *Store, code string) (object.Object, error) {
+	var (
+		l         = lexer.Lexer(code, "test")
+		p         = parser.New(l)
+		prog, err = p.Parse()
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := compiler.New()
+	if err := c.Compile(prog); err != nil {
+		return nil, err
+	}
+
+	parsedCode, err := bytecode.Read(bytes.NewReader(c.Bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	v.PushFrame(v.MakeFrame(parsedCode, nil, store, c.Constants, c.Names, c.Jumps))
+
+	return v.Run()
+}
@@ -23,25 +23,29 @@ func (t *Token) String() string {
 // Keywords maps all possible keyword literals to their
 // corresponding token types
 var Keywords = map[string]Type{
-	"return": Return,
-	"true":   True,
-	"false":  False,
-	"nil":    Nil,
-	"if":     If,
-	"then":   Then,
-	"else":   Else,
-	"while":  While,
-	"for":    For,
-	"next":   Next,
-	"break":  Break,
-	"match":  Match,
-	"model":  Model,
-	"where":  Where,
-	"import": Import,
-	"do":     Do,
-	"end":    End,
-	"in":     In,
-	"export": Export,
+	"return":     Return,
+	"true":       True,
+	"false":      False,
+	"nil":        Nil,
+	"if":         If,
+	"then":       Then,
+	"else":       Else,
+	"while":      While,
+	"for":        For,
+	"next":       Next,
+	"break":      Break,
+	"match":      Match,
+	"model":      Model,
+	"protocol":   Protocol,
+	"implements": Implements,
+	"where":      Where,
+	"import":     Import,
+	"do":         Do,
+	"end":        End,
+	"in":         In,
+	"not":        Not,
+	"export":     Export,
+	"const":      Const,
 }
 
 // IsKeyword checks if a token type is a keyword type.
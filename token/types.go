@@ -12,6 +12,11 @@ const (
 	String  = "string"
 	ID      = "identifier"
 
+	// Malformed is used for a literal which couldn't be scanned properly,
+	// such as a string containing an unknown escape sequence. The literal
+	// of a Malformed token describes the problem.
+	Malformed = "malformed"
+
 	Plus           = "plus"
 	Minus          = "minus"
 	Star           = "star"
@@ -43,7 +48,10 @@ const (
 	LambdaArrow    = "lambda-arrow"
 	Colon          = "colon"
 	Dot            = "dot"
+	Ellipsis       = "ellipsis"
 	Bang           = "bang"
+	Pipe           = "pipe"
+	Apply          = "apply"
 	PlusEquals     = "assign-plus"
 	MinusEquals    = "assign-minus"
 	StarEquals     = "assign-star"
@@ -55,24 +63,30 @@ const (
 	AndEquals      = "assign-and"
 	BitOrEquals    = "assign-bitwise-or"
 	BitAndEquals   = "assign-bitwise-and"
+	InterpStart    = "interpolation-start"
+	InterpEnd      = "interpolation-end"
 
-	Return = "return"
-	True   = "true"
-	False  = "false"
-	Nil    = "nil"
-	If     = "if"
-	Then   = "then"
-	Else   = "else"
-	While  = "while"
-	For    = "for"
-	Do     = "do"
-	End    = "end"
-	Next   = "next"
-	Break  = "break"
-	Match  = "match"
-	Model  = "model"
-	Where  = "where"
-	Import = "import"
-	In     = "in"
-	Export = "export"
+	Return     = "return"
+	True       = "true"
+	False      = "false"
+	Nil        = "nil"
+	If         = "if"
+	Then       = "then"
+	Else       = "else"
+	While      = "while"
+	For        = "for"
+	Do         = "do"
+	End        = "end"
+	Next       = "next"
+	Break      = "break"
+	Match      = "match"
+	Model      = "model"
+	Protocol   = "protocol"
+	Implements = "implements"
+	Where      = "where"
+	Import     = "import"
+	In         = "in"
+	Not        = "not"
+	Export     = "export"
+	Const      = "const"
 )